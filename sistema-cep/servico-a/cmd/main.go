@@ -1,32 +1,238 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"servico-a/internal/admin"
+	"servico-a/internal/balancer"
+	"servico-a/internal/breaker"
+	"servico-a/internal/cache"
 	"servico-a/internal/config"
 	"servico-a/internal/handlers"
+	"servico-a/internal/health"
+	"servico-a/internal/hedge"
+	"servico-a/internal/logging"
+	"servico-a/internal/models"
+	"servico-a/internal/mtls"
+	"servico-a/internal/retry"
 	"servico-a/internal/server"
 	"servico-a/internal/services"
 	"servico-a/internal/telemetry"
 )
 
 func main() {
-	cfg := config.LoadConfig()
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "arquivo YAML de configuração (as variáveis de ambiente têm precedência)")
+	printConfig := flag.Bool("print-config", false, "exibe a configuração efetiva, com credenciais ocultadas, e encerra")
+	flag.Parse()
 
-	shutdown, err := telemetry.InitTracer("servico-a")
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Configuração inválida:\n%v", err)
+	}
+
+	if *printConfig {
+		if err := cfg.WriteRedacted(os.Stdout); err != nil {
+			log.Fatal("Erro ao exibir configuração: ", err)
+		}
+		return
+	}
+
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Fatal("Erro ao configurar o log: ", err)
+	}
+	logging.SetLevel(level)
+
+	shutdown, err := telemetry.InitTracer("servico-a", cfg.ZipkinEndpoint)
 	if err != nil {
 		log.Fatal("Erro ao inicializar telemetria: ", err)
 	}
 	defer shutdown()
 
-	serviceBClient := services.NewServiceBClient(cfg.ServiceBURL)
+	metricsHandler, shutdownMeter, err := telemetry.InitMeter("servico-a")
+	if err != nil {
+		log.Fatal("Erro ao inicializar métricas: ", err)
+	}
+	defer shutdownMeter()
+
+	var circuitBreaker *breaker.Breaker
+	if cfg.Breaker.Enabled {
+		circuitBreaker = breaker.New("servico-b", breaker.Settings{
+			ConsecutiveFailures: cfg.Breaker.ConsecutiveFailures,
+			FailureRatio:        cfg.Breaker.FailureRatio,
+			MinRequests:         cfg.Breaker.MinRequests,
+			Window:              cfg.Breaker.Window,
+			OpenTimeout:         cfg.Breaker.OpenTimeout,
+			HalfOpenMaxRequests: cfg.Breaker.HalfOpenMaxRequests,
+		})
+	}
+
+	retryPolicy := retry.Policy{
+		MaxAttempts:     cfg.Retry.MaxAttempts,
+		InitialBackoff:  cfg.Retry.InitialBackoff,
+		MaxBackoff:      cfg.Retry.MaxBackoff,
+		BudgetRatio:     cfg.Retry.BudgetRatio,
+		BudgetMaxTokens: cfg.Retry.BudgetMaxTokens,
+	}
+
+	// Com mTLS, as chamadas e os health checks do Serviço B apresentam o
+	// certificado do Serviço A, relido quando os arquivos mudam
+	var tlsReloader *mtls.Reloader
+	var healthCheckTransport http.RoundTripper
+	if cfg.ServiceBTLS.Enabled {
+		tlsReloader, err = mtls.NewReloader(mtls.Settings{
+			CertFile:   cfg.ServiceBTLS.CertFile,
+			KeyFile:    cfg.ServiceBTLS.KeyFile,
+			CAFile:     cfg.ServiceBTLS.CAFile,
+			ServerName: cfg.ServiceBTLS.ServerName,
+		})
+		if err != nil {
+			log.Fatal("Erro ao carregar os certificados TLS: ", err)
+		}
+		healthCheckTransport = tlsReloader.Transport()
+	}
+
+	endpoints, err := balancer.New("servico-b", cfg.ServiceBURLs, balancer.Settings{
+		Strategy:            balancer.Strategy(cfg.LoadBalancer.Strategy),
+		ConsecutiveFailures: cfg.LoadBalancer.OutlierConsecutiveFailures,
+		EjectionDuration:    cfg.LoadBalancer.OutlierEjectionDuration,
+		HealthCheckInterval: cfg.LoadBalancer.HealthCheckInterval,
+		HealthCheckTimeout:  cfg.LoadBalancer.HealthCheckTimeout,
+		Transport:           healthCheckTransport,
+	})
+	if err != nil {
+		log.Fatal("Erro ao configurar endpoints do Serviço B: ", err)
+	}
+	endpoints.Start()
+	defer endpoints.Close()
+
+	var hedger *hedge.Hedger
+	if cfg.Hedge.Enabled {
+		hedger = hedge.New(hedge.Policy{
+			Delay:      cfg.Hedge.Delay,
+			Percentile: cfg.Hedge.Percentile,
+			MaxRatio:   cfg.Hedge.MaxRatio,
+		})
+	}
+
+	var serviceBClient *services.ServiceBClient
+	switch cfg.ServiceBTransport {
+	case "http":
+		serviceBClient = services.NewServiceBClient(endpoints, circuitBreaker, hedger, retryPolicy, tlsReloader)
+	case "grpc":
+		serviceBClient = services.NewServiceBGRPCClient(endpoints, circuitBreaker, hedger, retryPolicy, cfg.ServiceBGRPCPort, tlsReloader)
+	default:
+		log.Fatalf("SERVICE_B_TRANSPORT inválido: %q (use http ou grpc)", cfg.ServiceBTransport)
+	}
+	defer serviceBClient.Close()
+
+	var responseCache *cache.Cache
+	if cfg.Cache.Enabled {
+		responseCache = cache.New(cache.Settings{
+			TTL:                  cfg.Cache.TTL,
+			StaleWhileRevalidate: cfg.Cache.StaleWhileRevalidate,
+			StaleIfError:         cfg.Cache.StaleIfError,
+			MaxEntries:           cfg.Cache.MaxEntries,
+		}, func(ctx context.Context, cep string) (*models.TemperatureResponse, error) {
+			return serviceBClient.ForwardCEPRequest(ctx, models.CEPRequest{CEP: cep})
+		})
+	}
+
+	cepHandler := handlers.NewCEPHandler(serviceBClient, responseCache)
+
+	// O serviço só está pronto com ao menos uma instância do Serviço B
+	// respondendo ao health check
+	checker := health.New("servico-a", health.Settings{
+		CacheTTL: cfg.Readiness.CacheTTL,
+		Timeout:  cfg.Readiness.Timeout,
+	}, health.Check{Name: "servico-b", Run: endpoints.Check})
+
+	// Com o admin habilitado, /metrics sai da porta pública
+	publicMetrics := metricsHandler
+	if cfg.Admin.Enabled {
+		publicMetrics = nil
+	}
+
+	srv, err := server.NewServer(cfg, cepHandler, publicMetrics, checker)
+	if err != nil {
+		log.Fatal("Erro ao configurar a autenticação: ", err)
+	}
+
+	// Recarrega a configuração no SIGHUP ou quando o arquivo muda
+	var adminServer *admin.Server
+	watcher := config.NewWatcher(*configPath, cfg, func(next *config.Config) error {
+		return reloadConfig(next, srv, serviceBClient, adminServer)
+	})
+	// O servidor administrativo reúne as métricas, o pprof e os endpoints
+	// registrados por cada subsistema
+	if cfg.Admin.Enabled {
+		registry := admin.NewRegistry()
+		registry.Handle("GET /metrics", "Métricas no formato Prometheus", metricsHandler)
+		admin.RegisterLogLevel(registry)
+		if cfg.Admin.Pprof {
+			admin.RegisterPprof(registry)
+		}
+		watcher.RegisterAdmin(registry)
+		srv.RegisterAdmin(registry)
+		endpoints.RegisterAdmin(registry)
+		if circuitBreaker != nil {
+			circuitBreaker.RegisterAdmin(registry)
+		}
+		if responseCache != nil {
+			responseCache.RegisterAdmin(registry)
+		}
 
-	cepHandler := handlers.NewCEPHandler(serviceBClient)
+		adminAddr := net.JoinHostPort(cfg.Admin.BindAddress, cfg.Admin.Port)
+		adminServer = admin.NewServer(adminAddr, cfg.Admin.Token, registry)
+		go func() {
+			if err := adminServer.Start(); err != nil {
+				log.Fatal("Erro ao iniciar o servidor administrativo: ", err)
+			}
+		}()
+		log.Printf("Admin disponível em %s", adminAddr)
+	}
 
-	srv := server.NewServer(cfg.Port, cepHandler)
+	// O watcher só começa depois que o servidor administrativo existe, já
+	// que as recargas atualizam o seu token
+	watchCtx, stopWatcher := context.WithCancel(context.Background())
+	defer stopWatcher()
+	go watcher.Run(watchCtx)
+	if tlsReloader != nil {
+		go tlsReloader.Run(watchCtx, cfg.ServiceBTLS.ReloadInterval)
+	}
 
-	log.Printf("Serviço A iniciado na porta %s com tracing habilitado", cfg.Port)
+	log.Printf("Serviço A iniciado na porta %s com tracing habilitado (Serviço B via %s)", cfg.Port, cfg.ServiceBTransport)
 
 	if err := srv.Start(); err != nil {
 		log.Fatal("Erro ao iniciar o servidor: ", err)
 	}
 }
+
+// reloadConfig aplica a nova configuração aos componentes. As etapas que
+// podem falhar vêm antes das demais, e a troca das instâncias do Serviço B,
+// a última delas, não altera nada quando falha; assim uma recarga rejeitada
+// não altera nenhum componente
+func reloadConfig(next *config.Config, srv *server.Server, serviceBClient *services.ServiceBClient, adminServer *admin.Server) error {
+	level, err := logging.ParseLevel(next.LogLevel)
+	if err != nil {
+		return err
+	}
+	applyServer, err := srv.PrepareReload(next)
+	if err != nil {
+		return err
+	}
+	if err := serviceBClient.SetEndpoints(next.ServiceBURLs); err != nil {
+		return err
+	}
+
+	applyServer()
+	logging.SetLevel(level)
+	if adminServer != nil {
+		adminServer.SetToken(next.Admin.Token)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"servico-a/internal/balancer"
+	"servico-a/internal/config"
+	"servico-a/internal/health"
+	"servico-a/internal/logging"
+	"servico-a/internal/retry"
+	"servico-a/internal/server"
+	"servico-a/internal/services"
+)
+
+func TestReloadConfig(t *testing.T) {
+	defer logging.SetLevel(logging.CurrentLevel())
+
+	current := []string{"http://servico-b-1:8081"}
+	next := []string{"http://servico-b-2:8081", "http://servico-b-3:8081"}
+
+	tests := []struct {
+		name              string
+		modify            func(cfg *config.Config)
+		expectErr         bool
+		expectedEndpoints []string
+		expectedLevel     logging.Level
+	}{
+		{
+			name:              "Configuração válida",
+			modify:            func(cfg *config.Config) {},
+			expectedEndpoints: next,
+			expectedLevel:     logging.LevelDebug,
+		},
+		{
+			name: "Falha na autenticação",
+			modify: func(cfg *config.Config) {
+				cfg.Auth.APIKeys = []config.APIKeyConfig{{Owner: "sem-id"}}
+			},
+			expectErr:         true,
+			expectedEndpoints: current,
+			expectedLevel:     logging.LevelInfo,
+		},
+		{
+			name: "Nível de log inválido",
+			modify: func(cfg *config.Config) {
+				cfg.LogLevel = "verbose"
+			},
+			expectErr:         true,
+			expectedEndpoints: current,
+			expectedLevel:     logging.LevelInfo,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logging.SetLevel(logging.LevelInfo)
+
+			cfg := config.Default()
+			cfg.ServiceBURLs = current
+			endpoints, err := balancer.New("servico-b", cfg.ServiceBURLs, balancer.Settings{})
+			if err != nil {
+				t.Fatalf("balancer.New() err = %v", err)
+			}
+			client := services.NewServiceBClient(endpoints, nil, nil, retry.Policy{}, nil)
+			defer client.Close()
+			srv, err := server.NewServer(cfg, nil, nil, health.New("servico-a", health.Settings{}))
+			if err != nil {
+				t.Fatalf("NewServer() err = %v", err)
+			}
+
+			reloaded := config.Default()
+			reloaded.ServiceBURLs = next
+			reloaded.LogLevel = "debug"
+			tt.modify(reloaded)
+
+			err = reloadConfig(reloaded, srv, client, nil)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("reloadConfig() err = %v, expectErr %v", err, tt.expectErr)
+			}
+			if got := endpoints.URLs(); !slices.Equal(got, tt.expectedEndpoints) {
+				t.Errorf("endpoints = %v, expected %v", got, tt.expectedEndpoints)
+			}
+			if got := logging.CurrentLevel(); got != tt.expectedLevel {
+				t.Errorf("CurrentLevel() = %v, expected %v", got, tt.expectedLevel)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"servico-a/internal/logging"
+	"servico-a/internal/problem"
+)
+
+// Endpoint descreve um endpoint administrativo registrado
+type Endpoint struct {
+	Pattern     string `json:"pattern"`
+	Description string `json:"description"`
+}
+
+// Registry reúne os endpoints administrativos contribuídos pelos
+// subsistemas. Os padrões seguem o http.ServeMux, como "POST /cache/flush",
+// e registrar o mesmo padrão duas vezes é um erro de programação. GET /
+// lista os endpoints registrados
+type Registry struct {
+	mux *http.ServeMux
+
+	mu        sync.Mutex
+	endpoints []Endpoint
+}
+
+// NewRegistry cria um registro vazio
+func NewRegistry() *Registry {
+	r := &Registry{mux: http.NewServeMux()}
+	r.mux.HandleFunc("GET /{$}", r.index)
+	return r
+}
+
+// Handle registra o handler no padrão informado
+func (r *Registry) Handle(pattern, description string, handler http.Handler) {
+	r.mux.Handle(pattern, handler)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endpoints = append(r.endpoints, Endpoint{Pattern: pattern, Description: description})
+}
+
+// HandleFunc registra a função no padrão informado
+func (r *Registry) HandleFunc(pattern, description string, handler func(http.ResponseWriter, *http.Request)) {
+	r.Handle(pattern, description, http.HandlerFunc(handler))
+}
+
+// Endpoints retorna os endpoints registrados, ordenados pelo path
+func (r *Registry) Endpoints() []Endpoint {
+	r.mu.Lock()
+	endpoints := append([]Endpoint(nil), r.endpoints...)
+	r.mu.Unlock()
+
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		return path(endpoints[i].Pattern) < path(endpoints[j].Pattern)
+	})
+	return endpoints
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mux.ServeHTTP(w, req)
+}
+
+// index lista os endpoints registrados
+func (r *Registry) index(w http.ResponseWriter, req *http.Request) {
+	WriteJSON(w, http.StatusOK, map[string]any{"endpoints": r.Endpoints()})
+}
+
+// path retorna o path do padrão, sem o método
+func path(pattern string) string {
+	if _, p, ok := strings.Cut(pattern, " "); ok {
+		return p
+	}
+	return pattern
+}
+
+// WriteJSON escreve a resposta JSON de um endpoint administrativo
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// Server é o servidor HTTP administrativo, separado da porta pública. Todas
+// as requisições exigem o token no header Authorization: Bearer
+type Server struct {
+	addr     string
+	registry *Registry
+	token    atomic.Pointer[[sha256.Size]byte]
+}
+
+// NewServer cria o servidor administrativo em addr para os endpoints do
+// registro
+func NewServer(addr, token string, registry *Registry) *Server {
+	s := &Server{addr: addr, registry: registry}
+	s.SetToken(token)
+	return s
+}
+
+// SetToken troca o token exigido nas próximas requisições. Apenas o hash é
+// mantido em memória
+func (s *Server) SetToken(token string) {
+	sum := sha256.Sum256([]byte(token))
+	s.token.Store(&sum)
+}
+
+// Handler retorna o handler com a autenticação aplicada
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "missing or invalid admin token")
+			return
+		}
+		// As ações administrativas ficam registradas no log
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			logging.Printf(r.Context(), "Admin: %s %s de %s", r.Method, r.URL.Path, r.RemoteAddr)
+		}
+		s.registry.ServeHTTP(w, r)
+	})
+}
+
+// authorized compara o token em tempo constante
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(token))
+	return subtle.ConstantTimeCompare(sum[:], s.token.Load()[:]) == 1
+}
+
+// Start inicia o servidor administrativo
+func (s *Server) Start() error {
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return srv.ListenAndServe()
+}
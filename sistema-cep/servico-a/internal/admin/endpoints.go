@@ -0,0 +1,47 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+
+	"servico-a/internal/logging"
+	"servico-a/internal/problem"
+)
+
+// RegisterPprof registra os perfis do net/http/pprof em /debug/pprof/
+func RegisterPprof(r *Registry) {
+	r.HandleFunc("GET /debug/pprof/", "Índice do pprof e perfis nomeados (heap, goroutine, allocs, block, mutex)", pprof.Index)
+	r.HandleFunc("GET /debug/pprof/cmdline", "Linha de comando do processo", pprof.Cmdline)
+	r.HandleFunc("GET /debug/pprof/profile", "Perfil de CPU pelo tempo de ?seconds=", pprof.Profile)
+	r.HandleFunc("GET /debug/pprof/symbol", "Resolução de endereços em símbolos", pprof.Symbol)
+	r.HandleFunc("POST /debug/pprof/symbol", "Resolução de endereços em símbolos", pprof.Symbol)
+	r.HandleFunc("GET /debug/pprof/trace", "Trace de execução pelo tempo de ?seconds=", pprof.Trace)
+}
+
+// logLevel é o corpo de /log-level
+type logLevel struct {
+	Level string `json:"level"`
+}
+
+// RegisterLogLevel registra a consulta e a troca do nível do log em tempo de
+// execução. O nível vale até a próxima recarga da configuração
+func RegisterLogLevel(r *Registry) {
+	r.HandleFunc("GET /log-level", "Nível atual do log", func(w http.ResponseWriter, req *http.Request) {
+		WriteJSON(w, http.StatusOK, logLevel{Level: logging.CurrentLevel().String()})
+	})
+	r.HandleFunc("PUT /log-level", `Troca o nível do log até a próxima recarga da configuração: {"level": "debug"}`, func(w http.ResponseWriter, req *http.Request) {
+		var body logLevel
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, 1024)).Decode(&body); err != nil {
+			problem.Write(w, req, http.StatusBadRequest, problem.CodeInvalidRequestBody, "invalid request body")
+			return
+		}
+		level, err := logging.ParseLevel(body.Level)
+		if err != nil {
+			problem.Write(w, req, http.StatusBadRequest, problem.CodeInvalidRequestBody, err.Error())
+			return
+		}
+		logging.SetLevel(level)
+		WriteJSON(w, http.StatusOK, logLevel{Level: level.String()})
+	})
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// HashPrefix identifica o algoritmo dos hashes de API keys armazenados
+const HashPrefix = "sha256:"
+
+var (
+	// ErrUnknownKey é retornado para API keys ausentes ou não cadastradas
+	ErrUnknownKey = errors.New("invalid API key")
+	// ErrKeyDisabled é retornado para API keys cadastradas e desabilitadas
+	ErrKeyDisabled = errors.New("API key disabled")
+)
+
+// Key representa uma API key cadastrada. Apenas o hash da chave é
+// armazenado, no formato sha256:<hex>
+type Key struct {
+	ID      string
+	Owner   string
+	Hash    string
+	Enabled bool
+	// RequestsPerSecond e Burst definem o rate limiting próprio da chave.
+	// Zero usa o limite global
+	RequestsPerSecond float64
+	Burst             int
+	// AllowedEndpoints lista os caminhos, como padrões de path.Match, que a
+	// chave pode chamar. Vazio permite todos
+	AllowedEndpoints []string
+}
+
+// Allows indica se a chave pode chamar o caminho
+func (k *Key) Allows(urlPath string) bool {
+	if len(k.AllowedEndpoints) == 0 {
+		return true
+	}
+	for _, pattern := range k.AllowedEndpoints {
+		if ok, _ := path.Match(pattern, urlPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Store guarda as API keys cadastradas indexadas pelo hash. As chaves são
+// geradas aleatoriamente com alta entropia, então um SHA-256 sem salt basta
+// para que o repositório não revele as chaves
+type Store struct {
+	keys map[[sha256.Size]byte]*Key
+}
+
+// NewStore cria o repositório a partir das chaves cadastradas, rejeitando
+// hashes inválidos e IDs repetidos
+func NewStore(keys []Key) (*Store, error) {
+	s := &Store{keys: make(map[[sha256.Size]byte]*Key, len(keys))}
+	ids := make(map[string]bool, len(keys))
+
+	for i := range keys {
+		key := keys[i]
+		if key.ID == "" {
+			return nil, errors.New("API key without ID")
+		}
+		if ids[key.ID] {
+			return nil, fmt.Errorf("duplicate API key ID %q", key.ID)
+		}
+		ids[key.ID] = true
+
+		sum, err := ParseHash(key.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("API key %q: %w", key.ID, err)
+		}
+		if _, ok := s.keys[sum]; ok {
+			return nil, fmt.Errorf("API key %q: hash already used by another key", key.ID)
+		}
+		s.keys[sum] = &key
+	}
+	return s, nil
+}
+
+// Hash retorna o hash da API key no formato armazenado
+func Hash(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return HashPrefix + hex.EncodeToString(sum[:])
+}
+
+// ParseHash converte o hash armazenado no formato sha256:<hex>
+func ParseHash(value string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+
+	digest, ok := strings.CutPrefix(value, HashPrefix)
+	if !ok {
+		return sum, fmt.Errorf("hash must start with %q", HashPrefix)
+	}
+	decoded, err := hex.DecodeString(digest)
+	if err != nil || len(decoded) != sha256.Size {
+		return sum, fmt.Errorf("hash must have %d hexadecimal digits", 2*sha256.Size)
+	}
+	copy(sum[:], decoded)
+	return sum, nil
+}
+
+// Authenticate identifica a chave apresentada pelo cliente. Chaves
+// desabilitadas retornam ErrKeyDisabled junto com a chave, para que o ID
+// possa ser registrado
+func (s *Store) Authenticate(apiKey string) (*Key, error) {
+	if apiKey == "" {
+		return nil, ErrUnknownKey
+	}
+
+	key, ok := s.keys[sha256.Sum256([]byte(apiKey))]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if !key.Enabled {
+		return key, ErrKeyDisabled
+	}
+	return key, nil
+}
+
+// Métodos de autenticação registrados em Identity.Method
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Identity identifica o cliente autenticado da requisição
+type Identity struct {
+	Method string
+	// KeyID é o ID da API key usada, nunca a chave em si
+	KeyID string
+	Owner string
+	// ClientID e Scopes vêm das claims do token JWT
+	ClientID string
+	Scopes   []string
+	// RequestsPerSecond e Burst são o rate limiting próprio do cliente.
+	// Zero usa o limite global
+	RequestsPerSecond float64
+	Burst             int
+}
+
+type contextKey struct{}
+
+// NewContext retorna uma cópia do contexto contendo a identidade do cliente
+func NewContext(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+// FromContext retorna a identidade do cliente autenticado, se houver
+func FromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(contextKey{}).(*Identity)
+	return identity, ok
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStoreAuthenticate(t *testing.T) {
+	store, err := NewStore([]Key{
+		{ID: "parceiro", Owner: "Parceiro", Hash: Hash("chave-parceiro"), Enabled: true},
+		{ID: "legado", Owner: "Legado", Hash: Hash("chave-legado"), Enabled: false},
+	})
+	if err != nil {
+		t.Fatalf("NewStore() err = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		apiKey  string
+		wantID  string
+		wantErr error
+	}{
+		{name: "Chave habilitada", apiKey: "chave-parceiro", wantID: "parceiro"},
+		{name: "Chave desabilitada retorna o ID", apiKey: "chave-legado", wantID: "legado", wantErr: ErrKeyDisabled},
+		{name: "Chave desconhecida", apiKey: "chave-parceiro-2", wantErr: ErrUnknownKey},
+		{name: "Chave ausente", apiKey: "", wantErr: ErrUnknownKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := store.Authenticate(tt.apiKey)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() err = %v, expected %v", err, tt.wantErr)
+			}
+			var id string
+			if key != nil {
+				id = key.ID
+			}
+			if id != tt.wantID {
+				t.Errorf("ID = %q, expected %q", id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestNewStoreRejectsInvalidKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []Key
+		wantErr string
+	}{
+		{
+			name:    "ID repetido",
+			keys:    []Key{{ID: "a", Hash: Hash("1")}, {ID: "a", Hash: Hash("2")}},
+			wantErr: "duplicate API key ID",
+		},
+		{
+			name:    "Hash repetido",
+			keys:    []Key{{ID: "a", Hash: Hash("1")}, {ID: "b", Hash: Hash("1")}},
+			wantErr: "hash already used",
+		},
+		{
+			name:    "Hash sem prefixo",
+			keys:    []Key{{ID: "a", Hash: strings.TrimPrefix(Hash("1"), HashPrefix)}},
+			wantErr: "must start with",
+		},
+		{
+			name:    "Hash truncado",
+			keys:    []Key{{ID: "a", Hash: Hash("1")[:20]}},
+			wantErr: "hexadecimal digits",
+		},
+		{
+			name:    "Chave sem ID",
+			keys:    []Key{{Hash: Hash("1")}},
+			wantErr: "without ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewStore(tt.keys); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewStore() err = %v, expected erro contendo %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		allowed  []string
+		path     string
+		expected bool
+	}{
+		{name: "Sem restrição", path: "/", expected: true},
+		{name: "Caminho exato", allowed: []string{"/"}, path: "/", expected: true},
+		{name: "Caminho não listado", allowed: []string{"/"}, path: "/v2/temperature", expected: false},
+		{name: "Padrão com curinga", allowed: []string{"/v2/*"}, path: "/v2/temperature", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := &Key{AllowedEndpoints: tt.allowed}
+			if result := key.Allows(tt.path); result != tt.expected {
+				t.Errorf("Allows(%q) = %v, expected %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"servico-a/internal/singleflight"
+)
+
+// minRefreshInterval limita as consultas ao JWKS, com sucesso ou não, para
+// que tokens forjados ou um emissor fora do ar não gerem uma consulta por
+// requisição
+const minRefreshInterval = 30 * time.Second
+
+// maxJWKSSize limita o tamanho do JWKS lido
+const maxJWKSSize = 1 << 20
+
+// jwk é uma chave pública no formato JSON Web Key (RFC 7517)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey é uma chave do JWKS pronta para verificar assinaturas
+type publicKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// KeySet mantém em cache as chaves públicas de um JWKS lido de um arquivo ou
+// de uma URL. As chaves são relidas após o TTL e, antes disso, quando chega
+// um token com kid desconhecido, o que acompanha a rotação de chaves do
+// emissor. Se a releitura falhar, as chaves anteriores continuam em uso.
+// A consulta é feita fora do lock e compartilhada pelas verificações
+// simultâneas
+type KeySet struct {
+	fetch    func(ctx context.Context) ([]byte, error)
+	ttl      time.Duration
+	now      func() time.Time
+	inflight *singleflight.Group[map[string]publicKey]
+
+	mu        sync.Mutex
+	keys      map[string]publicKey
+	fetchedAt time.Time
+	lastTry   time.Time
+	lastErr   error
+}
+
+// NewFileKeySet cria o KeySet do JWKS gravado em path
+func NewFileKeySet(path string, ttl time.Duration) *KeySet {
+	return newKeySet(func(ctx context.Context) ([]byte, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(io.LimitReader(f, maxJWKSSize))
+	}, ttl)
+}
+
+// NewURLKeySet cria o KeySet do JWKS publicado em url, consultado com client
+func NewURLKeySet(url string, ttl time.Duration, client *http.Client) *KeySet {
+	return newKeySet(func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	}, ttl)
+}
+
+func newKeySet(fetch func(ctx context.Context) ([]byte, error), ttl time.Duration) *KeySet {
+	return &KeySet{
+		fetch:    fetch,
+		ttl:      ttl,
+		now:      time.Now,
+		inflight: singleflight.New[map[string]publicKey]("KeySet.refresh"),
+	}
+}
+
+// key retorna a chave com o kid informado, relendo o JWKS quando o cache
+// expirou ou não contém o kid. A releitura só acontece após o intervalo
+// mínimo desde a anterior; antes disso valem as chaves em cache
+func (ks *KeySet) key(ctx context.Context, kid string) (publicKey, error) {
+	ks.mu.Lock()
+	now := ks.now()
+	key, found := ks.keys[kid]
+	expired := ks.keys == nil || now.Sub(ks.fetchedAt) >= ks.ttl
+	refresh := now.Sub(ks.lastTry) >= minRefreshInterval
+	ks.mu.Unlock()
+
+	if found && !expired {
+		return key, nil
+	}
+	if refresh {
+		// O erro fica em lastErr; com chaves anteriores, elas continuam valendo
+		ks.inflight.Do(ctx, "jwks", ks.refresh)
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if ks.keys == nil {
+		if ks.lastErr != nil {
+			return publicKey{}, ks.lastErr
+		}
+		return publicKey{}, errors.New("JWKS not loaded yet")
+	}
+	key, found = ks.keys[kid]
+	if !found {
+		return publicKey{}, fmt.Errorf("unknown key ID %q", kid)
+	}
+	return key, nil
+}
+
+// refresh relê o JWKS e troca as chaves em cache. O horário da tentativa é
+// registrado mesmo em caso de falha, limitando as novas consultas
+func (ks *KeySet) refresh(ctx context.Context) (map[string]publicKey, error) {
+	data, err := ks.fetch(ctx)
+	var keys map[string]publicKey
+	if err != nil {
+		err = fmt.Errorf("fetching JWKS: %w", err)
+	} else {
+		keys, err = parseJWKS(data)
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.lastTry = ks.now()
+	ks.lastErr = err
+	if err != nil {
+		return nil, err
+	}
+	ks.keys = keys
+	ks.fetchedAt = ks.lastTry
+	return keys, nil
+}
+
+// parseJWKS converte as chaves de assinatura do JWKS, ignorando as de
+// outros usos e tipos não suportados
+func parseJWKS(data []byte) (map[string]publicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]publicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = publicKey{alg: k.Alg, key: key}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no supported signing keys")
+	}
+	return keys, nil
+}
+
+// publicKey converte a JWK em uma chave RSA ou EC
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		if n.BitLen() < 2048 {
+			return nil, errors.New("RSA key shorter than 2048 bits")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeBigInt decodifica um inteiro em base64url, como n, e, x e y
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// loaded indica se alguma versão do JWKS já foi lida
+func (ks *KeySet) loaded() bool {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	return ks.keys != nil
+}
@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha512" // registra SHA-384 e SHA-512 para crypto.Hash
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken é retornado para tokens JWT malformados, com assinatura
+// inválida ou com claims que não conferem
+var ErrInvalidToken = errors.New("invalid bearer token")
+
+// signingMethod descreve um algoritmo JWS aceito
+type signingMethod struct {
+	hash crypto.Hash
+	// pss indica RSASSA-PSS; caso contrário RSA usa PKCS #1 v1.5
+	pss bool
+	// curveBits é o tamanho da curva dos algoritmos ECDSA
+	curveBits int
+}
+
+// signingMethods lista os algoritmos aceitos. Algoritmos simétricos (HS*) e
+// "none" nunca são aceitos, pois as chaves vêm de um JWKS público
+var signingMethods = map[string]signingMethod{
+	"RS256": {hash: crypto.SHA256},
+	"RS384": {hash: crypto.SHA384},
+	"RS512": {hash: crypto.SHA512},
+	"PS256": {hash: crypto.SHA256, pss: true},
+	"PS384": {hash: crypto.SHA384, pss: true},
+	"PS512": {hash: crypto.SHA512, pss: true},
+	"ES256": {hash: crypto.SHA256, curveBits: 256},
+	"ES384": {hash: crypto.SHA384, curveBits: 384},
+	"ES512": {hash: crypto.SHA512, curveBits: 521},
+}
+
+// VerifierSettings define o emissor e a audiência esperados nos tokens e a
+// tolerância a diferenças de relógio na validação de exp e nbf
+type VerifierSettings struct {
+	Issuer    string
+	Audience  string
+	ClockSkew time.Duration
+}
+
+// Verifier valida tokens JWT assinados com as chaves de um JWKS
+type Verifier struct {
+	keys     *KeySet
+	settings VerifierSettings
+	now      func() time.Time
+}
+
+// Claims são os dados do token usados para identificar o cliente
+type Claims struct {
+	Subject  string
+	ClientID string
+	Scopes   []string
+}
+
+// HasScope indica se o token concede o escopo
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// NewVerifier cria o verificador dos tokens assinados com as chaves de keys
+func NewVerifier(keys *KeySet, settings VerifierSettings) *Verifier {
+	return &Verifier{keys: keys, settings: settings, now: time.Now}
+}
+
+// Verify valida a assinatura, o emissor (iss), a audiência (aud) e a validade
+// (exp e nbf) do token e retorna as claims do cliente. Erros de validação
+// envolvem ErrInvalidToken; falhas ao obter o JWKS são retornadas como estão
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg  string   `json:"alg"`
+		Kid  string   `json:"kid"`
+		Crit []string `json:"crit"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	if len(header.Crit) > 0 {
+		return nil, fmt.Errorf("%w: unsupported critical header %v", ErrInvalidToken, header.Crit)
+	}
+	method, ok := signingMethods[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Alg)
+	}
+
+	key, err := v.keys.key(ctx, header.Kid)
+	if err != nil {
+		if v.keys.loaded() {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+		return nil, err
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, fmt.Errorf("%w: algorithm %q does not match the key", ErrInvalidToken, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	if err := method.verify(key.key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var claims struct {
+		Issuer    string          `json:"iss"`
+		Subject   string          `json:"sub"`
+		Audience  audience        `json:"aud"`
+		ExpiresAt *json.Number    `json:"exp"`
+		NotBefore *json.Number    `json:"nbf"`
+		ClientID  string          `json:"client_id"`
+		AZP       string          `json:"azp"`
+		Scope     string          `json:"scope"`
+		SCP       json.RawMessage `json:"scp"`
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+
+	if claims.Issuer != v.settings.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if !claims.Audience.contains(v.settings.Audience) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	now := v.now()
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	expiresAt, ok := numericDate(*claims.ExpiresAt)
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed exp", ErrInvalidToken)
+	}
+	if !now.Before(expiresAt.Add(v.settings.ClockSkew)) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if claims.NotBefore != nil {
+		notBefore, ok := numericDate(*claims.NotBefore)
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed nbf", ErrInvalidToken)
+		}
+		if now.Add(v.settings.ClockSkew).Before(notBefore) {
+			return nil, fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+		}
+	}
+
+	result := &Claims{Subject: claims.Subject, ClientID: claims.ClientID}
+	if result.ClientID == "" {
+		result.ClientID = claims.AZP
+	}
+	if result.ClientID == "" {
+		result.ClientID = claims.Subject
+	}
+	if result.ClientID == "" {
+		return nil, fmt.Errorf("%w: missing client_id, azp and sub", ErrInvalidToken)
+	}
+
+	result.Scopes = strings.Fields(claims.Scope)
+	if len(claims.SCP) > 0 {
+		var scp []string
+		if err := json.Unmarshal(claims.SCP, &scp); err != nil {
+			var single string
+			if err := json.Unmarshal(claims.SCP, &single); err != nil {
+				return nil, fmt.Errorf("%w: malformed scp", ErrInvalidToken)
+			}
+			scp = strings.Fields(single)
+		}
+		result.Scopes = append(result.Scopes, scp...)
+	}
+	return result, nil
+}
+
+// verify confere a assinatura do conteúdo com a chave pública
+func (m signingMethod) verify(key any, signed, signature []byte) error {
+	h := m.hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if m.curveBits != 0 {
+			return errors.New("algorithm does not match the key type")
+		}
+		if m.pss {
+			// RFC 7518: o salt do PSS tem o tamanho do hash
+			return rsa.VerifyPSS(key, m.hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		return rsa.VerifyPKCS1v15(key, m.hash, digest, signature)
+	case *ecdsa.PublicKey:
+		if m.curveBits == 0 || key.Curve.Params().BitSize != m.curveBits {
+			return errors.New("algorithm does not match the key type")
+		}
+		// A assinatura ECDSA do JWS é r || s, cada um com o tamanho da curva
+		size := (m.curveBits + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("malformed ECDSA signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errors.New("signature verification failed")
+		}
+		return nil
+	default:
+		return errors.New("unsupported key type")
+	}
+}
+
+// audience aceita a claim aud como texto ou como lista
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, aud := range a {
+		if aud == value {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeSegment decodifica um segmento base64url do token em JSON
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// numericDate converte uma data em segundos desde a época, possivelmente
+// fracionários, como exp e nbf
+func numericDate(n json.Number) (time.Time, bool) {
+	seconds, err := n.Float64()
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return time.Time{}, false
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second))), true
+}
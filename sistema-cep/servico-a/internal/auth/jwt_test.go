@@ -0,0 +1,441 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testKey é um par de chaves gerado no teste para assinar tokens
+type testKey struct {
+	kid    string
+	alg    string
+	signer crypto.Signer
+}
+
+func newRSAKey(t *testing.T, kid, alg string) testKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() err = %v", err)
+	}
+	return testKey{kid: kid, alg: alg, signer: key}
+}
+
+func newECKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() err = %v", err)
+	}
+	return testKey{kid: kid, alg: "ES256", signer: key}
+}
+
+// jwk retorna a chave pública no formato JWK
+func (k testKey) jwk() map[string]string {
+	encode := func(n *big.Int) string { return base64.RawURLEncoding.EncodeToString(n.Bytes()) }
+
+	switch pub := k.signer.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": k.kid, "use": "sig", "alg": k.alg, "n": encode(pub.N), "e": encode(big.NewInt(int64(pub.E)))}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": k.kid, "crv": "P-256", "x": encode(pub.X), "y": encode(pub.Y)}
+	}
+	return nil
+}
+
+// sign monta e assina o token com o header e as claims informados
+func (k testKey) sign(t *testing.T, header, claims map[string]any) string {
+	t.Helper()
+
+	segment := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("json.Marshal() err = %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := segment(header) + "." + segment(claims)
+
+	method := signingMethods[k.alg]
+	h := method.hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	var signature []byte
+	var err error
+	switch key := k.signer.(type) {
+	case *rsa.PrivateKey:
+		if method.pss {
+			signature, err = rsa.SignPSS(rand.Reader, key, method.hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, key, method.hash, digest)
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key, digest)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	if err != nil {
+		t.Fatalf("assinatura err = %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// token assina as claims com o header padrão da chave
+func (k testKey) token(t *testing.T, claims map[string]any) string {
+	return k.sign(t, map[string]any{"alg": k.alg, "kid": k.kid, "typ": "JWT"}, claims)
+}
+
+// writeJWKS grava o JWKS com as chaves públicas em um arquivo temporário
+func writeJWKS(t *testing.T, keys ...testKey) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwksJSON(keys...), 0o600); err != nil {
+		t.Fatalf("os.WriteFile() err = %v", err)
+	}
+	return path
+}
+
+func jwksJSON(keys ...testKey) []byte {
+	set := map[string][]map[string]string{"keys": {}}
+	for _, k := range keys {
+		set["keys"] = append(set["keys"], k.jwk())
+	}
+	data, _ := json.Marshal(set)
+	return data
+}
+
+func TestVerifierVerify(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	rs := newRSAKey(t, "rsa-1", "RS256")
+	ps := newRSAKey(t, "rsa-pss", "PS256")
+	es := newECKey(t, "ec-1")
+	other := newRSAKey(t, "rsa-1", "RS256")
+
+	verifier := NewVerifier(NewFileKeySet(writeJWKS(t, rs, ps, es), time.Hour), VerifierSettings{
+		Issuer:    "https://idp.example.com",
+		Audience:  "servico-a",
+		ClockSkew: 30 * time.Second,
+	})
+	verifier.now = func() time.Time { return now }
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"iss":       "https://idp.example.com",
+			"aud":       "servico-a",
+			"sub":       "user-1",
+			"client_id": "relatorios",
+			"scope":     "temperature:read profile",
+			"exp":       now.Add(time.Hour).Unix(),
+		}
+		for key, value := range overrides {
+			if value == nil {
+				delete(c, key)
+				continue
+			}
+			c[key] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		wantClientID string
+		wantScopes   []string
+		wantErr      string
+	}{
+		{
+			name:         "RS256 válido",
+			token:        rs.token(t, claims(nil)),
+			wantClientID: "relatorios",
+			wantScopes:   []string{"temperature:read", "profile"},
+		},
+		{
+			name:         "PS256 válido",
+			token:        ps.token(t, claims(nil)),
+			wantClientID: "relatorios",
+			wantScopes:   []string{"temperature:read", "profile"},
+		},
+		{
+			name:         "ES256 com scp em lista, azp e aud em lista",
+			token:        es.token(t, claims(map[string]any{"client_id": nil, "scope": nil, "azp": "painel", "scp": []string{"temperature:read"}, "aud": []string{"outro", "servico-a"}})),
+			wantClientID: "painel",
+			wantScopes:   []string{"temperature:read"},
+		},
+		{
+			name:         "Sem client_id e azp usa o sub",
+			token:        rs.token(t, claims(map[string]any{"client_id": nil})),
+			wantClientID: "user-1",
+			wantScopes:   []string{"temperature:read", "profile"},
+		},
+		{
+			name:         "Expirado dentro da tolerância do relógio",
+			token:        rs.token(t, claims(map[string]any{"exp": now.Add(-10 * time.Second).Unix()})),
+			wantClientID: "relatorios",
+			wantScopes:   []string{"temperature:read", "profile"},
+		},
+		{
+			name:    "Expirado",
+			token:   rs.token(t, claims(map[string]any{"exp": now.Add(-time.Minute).Unix()})),
+			wantErr: "token expired",
+		},
+		{
+			name:    "Sem exp",
+			token:   rs.token(t, claims(map[string]any{"exp": nil})),
+			wantErr: "missing exp",
+		},
+		{
+			name:    "Ainda não válido",
+			token:   rs.token(t, claims(map[string]any{"nbf": now.Add(time.Minute).Unix()})),
+			wantErr: "not valid yet",
+		},
+		{
+			name:    "Outro emissor",
+			token:   rs.token(t, claims(map[string]any{"iss": "https://outro.example.com"})),
+			wantErr: "unexpected issuer",
+		},
+		{
+			name:    "Outra audiência",
+			token:   rs.token(t, claims(map[string]any{"aud": "servico-b"})),
+			wantErr: "unexpected audience",
+		},
+		{
+			name:    "Assinado por outra chave com o mesmo kid",
+			token:   other.token(t, claims(nil)),
+			wantErr: "verification error",
+		},
+		{
+			name:    "Claims alteradas após a assinatura",
+			token:   tamper(rs.token(t, claims(nil)), claims(map[string]any{"scope": "admin"})),
+			wantErr: "verification error",
+		},
+		{
+			name:    "Algoritmo none",
+			token:   rs.sign(t, map[string]any{"alg": "none", "kid": "rsa-1"}, claims(nil)),
+			wantErr: "unsupported algorithm",
+		},
+		{
+			name:    "Algoritmo simétrico",
+			token:   rs.sign(t, map[string]any{"alg": "HS256", "kid": "rsa-1"}, claims(nil)),
+			wantErr: "unsupported algorithm",
+		},
+		{
+			name:    "Algoritmo diferente do cadastrado na chave",
+			token:   ps.sign(t, map[string]any{"alg": "RS256", "kid": "rsa-pss"}, claims(nil)),
+			wantErr: "does not match the key",
+		},
+		{
+			name:    "Header crítico desconhecido",
+			token:   rs.sign(t, map[string]any{"alg": "RS256", "kid": "rsa-1", "crit": []string{"exp"}}, claims(nil)),
+			wantErr: "critical header",
+		},
+		{
+			name:    "kid desconhecido",
+			token:   rs.sign(t, map[string]any{"alg": "RS256", "kid": "rsa-2"}, claims(nil)),
+			wantErr: "unknown key ID",
+		},
+		{
+			name:    "Token malformado",
+			token:   "abc.def",
+			wantErr: "malformed token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrInvalidToken) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Verify() err = %v, expected ErrInvalidToken contendo %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() err = %v", err)
+			}
+			if result.ClientID != tt.wantClientID {
+				t.Errorf("ClientID = %q, expected %q", result.ClientID, tt.wantClientID)
+			}
+			if strings.Join(result.Scopes, " ") != strings.Join(tt.wantScopes, " ") {
+				t.Errorf("Scopes = %v, expected %v", result.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
+
+// tamper troca as claims do token mantendo a assinatura original
+func tamper(token string, claims map[string]any) string {
+	parts := strings.Split(token, ".")
+	data, _ := json.Marshal(claims)
+	parts[1] = base64.RawURLEncoding.EncodeToString(data)
+	return strings.Join(parts, ".")
+}
+
+func TestKeySetRefresh(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	oldKey := newECKey(t, "antiga")
+	newKey := newECKey(t, "nova")
+
+	var fetches atomic.Int32
+	var jwks atomic.Pointer[[]byte]
+	var failing atomic.Bool
+	initial := jwksJSON(oldKey)
+	jwks.Store(&initial)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write(*jwks.Load())
+	}))
+	defer server.Close()
+
+	keys := NewURLKeySet(server.URL, 10*time.Minute, server.Client())
+	keys.now = func() time.Time { return now }
+	verifier := NewVerifier(keys, VerifierSettings{Issuer: "idp", Audience: "servico-a"})
+	verifier.now = keys.now
+
+	token := func(k testKey) string {
+		return k.token(t, map[string]any{"iss": "idp", "aud": "servico-a", "sub": "cliente", "exp": now.Add(time.Hour).Unix()})
+	}
+	verify := func(step string, k testKey, wantFetches int32) error {
+		t.Helper()
+		_, err := verifier.Verify(context.Background(), token(k))
+		if got := fetches.Load(); got != wantFetches {
+			t.Errorf("%s: consultas ao JWKS = %d, expected %d", step, got, wantFetches)
+		}
+		return err
+	}
+
+	if err := verify("primeiro token", oldKey, 1); err != nil {
+		t.Fatalf("primeiro token: err = %v", err)
+	}
+	if err := verify("chave em cache", oldKey, 1); err != nil {
+		t.Fatalf("chave em cache: err = %v", err)
+	}
+
+	// O emissor passa a publicar a nova chave: o kid desconhecido força a
+	// releitura antes do TTL, respeitado o intervalo mínimo entre consultas
+	now = now.Add(time.Minute)
+	rotated := jwksJSON(oldKey, newKey)
+	jwks.Store(&rotated)
+	if err := verify("kid novo após a rotação", newKey, 2); err != nil {
+		t.Fatalf("kid novo após a rotação: err = %v", err)
+	}
+
+	// Outro kid desconhecido logo em seguida não gera nova consulta
+	if err := verify("kid desconhecido repetido", newECKey(t, "forjada"), 2); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("kid desconhecido: err = %v, expected ErrInvalidToken", err)
+	}
+
+	// Após o TTL o JWKS é relido; se a releitura falhar, as chaves em cache
+	// continuam valendo
+	now = now.Add(11 * time.Minute)
+	failing.Store(true)
+	if err := verify("JWKS indisponível após o TTL", newKey, 3); err != nil {
+		t.Errorf("JWKS indisponível com cache: err = %v, expected chaves anteriores em uso", err)
+	}
+
+	// A falha também respeita o intervalo mínimo entre consultas
+	now = now.Add(time.Second)
+	if err := verify("JWKS indisponível logo após a falha", newKey, 3); err != nil {
+		t.Errorf("JWKS indisponível com cache: err = %v, expected chaves anteriores em uso", err)
+	}
+}
+
+func TestKeySetFailingIssuer(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	keys := NewURLKeySet(server.URL, time.Minute, server.Client())
+	var clock sync.Mutex
+	keys.now = func() time.Time {
+		clock.Lock()
+		defer clock.Unlock()
+		return now
+	}
+
+	// Verificações simultâneas antes do primeiro JWKS compartilham a consulta
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := keys.key(context.Background(), "ec-1"); err == nil {
+				t.Error("key() err = nil, expected erro de JWKS indisponível")
+			}
+		}()
+	}
+	for deadline := time.Now().Add(time.Second); fetches.Load() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("consultas ao JWKS = %d, expected 1", got)
+	}
+
+	// Antes do intervalo mínimo, a falha anterior é retornada sem nova consulta
+	for i := 0; i < 5; i++ {
+		if _, err := keys.key(context.Background(), "ec-1"); err == nil {
+			t.Error("key() err = nil, expected erro de JWKS indisponível")
+		}
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("consultas ao JWKS antes do intervalo = %d, expected 1", got)
+	}
+
+	clock.Lock()
+	now = now.Add(minRefreshInterval)
+	clock.Unlock()
+	keys.key(context.Background(), "ec-1")
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("consultas ao JWKS após o intervalo = %d, expected 2", got)
+	}
+}
+
+func TestKeySetUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	key := newECKey(t, "ec-1")
+	verifier := NewVerifier(NewURLKeySet(server.URL, time.Minute, server.Client()), VerifierSettings{Issuer: "idp", Audience: "servico-a"})
+
+	// Sem nenhum JWKS lido, a falha não é tratada como token inválido
+	_, err := verifier.Verify(context.Background(), key.token(t, map[string]any{"iss": "idp", "aud": "servico-a", "sub": "c", "exp": time.Now().Add(time.Hour).Unix()}))
+	if err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() err = %v, expected erro de JWKS indisponível", err)
+	}
+}
@@ -0,0 +1,50 @@
+package balancer
+
+import (
+	"net/http"
+	"time"
+
+	"servico-a/internal/admin"
+)
+
+// endpointState é o estado de um endpoint exibido no admin
+type endpointState struct {
+	URL                 string     `json:"url"`
+	Healthy             bool       `json:"healthy"`
+	EjectedUntil        *time.Time `json:"ejected_until,omitempty"`
+	Outstanding         int        `json:"outstanding"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+}
+
+// RegisterAdmin registra a consulta do estado dos endpoints
+func (b *Balancer) RegisterAdmin(r *admin.Registry) {
+	r.HandleFunc("GET /balancer", "Estado de cada instância do "+b.name+": health check, ejeção e requisições em andamento", func(w http.ResponseWriter, req *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, map[string]any{
+			"strategy":  b.Strategy(),
+			"endpoints": b.states(),
+		})
+	})
+}
+
+// states copia o estado dos endpoints
+func (b *Balancer) states() []endpointState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	states := make([]endpointState, 0, len(b.endpoints))
+	for _, endpoint := range b.endpoints {
+		state := endpointState{
+			URL:                 endpoint.URL,
+			Healthy:             endpoint.healthy,
+			Outstanding:         endpoint.outstanding,
+			ConsecutiveFailures: endpoint.consecutiveFailures,
+		}
+		if endpoint.ejectedUntil.After(now) {
+			ejectedUntil := endpoint.ejectedUntil
+			state.EjectedUntil = &ejectedUntil
+		}
+		states = append(states, state)
+	}
+	return states
+}
@@ -0,0 +1,441 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"servico-a/internal/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrNoEndpoints é retornado quando o balanceador não tem endpoints
+var ErrNoEndpoints = errors.New("no endpoints configured")
+
+// Strategy define como o próximo endpoint é escolhido
+type Strategy string
+
+const (
+	// StrategyRoundRobin alterna entre os endpoints disponíveis
+	StrategyRoundRobin Strategy = "round_robin"
+	// StrategyLeastOutstanding escolhe o endpoint com menos requisições em
+	// andamento
+	StrategyLeastOutstanding Strategy = "least_outstanding"
+)
+
+// Outcome indica como o resultado de uma chamada deve ser contabilizado
+type Outcome int
+
+const (
+	Success Outcome = iota
+	Failure
+	// Ignore descarta a chamada, por exemplo quando o cliente cancelou a
+	// requisição antes da resposta
+	Ignore
+)
+
+// Settings define a estratégia de balanceamento, a ejeção de endpoints com
+// falhas consecutivas e os health checks ativos. Valores zero desabilitam a
+// ejeção e os health checks
+type Settings struct {
+	Strategy            Strategy
+	ConsecutiveFailures int
+	EjectionDuration    time.Duration
+	HealthCheckInterval time.Duration
+	HealthCheckTimeout  time.Duration
+	HealthCheckPath     string
+	// Transport é usado nos health checks; nil usa o http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// Endpoint representa uma instância do serviço chamado
+type Endpoint struct {
+	URL string
+
+	outstanding         int
+	consecutiveFailures int
+	ejectedUntil        time.Time
+	healthy             bool
+}
+
+// Balancer distribui as chamadas entre os endpoints, evitando os que
+// falharam nos health checks ou foram ejetados por falhas consecutivas
+type Balancer struct {
+	name      string
+	settings  Settings
+	endpoints []*Endpoint
+	client    *http.Client
+	now       func() time.Time
+
+	mu   sync.Mutex
+	next int
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New cria um balanceador para as URLs base informadas
+func New(name string, urls []string, settings Settings) (*Balancer, error) {
+	if len(urls) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	switch settings.Strategy {
+	case "":
+		settings.Strategy = StrategyRoundRobin
+	case StrategyRoundRobin, StrategyLeastOutstanding:
+	default:
+		return nil, fmt.Errorf("unknown load balancing strategy %q", settings.Strategy)
+	}
+	if settings.HealthCheckPath == "" {
+		settings.HealthCheckPath = "/readyz"
+	}
+	if settings.HealthCheckTimeout <= 0 {
+		settings.HealthCheckTimeout = 2 * time.Second
+	}
+
+	endpoints, err := newEndpoints(urls, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Balancer{
+		name:      name,
+		settings:  settings,
+		endpoints: endpoints,
+		client:    &http.Client{Timeout: settings.HealthCheckTimeout, Transport: settings.Transport},
+		now:       time.Now,
+		stop:      make(chan struct{}),
+	}
+	b.registerMetrics()
+
+	return b, nil
+}
+
+// newEndpoints cria os endpoints das URLs, reaproveitando os existentes com
+// a mesma URL para manter o estado de ejeção, health check e requisições em
+// andamento
+func newEndpoints(urls []string, existing []*Endpoint) ([]*Endpoint, error) {
+	byURL := make(map[string]*Endpoint, len(existing))
+	for _, endpoint := range existing {
+		byURL[endpoint.URL] = endpoint
+	}
+
+	endpoints := make([]*Endpoint, 0, len(urls))
+	for _, raw := range urls {
+		parsed, err := url.Parse(raw)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint URL %q", raw)
+		}
+		u := strings.TrimSuffix(raw, "/")
+		if endpoint, ok := byURL[u]; ok {
+			endpoints = append(endpoints, endpoint)
+			continue
+		}
+		endpoints = append(endpoints, &Endpoint{URL: u, healthy: true})
+	}
+	return endpoints, nil
+}
+
+// Strategy retorna a estratégia de balanceamento em uso
+func (b *Balancer) Strategy() Strategy {
+	return b.settings.Strategy
+}
+
+// Len retorna a quantidade de endpoints configurados
+func (b *Balancer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.endpoints)
+}
+
+// SetEndpoints troca a lista de endpoints. Os endpoints mantidos preservam
+// seu estado; os novos começam disponíveis até o próximo health check.
+// Chamadas em andamento para endpoints removidos terminam normalmente. Em
+// caso de erro a lista atual não é alterada
+func (b *Balancer) SetEndpoints(urls []string) error {
+	if len(urls) == 0 {
+		return ErrNoEndpoints
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	endpoints, err := newEndpoints(urls, b.endpoints)
+	if err != nil {
+		return err
+	}
+	b.endpoints = endpoints
+	return nil
+}
+
+// URLs retorna as URLs dos endpoints configurados
+func (b *Balancer) URLs() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	urls := make([]string, len(b.endpoints))
+	for i, endpoint := range b.endpoints {
+		urls[i] = endpoint.URL
+	}
+	return urls
+}
+
+// Pick escolhe o endpoint da próxima chamada. A função retornada deve ser
+// chamada com o resultado da chamada. Os endpoints com URL em exclude, como
+// o da chamada original de um hedge, só são escolhidos se nenhum outro
+// estiver disponível. Se nenhum endpoint estiver disponível, todos são
+// considerados, evitando rejeitar todo o tráfego quando os health checks ou
+// a ejeção excluem todas as instâncias
+func (b *Balancer) Pick(ctx context.Context, exclude ...string) (*Endpoint, func(Outcome)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	candidates := b.candidates(func(endpoint *Endpoint) bool {
+		return endpoint.available(now) && !slices.Contains(exclude, endpoint.URL)
+	})
+	if len(candidates) == 0 && len(exclude) > 0 {
+		candidates = b.candidates(func(endpoint *Endpoint) bool { return endpoint.available(now) })
+	}
+	if len(candidates) == 0 {
+		candidates = b.endpoints
+		trace.SpanFromContext(ctx).AddEvent("load_balancer.no_available_endpoints", trace.WithAttributes(
+			attribute.String("load_balancer.name", b.name),
+		))
+	}
+
+	start := b.next % len(candidates)
+	b.next++
+
+	chosen := candidates[start]
+	if b.settings.Strategy == StrategyLeastOutstanding {
+		// Percorre a partir da posição do round-robin para distribuir os empates
+		for i := 1; i < len(candidates); i++ {
+			candidate := candidates[(start+i)%len(candidates)]
+			if candidate.outstanding < chosen.outstanding {
+				chosen = candidate
+			}
+		}
+	}
+	chosen.outstanding++
+
+	var once sync.Once
+	return chosen, func(outcome Outcome) {
+		once.Do(func() { b.record(ctx, chosen, outcome) })
+	}
+}
+
+// candidates retorna os endpoints que atendem ao filtro
+func (b *Balancer) candidates(filter func(*Endpoint) bool) []*Endpoint {
+	candidates := make([]*Endpoint, 0, len(b.endpoints))
+	for _, endpoint := range b.endpoints {
+		if filter(endpoint) {
+			candidates = append(candidates, endpoint)
+		}
+	}
+	return candidates
+}
+
+// record contabiliza o resultado de uma chamada e ejeta o endpoint ao
+// atingir o limite de falhas consecutivas
+func (b *Balancer) record(ctx context.Context, endpoint *Endpoint, outcome Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	endpoint.outstanding--
+
+	switch outcome {
+	case Success:
+		endpoint.consecutiveFailures = 0
+	case Failure:
+		endpoint.consecutiveFailures++
+		if b.settings.ConsecutiveFailures > 0 && endpoint.consecutiveFailures >= b.settings.ConsecutiveFailures {
+			endpoint.consecutiveFailures = 0
+			endpoint.ejectedUntil = b.now().Add(b.settings.EjectionDuration)
+
+			trace.SpanFromContext(ctx).AddEvent("load_balancer.endpoint_ejected", trace.WithAttributes(
+				attribute.String("load_balancer.name", b.name),
+				attribute.String("load_balancer.endpoint", endpoint.URL),
+			))
+			logging.Warnf(ctx, "Endpoint %s do %s ejetado por %v após %d falhas consecutivas",
+				endpoint.URL, b.name, b.settings.EjectionDuration, b.settings.ConsecutiveFailures)
+		}
+	}
+}
+
+// Start inicia os health checks periódicos, se habilitados
+func (b *Balancer) Start() {
+	if b.settings.HealthCheckInterval <= 0 {
+		return
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		ticker := time.NewTicker(b.settings.HealthCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			b.checkHealth(context.Background())
+
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close interrompe os health checks
+func (b *Balancer) Close() {
+	close(b.stop)
+	b.wg.Wait()
+}
+
+// checkHealth consulta o endpoint de health de todas as instâncias em paralelo
+func (b *Balancer) checkHealth(ctx context.Context) {
+	b.mu.Lock()
+	endpoints := b.endpoints
+	b.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, endpoint := range endpoints {
+		wg.Add(1)
+		go func(endpoint *Endpoint) {
+			defer wg.Done()
+			b.setHealthy(endpoint, b.probe(ctx, endpoint) == nil)
+		}(endpoint)
+	}
+	wg.Wait()
+}
+
+// Check consulta o health check de todas as instâncias em paralelo e
+// retorna nil se ao menos uma responder com sucesso. O estado usado no
+// balanceamento não é alterado
+func (b *Balancer) Check(ctx context.Context) error {
+	b.mu.Lock()
+	endpoints := b.endpoints
+	b.mu.Unlock()
+
+	errs := make([]string, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, endpoint *Endpoint) {
+			defer wg.Done()
+			if err := b.probe(ctx, endpoint); err != nil {
+				errs[i] = err.Error()
+			}
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("nenhuma instância do %s respondeu ao health check: %s", b.name, strings.Join(errs, "; "))
+}
+
+// probe consulta o health check do endpoint, retornando nil se ele
+// respondeu com sucesso
+func (b *Balancer) probe(ctx context.Context, endpoint *Endpoint) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.URL+b.settings.HealthCheckPath, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s respondeu %d", endpoint.URL+b.settings.HealthCheckPath, resp.StatusCode)
+	}
+	return nil
+}
+
+// setHealthy atualiza o resultado do health check, registrando as mudanças
+func (b *Balancer) setHealthy(endpoint *Endpoint, healthy bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if endpoint.healthy == healthy {
+		return
+	}
+	endpoint.healthy = healthy
+
+	if healthy {
+		logging.Printf(context.Background(), "Endpoint %s do %s voltou a responder ao health check", endpoint.URL, b.name)
+	} else {
+		logging.Warnf(context.Background(), "Endpoint %s do %s falhou no health check", endpoint.URL, b.name)
+	}
+}
+
+// available indica se o endpoint pode receber chamadas
+func (e *Endpoint) available(now time.Time) bool {
+	return e.healthy && !now.Before(e.ejectedUntil)
+}
+
+// registerMetrics publica a disponibilidade e as requisições em andamento
+// de cada endpoint como gauges
+func (b *Balancer) registerMetrics() {
+	meter := otel.Meter("servico-a")
+
+	available, err := meter.Int64ObservableGauge(
+		"load_balancer.endpoint.available",
+		metric.WithDescription("Disponibilidade do endpoint (1 = disponível, 0 = ejetado ou sem health check)"),
+	)
+	if err != nil {
+		otel.Handle(err)
+		return
+	}
+	outstanding, err := meter.Int64ObservableGauge(
+		"load_balancer.endpoint.outstanding",
+		metric.WithDescription("Requisições em andamento por endpoint"),
+	)
+	if err != nil {
+		otel.Handle(err)
+		return
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		now := b.now()
+		for _, endpoint := range b.endpoints {
+			attrs := metric.WithAttributes(
+				attribute.String("load_balancer.name", b.name),
+				attribute.String("load_balancer.endpoint", endpoint.URL),
+			)
+			var value int64
+			if endpoint.available(now) {
+				value = 1
+			}
+			o.ObserveInt64(available, value, attrs)
+			o.ObserveInt64(outstanding, int64(endpoint.outstanding), attrs)
+		}
+		return nil
+	}, available, outstanding)
+	if err != nil {
+		otel.Handle(err)
+	}
+}
@@ -0,0 +1,260 @@
+package balancer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPickRoundRobin(t *testing.T) {
+	b, err := New("test", []string{"http://a:8081", "http://b:8081/", "http://c:8081"}, Settings{})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	expected := []string{"http://a:8081", "http://b:8081", "http://c:8081", "http://a:8081"}
+	for i, want := range expected {
+		endpoint, done := b.Pick(context.Background())
+		done(Success)
+		if endpoint.URL != want {
+			t.Errorf("escolha %d = %s, expected %s", i+1, endpoint.URL, want)
+		}
+	}
+}
+
+func TestPickLeastOutstanding(t *testing.T) {
+	b, err := New("test", []string{"http://a:8081", "http://b:8081"}, Settings{Strategy: StrategyLeastOutstanding})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	// A primeira chamada fica em andamento em a; as seguintes vão para b
+	// enquanto a estiver ocupado
+	first, _ := b.Pick(context.Background())
+	for i := 0; i < 3; i++ {
+		endpoint, done := b.Pick(context.Background())
+		if endpoint == first {
+			t.Fatalf("escolha %d usou o endpoint ocupado %s", i+2, endpoint.URL)
+		}
+		done(Success)
+	}
+}
+
+func TestPickExclude(t *testing.T) {
+	for _, strategy := range []Strategy{StrategyRoundRobin, StrategyLeastOutstanding} {
+		t.Run(string(strategy), func(t *testing.T) {
+			b, err := New("test", []string{"http://a:8081", "http://b:8081", "http://c:8081"}, Settings{Strategy: strategy})
+			if err != nil {
+				t.Fatalf("New() err = %v", err)
+			}
+
+			// Com outros endpoints disponíveis, o excluído nunca é escolhido
+			for i := 0; i < 6; i++ {
+				endpoint, done := b.Pick(context.Background(), "http://a:8081")
+				done(Success)
+				if endpoint.URL == "http://a:8081" {
+					t.Fatalf("escolha %d usou o endpoint excluído", i+1)
+				}
+			}
+
+			// Sem alternativa, o endpoint excluído ainda é usado
+			single, err := New("test", []string{"http://a:8081"}, Settings{Strategy: strategy})
+			if err != nil {
+				t.Fatalf("New() err = %v", err)
+			}
+			endpoint, done := single.Pick(context.Background(), "http://a:8081")
+			done(Success)
+			if endpoint.URL != "http://a:8081" {
+				t.Errorf("escolha = %s, expected http://a:8081", endpoint.URL)
+			}
+		})
+	}
+}
+
+func TestOutlierEjection(t *testing.T) {
+	now := time.Now()
+	b, err := New("test", []string{"http://a:8081", "http://b:8081"}, Settings{
+		ConsecutiveFailures: 2,
+		EjectionDuration:    30 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+	b.now = func() time.Time { return now }
+
+	// Duas falhas consecutivas em a o ejetam
+	for i := 0; i < 4; i++ {
+		endpoint, done := b.Pick(context.Background())
+		if endpoint.URL == "http://a:8081" {
+			done(Failure)
+		} else {
+			done(Success)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		endpoint, done := b.Pick(context.Background())
+		done(Success)
+		if endpoint.URL != "http://b:8081" {
+			t.Errorf("escolha %d = %s com a ejetado", i+1, endpoint.URL)
+		}
+	}
+
+	// Após a duração da ejeção, a volta a receber chamadas
+	now = now.Add(31 * time.Second)
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		endpoint, done := b.Pick(context.Background())
+		done(Success)
+		seen[endpoint.URL] = true
+	}
+	if !seen["http://a:8081"] {
+		t.Error("endpoint a não voltou após a ejeção")
+	}
+}
+
+func TestPickWithoutAvailableEndpoints(t *testing.T) {
+	b, err := New("test", []string{"http://a:8081"}, Settings{
+		ConsecutiveFailures: 1,
+		EjectionDuration:    time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	_, done := b.Pick(context.Background())
+	done(Failure)
+
+	// Com todos os endpoints ejetados, as chamadas continuam sendo feitas
+	endpoint, done := b.Pick(context.Background())
+	done(Success)
+	if endpoint.URL != "http://a:8081" {
+		t.Errorf("escolha = %s, expected http://a:8081", endpoint.URL)
+	}
+}
+
+func TestSetEndpoints(t *testing.T) {
+	b, err := New("test", []string{"http://a:8081", "http://b:8081"}, Settings{
+		ConsecutiveFailures: 1,
+		EjectionDuration:    time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+
+	// Ejeta a antes da troca para verificar que o estado é mantido
+	endpoint, done := b.Pick(context.Background())
+	if endpoint.URL != "http://a:8081" {
+		t.Fatalf("escolha = %s, expected http://a:8081", endpoint.URL)
+	}
+	done(Failure)
+
+	if err := b.SetEndpoints([]string{"http://a:8081/", "http://c:8081"}); err != nil {
+		t.Fatalf("SetEndpoints() err = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		endpoint, done := b.Pick(context.Background())
+		done(Success)
+		if endpoint.URL != "http://c:8081" {
+			t.Errorf("escolha %d = %s, expected http://c:8081 com a ejetado e b removido", i+1, endpoint.URL)
+		}
+	}
+
+	// Listas inválidas não alteram os endpoints atuais
+	for _, urls := range [][]string{nil, {"http://d:8081", "d:8081"}} {
+		if err := b.SetEndpoints(urls); err == nil {
+			t.Errorf("SetEndpoints(%v) err = nil, expected erro", urls)
+		}
+	}
+	if got := b.URLs(); len(got) != 2 || got[0] != "http://a:8081" || got[1] != "http://c:8081" {
+		t.Errorf("URLs() = %v, expected [http://a:8081 http://c:8081]", got)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" {
+			t.Errorf("health check em %s, expected /readyz", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unhealthy.Close()
+
+	b, err := New("test", []string{unhealthy.URL, healthy.URL}, Settings{})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+	b.checkHealth(context.Background())
+
+	for i := 0; i < 3; i++ {
+		endpoint, done := b.Pick(context.Background())
+		done(Success)
+		if endpoint.URL != healthy.URL {
+			t.Errorf("escolha %d = %s, expected %s", i+1, endpoint.URL, healthy.URL)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unhealthy.Close()
+
+	b, err := New("servico-b", []string{unhealthy.URL, healthy.URL}, Settings{})
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+	if err := b.Check(context.Background()); err != nil {
+		t.Errorf("Check() com uma instância disponível err = %v", err)
+	}
+
+	if err := b.SetEndpoints([]string{unhealthy.URL}); err != nil {
+		t.Fatalf("SetEndpoints() err = %v", err)
+	}
+	err = b.Check(context.Background())
+	if err == nil || !strings.Contains(err.Error(), unhealthy.URL+"/readyz respondeu 503") {
+		t.Errorf("Check() sem instâncias disponíveis err = %v", err)
+	}
+
+	// O estado do balanceamento não é alterado pela verificação
+	endpoint, done := b.Pick(context.Background())
+	done(Ignore)
+	if !endpoint.healthy {
+		t.Error("Check() marcou a instância como indisponível")
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		urls     []string
+		settings Settings
+	}{
+		{name: "Sem endpoints", urls: nil},
+		{name: "URL sem host", urls: []string{"servico-b:8081"}},
+		{name: "Estratégia desconhecida", urls: []string{"http://a:8081"}, settings: Settings{Strategy: "random"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New("test", tt.urls, tt.settings); err == nil {
+				t.Error("New() err = nil, expected erro")
+			}
+		})
+	}
+}
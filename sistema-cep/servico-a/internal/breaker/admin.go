@@ -0,0 +1,26 @@
+package breaker
+
+import (
+	"context"
+	"net/http"
+
+	"servico-a/internal/admin"
+)
+
+// RegisterAdmin registra a consulta do estado do circuito
+func (b *Breaker) RegisterAdmin(r *admin.Registry) {
+	r.HandleFunc("GET /breaker", "Estado do circuit breaker do "+b.name+" e contadores da janela atual", func(w http.ResponseWriter, req *http.Request) {
+		b.mu.Lock()
+		state, _ := b.currentState(context.Background(), b.now())
+		body := map[string]any{
+			"name":                 b.name,
+			"state":                state.String(),
+			"requests":             b.requests,
+			"failures":             b.failures,
+			"consecutive_failures": b.consecutiveFailures,
+		}
+		b.mu.Unlock()
+
+		admin.WriteJSON(w, http.StatusOK, body)
+	})
+}
@@ -0,0 +1,263 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"servico-a/internal/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrOpen é retornado quando o circuito está aberto e a chamada é rejeitada
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State representa o estado do circuito
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+// String retorna o nome do estado
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// Outcome indica como o resultado de uma chamada deve ser contabilizado
+type Outcome int
+
+const (
+	Success Outcome = iota
+	Failure
+	// Ignore descarta a chamada, por exemplo quando o cliente cancelou a
+	// requisição antes da resposta
+	Ignore
+)
+
+// Settings define as políticas de abertura do circuito. O circuito abre
+// quando qualquer uma das políticas habilitadas (valor maior que zero) é
+// atingida dentro da janela
+type Settings struct {
+	ConsecutiveFailures int
+	FailureRatio        float64
+	MinRequests         int
+	Window              time.Duration
+	OpenTimeout         time.Duration
+	HalfOpenMaxRequests int
+}
+
+// Breaker implementa um circuit breaker com os estados fechado, aberto e
+// meio-aberto
+type Breaker struct {
+	name     string
+	settings Settings
+	now      func() time.Time
+
+	mu                   sync.Mutex
+	state                State
+	generation           uint64
+	expiry               time.Time
+	requests             int
+	failures             int
+	consecutiveFailures  int
+	halfOpenInFlight     int
+	consecutiveSuccesses int
+}
+
+// New cria um circuit breaker fechado com as políticas informadas
+func New(name string, settings Settings) *Breaker {
+	if settings.HalfOpenMaxRequests <= 0 {
+		settings.HalfOpenMaxRequests = 1
+	}
+
+	b := &Breaker{
+		name:     name,
+		settings: settings,
+		now:      time.Now,
+	}
+	b.toNewGeneration(b.now())
+	b.registerMetrics()
+
+	return b
+}
+
+// State retorna o estado atual do circuito
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, _ := b.currentState(context.Background(), b.now())
+	return state
+}
+
+// Allow verifica se a chamada pode prosseguir. Quando permitida, a função
+// retornada deve ser chamada com o resultado da chamada
+func (b *Breaker) Allow(ctx context.Context) (func(Outcome), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	state, generation := b.currentState(ctx, now)
+
+	if state == StateOpen {
+		return nil, ErrOpen
+	}
+	if state == StateHalfOpen {
+		if b.halfOpenInFlight >= b.settings.HalfOpenMaxRequests {
+			return nil, ErrOpen
+		}
+		b.halfOpenInFlight++
+	}
+
+	b.requests++
+
+	var once sync.Once
+	return func(outcome Outcome) {
+		once.Do(func() { b.record(ctx, generation, outcome) })
+	}, nil
+}
+
+// record contabiliza o resultado de uma chamada, descartando resultados de
+// gerações anteriores à última transição de estado
+func (b *Breaker) record(ctx context.Context, generation uint64, outcome Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	state, current := b.currentState(ctx, now)
+	if generation != current {
+		return
+	}
+
+	if state == StateHalfOpen {
+		b.halfOpenInFlight--
+	}
+
+	switch outcome {
+	case Success:
+		b.consecutiveFailures = 0
+		if state == StateHalfOpen {
+			b.consecutiveSuccesses++
+			if b.consecutiveSuccesses >= b.settings.HalfOpenMaxRequests {
+				b.setState(ctx, StateClosed, now)
+			}
+		}
+	case Failure:
+		b.failures++
+		b.consecutiveFailures++
+		if state == StateHalfOpen || b.shouldTrip() {
+			b.setState(ctx, StateOpen, now)
+		}
+	case Ignore:
+		b.requests--
+	}
+}
+
+// shouldTrip avalia as políticas de abertura do circuito
+func (b *Breaker) shouldTrip() bool {
+	if b.settings.ConsecutiveFailures > 0 && b.consecutiveFailures >= b.settings.ConsecutiveFailures {
+		return true
+	}
+	if b.settings.FailureRatio > 0 && b.requests >= b.settings.MinRequests && b.requests > 0 {
+		return float64(b.failures)/float64(b.requests) >= b.settings.FailureRatio
+	}
+	return false
+}
+
+// currentState atualiza o estado conforme o tempo: a janela do estado
+// fechado é reiniciada e o circuito aberto passa a meio-aberto após o timeout
+func (b *Breaker) currentState(ctx context.Context, now time.Time) (State, uint64) {
+	switch b.state {
+	case StateClosed:
+		if !b.expiry.IsZero() && now.After(b.expiry) {
+			b.toNewGeneration(now)
+		}
+	case StateOpen:
+		if now.After(b.expiry) {
+			b.setState(ctx, StateHalfOpen, now)
+		}
+	}
+	return b.state, b.generation
+}
+
+// setState realiza a transição de estado e a registra como evento no span
+// da chamada que a provocou
+func (b *Breaker) setState(ctx context.Context, state State, now time.Time) {
+	if b.state == state {
+		return
+	}
+
+	from := b.state
+	b.state = state
+	b.toNewGeneration(now)
+
+	trace.SpanFromContext(ctx).AddEvent("circuit_breaker.state_change", trace.WithAttributes(
+		attribute.String("circuit_breaker.name", b.name),
+		attribute.String("circuit_breaker.from", from.String()),
+		attribute.String("circuit_breaker.to", state.String()),
+	))
+	logging.Printf(ctx, "Circuit breaker %s mudou de %s para %s", b.name, from, state)
+}
+
+// toNewGeneration zera os contadores e define a expiração do estado atual
+func (b *Breaker) toNewGeneration(now time.Time) {
+	b.generation++
+	b.requests = 0
+	b.failures = 0
+	b.consecutiveFailures = 0
+	b.consecutiveSuccesses = 0
+	b.halfOpenInFlight = 0
+
+	switch b.state {
+	case StateClosed:
+		if b.settings.Window > 0 {
+			b.expiry = now.Add(b.settings.Window)
+		} else {
+			b.expiry = time.Time{}
+		}
+	case StateOpen:
+		b.expiry = now.Add(b.settings.OpenTimeout)
+	default:
+		b.expiry = time.Time{}
+	}
+}
+
+// registerMetrics publica o estado do circuito como gauge
+// (0 = fechado, 1 = meio-aberto, 2 = aberto)
+func (b *Breaker) registerMetrics() {
+	meter := otel.Meter("servico-a")
+
+	gauge, err := meter.Int64ObservableGauge(
+		"circuit_breaker.state",
+		metric.WithDescription("Estado do circuit breaker (0 = fechado, 1 = meio-aberto, 2 = aberto)"),
+	)
+	if err != nil {
+		otel.Handle(err)
+		return
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(gauge, int64(b.State()), metric.WithAttributes(attribute.String("circuit_breaker.name", b.name)))
+		return nil
+	}, gauge)
+	if err != nil {
+		otel.Handle(err)
+	}
+}
@@ -0,0 +1,131 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestBreaker(settings Settings) (*Breaker, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := New("test", settings)
+	b.now = func() time.Time { return now }
+	b.toNewGeneration(now)
+	return b, &now
+}
+
+func call(t *testing.T, b *Breaker, outcome Outcome) error {
+	t.Helper()
+	done, err := b.Allow(context.Background())
+	if err != nil {
+		return err
+	}
+	done(outcome)
+	return nil
+}
+
+func TestBreakerConsecutiveFailures(t *testing.T) {
+	b, now := newTestBreaker(Settings{ConsecutiveFailures: 3, OpenTimeout: 10 * time.Second})
+
+	call(t, b, Failure)
+	call(t, b, Failure)
+	call(t, b, Success)
+	call(t, b, Failure)
+	call(t, b, Failure)
+	if state := b.State(); state != StateClosed {
+		t.Fatalf("estado após falhas intercaladas = %s, expected closed", state)
+	}
+
+	call(t, b, Failure)
+	if state := b.State(); state != StateOpen {
+		t.Fatalf("estado após 3 falhas consecutivas = %s, expected open", state)
+	}
+	if err := call(t, b, Success); !errors.Is(err, ErrOpen) {
+		t.Fatalf("chamada com circuito aberto: err = %v, expected ErrOpen", err)
+	}
+
+	*now = now.Add(11 * time.Second)
+	if state := b.State(); state != StateHalfOpen {
+		t.Fatalf("estado após o timeout = %s, expected half-open", state)
+	}
+}
+
+func TestBreakerFailureRatio(t *testing.T) {
+	b, _ := newTestBreaker(Settings{FailureRatio: 0.5, MinRequests: 4, Window: time.Minute, OpenTimeout: time.Second})
+
+	call(t, b, Failure)
+	call(t, b, Failure)
+	call(t, b, Failure)
+	if state := b.State(); state != StateClosed {
+		t.Fatalf("estado abaixo do mínimo de requisições = %s, expected closed", state)
+	}
+
+	call(t, b, Success)
+	call(t, b, Failure)
+	if state := b.State(); state != StateOpen {
+		t.Fatalf("estado com 80%% de falhas = %s, expected open", state)
+	}
+}
+
+func TestBreakerWindowReset(t *testing.T) {
+	b, now := newTestBreaker(Settings{FailureRatio: 0.5, MinRequests: 2, Window: time.Minute, OpenTimeout: time.Second})
+
+	call(t, b, Failure)
+	*now = now.Add(2 * time.Minute)
+	call(t, b, Failure)
+
+	if state := b.State(); state != StateClosed {
+		t.Fatalf("falhas em janelas diferentes abriram o circuito: %s", state)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcome  Outcome
+		expected State
+	}{
+		{name: "Sucesso no meio-aberto fecha o circuito", outcome: Success, expected: StateClosed},
+		{name: "Falha no meio-aberto reabre o circuito", outcome: Failure, expected: StateOpen},
+		{name: "Chamada ignorada mantém o meio-aberto", outcome: Ignore, expected: StateHalfOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, now := newTestBreaker(Settings{ConsecutiveFailures: 1, OpenTimeout: time.Second, HalfOpenMaxRequests: 1})
+
+			call(t, b, Failure)
+			*now = now.Add(2 * time.Second)
+
+			done, err := b.Allow(context.Background())
+			if err != nil {
+				t.Fatalf("primeira chamada no meio-aberto rejeitada: %v", err)
+			}
+			if _, err := b.Allow(context.Background()); !errors.Is(err, ErrOpen) {
+				t.Fatalf("chamada acima do limite do meio-aberto: err = %v, expected ErrOpen", err)
+			}
+
+			done(tt.outcome)
+			if state := b.State(); state != tt.expected {
+				t.Errorf("estado = %s, expected %s", state, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBreakerIgnoresStaleResults(t *testing.T) {
+	b, _ := newTestBreaker(Settings{ConsecutiveFailures: 1, OpenTimeout: time.Minute})
+
+	stale, err := b.Allow(context.Background())
+	if err != nil {
+		t.Fatalf("Allow() err = %v", err)
+	}
+	call(t, b, Failure)
+
+	// Resultado de uma chamada iniciada antes da abertura não fecha o circuito
+	stale(Success)
+	if state := b.State(); state != StateOpen {
+		t.Errorf("estado = %s, expected open", state)
+	}
+}
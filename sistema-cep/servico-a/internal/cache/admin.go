@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"net/http"
+
+	"servico-a/internal/admin"
+	"servico-a/internal/logging"
+)
+
+// RegisterAdmin registra a consulta do tamanho do cache e o descarte de
+// todas as respostas
+func (c *Cache) RegisterAdmin(r *admin.Registry) {
+	r.HandleFunc("GET /cache", "Entradas e limites do cache de respostas", func(w http.ResponseWriter, req *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, map[string]any{
+			"entries":                c.Len(),
+			"max_entries":            c.settings.MaxEntries,
+			"ttl":                    c.settings.TTL.String(),
+			"stale_while_revalidate": c.settings.StaleWhileRevalidate.String(),
+			"stale_if_error":         c.settings.StaleIfError.String(),
+		})
+	})
+	r.HandleFunc("POST /cache/flush", "Descarta todas as respostas em cache", func(w http.ResponseWriter, req *http.Request) {
+		flushed := c.Flush()
+		logging.Printf(req.Context(), "Cache de respostas esvaziado pelo admin: %d entradas descartadas", flushed)
+		admin.WriteJSON(w, http.StatusOK, map[string]int{"flushed": flushed})
+	})
+}
@@ -0,0 +1,261 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"servico-a/internal/logging"
+	"servico-a/internal/models"
+	"servico-a/internal/requestid"
+	"servico-a/internal/services"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// revalidateTimeout limita a duração de uma revalidação em segundo plano
+const revalidateTimeout = 30 * time.Second
+
+// Status indica como a resposta foi obtida
+type Status string
+
+const (
+	// StatusHit indica uma resposta do cache dentro do TTL
+	StatusHit Status = "HIT"
+	// StatusMiss indica uma resposta obtida do Serviço B
+	StatusMiss Status = "MISS"
+	// StatusStale indica uma resposta expirada servida do cache enquanto é
+	// revalidada ou porque o Serviço B falhou
+	StatusStale Status = "STALE"
+)
+
+// Result descreve a origem da resposta
+type Result struct {
+	Status Status
+	Age    time.Duration
+}
+
+// Fetcher busca a temperatura do CEP quando não há resposta válida no cache
+type Fetcher func(ctx context.Context, cep string) (*models.TemperatureResponse, error)
+
+// Settings define a validade das respostas e o tamanho do cache. Após o
+// TTL, a resposta ainda é servida por StaleWhileRevalidate enquanto é
+// atualizada em segundo plano, e por StaleIfError quando o Serviço B falha
+type Settings struct {
+	TTL                  time.Duration
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+	MaxEntries           int
+}
+
+type entry struct {
+	cep         string
+	temperature models.TemperatureResponse
+	storedAt    time.Time
+}
+
+// Cache guarda as temperaturas por CEP, descartando as menos usadas quando
+// o limite de entradas é atingido
+type Cache struct {
+	settings Settings
+	fetch    Fetcher
+	now      func() time.Time
+	requests metric.Int64Counter
+
+	mu           sync.Mutex
+	entries      map[string]*list.Element
+	lru          *list.List
+	revalidating map[string]bool
+}
+
+// New cria um cache que usa fetch para buscar as respostas ausentes ou expiradas
+func New(settings Settings, fetch Fetcher) *Cache {
+	requests, err := otel.Meter("servico-a").Int64Counter(
+		"cache.requests",
+		metric.WithDescription("Consultas ao cache de respostas por resultado"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return &Cache{
+		settings:     settings,
+		fetch:        fetch,
+		now:          time.Now,
+		requests:     requests,
+		entries:      make(map[string]*list.Element),
+		lru:          list.New(),
+		revalidating: make(map[string]bool),
+	}
+}
+
+// Normalize remove a formatação do CEP, para que "01310-100" e "01310100"
+// compartilhem a mesma entrada
+func Normalize(cep string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, cep)
+}
+
+// Len retorna o número de CEPs em cache
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// Flush descarta todas as respostas em cache e retorna quantas eram.
+// Revalidações em andamento ainda podem gravar as suas respostas
+func (c *Cache) Flush() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	flushed := c.lru.Len()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	return flushed
+}
+
+// Get retorna a temperatura do CEP, do cache ou do Serviço B
+func (c *Cache) Get(ctx context.Context, cep string) (*models.TemperatureResponse, Result, error) {
+	key := Normalize(cep)
+	now := c.now()
+
+	cached, age, ok := c.lookup(key, now)
+	switch {
+	case ok && age < c.settings.TTL:
+		return c.serve(ctx, cached, Result{Status: StatusHit, Age: age})
+
+	case ok && age < c.settings.TTL+c.settings.StaleWhileRevalidate:
+		c.revalidate(ctx, key)
+		return c.serve(ctx, cached, Result{Status: StatusStale, Age: age})
+	}
+
+	temperature, err := c.fetch(ctx, key)
+	if err != nil {
+		if ok && age < c.settings.TTL+c.settings.StaleIfError && isUpstreamFailure(err) {
+			logging.Warnf(ctx, "Servindo resposta expirada do cache para o CEP %s após falha do Serviço B: %v", key, err)
+			trace.SpanFromContext(ctx).AddEvent("cache.stale_if_error")
+			return c.serve(ctx, cached, Result{Status: StatusStale, Age: age})
+		}
+		return nil, Result{Status: StatusMiss}, err
+	}
+
+	c.store(key, temperature, c.now())
+	return c.serve(ctx, temperature, Result{Status: StatusMiss})
+}
+
+// serve contabiliza a consulta e devolve uma cópia da resposta
+func (c *Cache) serve(ctx context.Context, temperature *models.TemperatureResponse, result Result) (*models.TemperatureResponse, Result, error) {
+	if c.requests != nil {
+		c.requests.Add(ctx, 1, metric.WithAttributes(attribute.String("cache.status", string(result.Status))))
+	}
+	copied := *temperature
+	return &copied, result, nil
+}
+
+// lookup retorna a entrada do CEP e sua idade, marcando-a como usada
+func (c *Cache) lookup(key string, now time.Time) (*models.TemperatureResponse, time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, 0, false
+	}
+
+	e := element.Value.(*entry)
+	age := now.Sub(e.storedAt)
+	if age >= c.settings.TTL+max(c.settings.StaleWhileRevalidate, c.settings.StaleIfError) {
+		c.lru.Remove(element)
+		delete(c.entries, key)
+		return nil, 0, false
+	}
+
+	c.lru.MoveToFront(element)
+	temperature := e.temperature
+	return &temperature, age, true
+}
+
+// store grava a resposta, descartando a entrada menos usada se necessário
+func (c *Cache) store(key string, temperature *models.TemperatureResponse, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		e := element.Value.(*entry)
+		e.temperature = *temperature
+		e.storedAt = now
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry{cep: key, temperature: *temperature, storedAt: now})
+	for c.settings.MaxEntries > 0 && c.lru.Len() > c.settings.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).cep)
+	}
+}
+
+// revalidate atualiza a entrada em segundo plano, com no máximo uma
+// revalidação por CEP em andamento. A revalidação gera um trace próprio,
+// ligado ao span da requisição que a disparou
+func (c *Cache) revalidate(ctx context.Context, key string) {
+	c.mu.Lock()
+	if c.revalidating[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.revalidating[key] = true
+	c.mu.Unlock()
+
+	bgCtx := requestid.NewContext(context.Background(), requestid.FromContext(ctx))
+	bgCtx, span := otel.Tracer("servico-a").Start(bgCtx, "cache.revalidate",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+		trace.WithAttributes(attribute.String("cep.value", key)),
+	)
+
+	go func() {
+		defer span.End()
+		defer func() {
+			c.mu.Lock()
+			delete(c.revalidating, key)
+			c.mu.Unlock()
+		}()
+
+		bgCtx, cancel := context.WithTimeout(bgCtx, revalidateTimeout)
+		defer cancel()
+
+		temperature, err := c.fetch(bgCtx, key)
+		if err != nil {
+			logging.Errorf(bgCtx, "Erro ao revalidar o cache para o CEP %s: %v", key, err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "revalidation failed")
+			return
+		}
+		c.store(key, temperature, c.now())
+	}()
+}
+
+// isUpstreamFailure indica se o erro é uma falha do Serviço B, e não uma
+// resposta sobre o CEP ou um cancelamento pelo cliente
+func isUpstreamFailure(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	return errors.Is(err, services.ErrUnavailable) || errors.Is(err, services.ErrProtocol)
+}
@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"servico-a/internal/models"
+	"servico-a/internal/services"
+)
+
+// fakeFetcher simula o Serviço B, contando as chamadas
+type fakeFetcher struct {
+	mu    sync.Mutex
+	calls int
+	temp  float64
+	err   error
+	done  chan struct{}
+}
+
+func (f *fakeFetcher) fetch(ctx context.Context, cep string) (*models.TemperatureResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if f.done != nil {
+		defer func() { f.done <- struct{}{} }()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &models.TemperatureResponse{City: "São Paulo", TempC: f.temp}, nil
+}
+
+func (f *fakeFetcher) set(temp float64, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.temp = temp
+	f.err = err
+}
+
+func (f *fakeFetcher) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls
+}
+
+func newTestCache(fetcher *fakeFetcher, settings Settings) (*Cache, *time.Time) {
+	now := time.Now()
+	c := New(settings, fetcher.fetch)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestGet(t *testing.T) {
+	fetcher := &fakeFetcher{temp: 20}
+	c, now := newTestCache(fetcher, Settings{TTL: time.Minute, MaxEntries: 10})
+
+	temperature, result, err := c.Get(context.Background(), "01310100")
+	if err != nil || result.Status != StatusMiss || temperature.TempC != 20 {
+		t.Fatalf("Get() = %+v, %+v, %v; expected MISS", temperature, result, err)
+	}
+
+	// O CEP formatado usa a mesma entrada
+	*now = now.Add(10 * time.Second)
+	_, result, err = c.Get(context.Background(), "01310-100")
+	if err != nil || result.Status != StatusHit || result.Age != 10*time.Second {
+		t.Fatalf("Get() = %+v, %v; expected HIT com idade de 10s", result, err)
+	}
+
+	// Após o TTL, sem janelas de resposta expirada, o Serviço B é consultado
+	fetcher.set(25, nil)
+	*now = now.Add(time.Minute)
+	temperature, result, _ = c.Get(context.Background(), "01310100")
+	if result.Status != StatusMiss || temperature.TempC != 25 {
+		t.Errorf("Get() = %+v, %+v; expected MISS com a nova temperatura", temperature, result)
+	}
+
+	if calls := fetcher.count(); calls != 2 {
+		t.Errorf("chamadas ao Serviço B = %d, expected 2", calls)
+	}
+}
+
+func TestGetStaleWhileRevalidate(t *testing.T) {
+	fetcher := &fakeFetcher{temp: 20}
+	c, now := newTestCache(fetcher, Settings{TTL: time.Minute, StaleWhileRevalidate: time.Minute})
+
+	c.Get(context.Background(), "01310100")
+
+	fetcher.done = make(chan struct{}, 1)
+	fetcher.set(25, nil)
+	*now = now.Add(90 * time.Second)
+
+	// A resposta expirada é servida enquanto a revalidação acontece
+	temperature, result, err := c.Get(context.Background(), "01310100")
+	if err != nil || result.Status != StatusStale || temperature.TempC != 20 {
+		t.Fatalf("Get() = %+v, %+v, %v; expected STALE", temperature, result, err)
+	}
+
+	select {
+	case <-fetcher.done:
+	case <-time.After(time.Second):
+		t.Fatal("revalidação não foi executada")
+	}
+
+	// Aguarda a revalidação concluir antes de consultar novamente
+	for i := 0; i < 100; i++ {
+		c.mu.Lock()
+		revalidating := c.revalidating["01310100"]
+		c.mu.Unlock()
+		if !revalidating {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	temperature, result, _ = c.Get(context.Background(), "01310100")
+	if result.Status != StatusHit || temperature.TempC != 25 {
+		t.Errorf("Get() = %+v, %+v; expected HIT com a temperatura revalidada", temperature, result)
+	}
+}
+
+func TestGetStaleIfError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		elapsed        time.Duration
+		expectedStatus Status
+		expectedErr    bool
+	}{
+		{
+			name:           "Serviço B indisponível dentro da janela",
+			err:            &services.ServiceBError{Kind: services.ErrUnavailable},
+			elapsed:        5 * time.Minute,
+			expectedStatus: StatusStale,
+		},
+		{
+			name:           "Resposta inválida dentro da janela",
+			err:            &services.ServiceBError{Kind: services.ErrProtocol},
+			elapsed:        5 * time.Minute,
+			expectedStatus: StatusStale,
+		},
+		{
+			name:        "Serviço B indisponível após a janela",
+			err:         &services.ServiceBError{Kind: services.ErrUnavailable},
+			elapsed:     2 * time.Hour,
+			expectedErr: true,
+		},
+		{
+			name:        "CEP não encontrado não usa resposta expirada",
+			err:         &services.ServiceBError{Kind: services.ErrZipcodeNotFound},
+			elapsed:     5 * time.Minute,
+			expectedErr: true,
+		},
+		{
+			name:        "Cancelamento pelo cliente",
+			err:         errors.Join(&services.ServiceBError{Kind: services.ErrUnavailable}, context.Canceled),
+			elapsed:     5 * time.Minute,
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &fakeFetcher{temp: 20}
+			c, now := newTestCache(fetcher, Settings{TTL: time.Minute, StaleIfError: time.Hour})
+
+			c.Get(context.Background(), "01310100")
+
+			fetcher.set(0, tt.err)
+			*now = now.Add(tt.elapsed)
+
+			temperature, result, err := c.Get(context.Background(), "01310100")
+			if tt.expectedErr {
+				if err == nil {
+					t.Errorf("Get() = %+v, %+v; expected erro", temperature, result)
+				}
+				return
+			}
+			if err != nil || result.Status != tt.expectedStatus || temperature.TempC != 20 {
+				t.Errorf("Get() = %+v, %+v, %v; expected %s", temperature, result, err, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestMaxEntries(t *testing.T) {
+	fetcher := &fakeFetcher{temp: 20}
+	c, _ := newTestCache(fetcher, Settings{TTL: time.Minute, MaxEntries: 2})
+
+	c.Get(context.Background(), "01310100")
+	c.Get(context.Background(), "20040002")
+	// Usa o primeiro CEP para que o segundo seja o menos usado
+	c.Get(context.Background(), "01310100")
+	c.Get(context.Background(), "30130010")
+
+	if _, result, _ := c.Get(context.Background(), "01310100"); result.Status != StatusHit {
+		t.Errorf("CEP usado recentemente foi descartado: %+v", result)
+	}
+	if _, result, _ := c.Get(context.Background(), "20040002"); result.Status != StatusMiss {
+		t.Errorf("CEP menos usado não foi descartado: %+v", result)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	fetcher := &fakeFetcher{temp: 20}
+	c, _ := newTestCache(fetcher, Settings{TTL: time.Minute, MaxEntries: 10})
+
+	c.Get(context.Background(), "01310100")
+	c.Get(context.Background(), "20040002")
+
+	if flushed := c.Flush(); flushed != 2 {
+		t.Errorf("Flush() = %d, expected 2", flushed)
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d após Flush, expected 0", c.Len())
+	}
+	if _, result, _ := c.Get(context.Background(), "01310100"); result.Status != StatusMiss {
+		t.Errorf("CEP continua em cache após Flush: %+v", result)
+	}
+}
@@ -0,0 +1,99 @@
+package concurrency
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Settings define os limites e a reação do limitador. Respostas acima de
+// LatencyThreshold ou com falha reduzem o limite multiplicando-o por
+// BackoffRatio; respostas rápidas o aumentam gradualmente
+type Settings struct {
+	InitialLimit     int
+	MinLimit         int
+	MaxLimit         int
+	LatencyThreshold time.Duration
+	BackoffRatio     float64
+}
+
+// Limiter limita as requisições simultâneas com um limite adaptativo AIMD
+// (aumento aditivo, redução multiplicativa) guiado pela latência observada
+type Limiter struct {
+	settings Settings
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+}
+
+// NewLimiter cria um limitador com o limite inicial informado
+func NewLimiter(settings Settings) *Limiter {
+	if settings.MinLimit < 1 {
+		settings.MinLimit = 1
+	}
+	if settings.MaxLimit < settings.MinLimit {
+		settings.MaxLimit = settings.MinLimit
+	}
+	if settings.BackoffRatio <= 0 || settings.BackoffRatio >= 1 {
+		settings.BackoffRatio = 0.9
+	}
+
+	initial := min(max(settings.InitialLimit, settings.MinLimit), settings.MaxLimit)
+	return &Limiter{
+		settings: settings,
+		limit:    float64(initial),
+	}
+}
+
+// Acquire reserva uma vaga para a requisição. Quando permitida, a função
+// retornada deve ser chamada ao fim da requisição com a latência e se ela
+// falhou por sobrecarga (erro 5xx ou prazo esgotado)
+func (l *Limiter) Acquire() (func(latency time.Duration, failed bool), bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight >= int(l.limit) {
+		return nil, false
+	}
+	l.inFlight++
+
+	var once sync.Once
+	return func(latency time.Duration, failed bool) {
+		once.Do(func() { l.release(latency, failed) })
+	}, true
+}
+
+// release libera a vaga e ajusta o limite conforme o resultado
+func (l *Limiter) release(latency time.Duration, failed bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inFlight := l.inFlight
+	l.inFlight--
+
+	switch {
+	case failed || (l.settings.LatencyThreshold > 0 && latency > l.settings.LatencyThreshold):
+		l.limit = math.Max(float64(l.settings.MinLimit), l.limit*l.settings.BackoffRatio)
+	case inFlight*2 >= int(l.limit):
+		// Só aumenta quando o limite está sendo usado; com pouca carga, o
+		// limite não diz nada sobre a capacidade do servidor
+		l.limit = math.Min(float64(l.settings.MaxLimit), l.limit+1/l.limit)
+	}
+}
+
+// Limit retorna o limite atual de requisições simultâneas
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.limit)
+}
+
+// InFlight retorna a quantidade de requisições em andamento
+func (l *Limiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.inFlight
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"net/http"
+
+	"servico-a/internal/admin"
+)
+
+// RegisterAdmin registra a consulta da configuração em uso, com credenciais
+// ocultadas, e a recarga sob demanda
+func (w *Watcher) RegisterAdmin(r *admin.Registry) {
+	r.HandleFunc("GET /config", "Configuração em uso em YAML, com credenciais ocultadas", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/yaml")
+		rw.Header().Set("Cache-Control", "no-store")
+		w.Current().WriteRedacted(rw)
+	})
+	r.HandleFunc("POST /config/reload", "Recarrega a configuração, como no SIGHUP", func(rw http.ResponseWriter, req *http.Request) {
+		if err := w.Reload(req.Context(), "admin"); err != nil {
+			admin.WriteJSON(rw, http.StatusUnprocessableEntity, map[string]string{"status": "rejected", "error": err.Error()})
+			return
+		}
+		admin.WriteJSON(rw, http.StatusOK, map[string]string{"status": "reloaded"})
+	})
+}
@@ -1,15 +1,533 @@
 package config
 
-import "os"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
 
+	"gopkg.in/yaml.v3"
+)
+
+// Config representa a configuração da aplicação. Os valores padrão são
+// sobrescritos pelo arquivo YAML, se informado, e depois pelas variáveis de
+// ambiente
 type Config struct {
-	Port        string
-	ServiceBURL string
+	Port           string        `yaml:"port"`
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	ServiceBURLs   []string      `yaml:"service_b_urls"`
+	// ServiceBTransport escolhe o protocolo das chamadas ao Serviço B: "http"
+	// (POST /temperature) ou "grpc" (TemperatureService na ServiceBGRPCPort)
+	ServiceBTransport string `yaml:"service_b_transport"`
+	ServiceBGRPCPort  string `yaml:"service_b_grpc_port"`
+	// ServiceBTLS habilita o mTLS nas chamadas ao Serviço B
+	ServiceBTLS    TLSConfig `yaml:"service_b_tls"`
+	ZipkinEndpoint string    `yaml:"zipkin_endpoint"`
+	LogLevel       string    `yaml:"log_level"`
+	// ConfigWatchInterval é o intervalo de verificação de mudanças no
+	// arquivo de configuração. Zero recarrega apenas no SIGHUP
+	ConfigWatchInterval time.Duration          `yaml:"config_watch_interval"`
+	Readiness           ReadinessConfig        `yaml:"readiness"`
+	Admin               AdminConfig            `yaml:"admin"`
+	LoadBalancer        LoadBalancerConfig     `yaml:"load_balancer"`
+	Hedge               HedgeConfig            `yaml:"hedge"`
+	Cache               CacheConfig            `yaml:"cache"`
+	Concurrency         ConcurrencyLimitConfig `yaml:"concurrency"`
+	CORS                CORSConfig             `yaml:"cors"`
+	Auth                AuthConfig             `yaml:"auth"`
+	RateLimit           RateLimitConfig        `yaml:"rate_limit"`
+	Breaker             CircuitBreakerConfig   `yaml:"breaker"`
+	Retry               RetryConfig            `yaml:"retry"`
+}
+
+// TLSConfig representa o mTLS nas chamadas ao Serviço B: o certificado
+// apresentado pelo Serviço A e as CAs aceitas para o certificado do Serviço
+// B, verificado contra o host das URLs ou ServerName. Os arquivos são relidos
+// quando mudam, verificados a cada ReloadInterval; zero desabilita a recarga
+type TLSConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	CertFile       string        `yaml:"cert_file"`
+	KeyFile        string        `yaml:"key_file"`
+	CAFile         string        `yaml:"ca_file"`
+	ServerName     string        `yaml:"server_name"`
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+// ReadinessConfig representa as verificações das dependências feitas pelo
+// /readyz. Os resultados são reaproveitados por CacheTTL e cada verificação
+// é limitada a Timeout
+type ReadinessConfig struct {
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// AdminConfig representa o servidor administrativo, em uma porta separada
+// da pública, com /metrics, pprof, a configuração em uso e os endpoints dos
+// subsistemas. Todas as requisições exigem o token no header Authorization:
+// Bearer; com TokenFile o token é relido quando o arquivo muda
+type AdminConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	BindAddress string `yaml:"bind_address"`
+	Port        string `yaml:"port"`
+	Token       string `yaml:"token"`
+	TokenFile   string `yaml:"token_file"`
+	Pprof       bool   `yaml:"pprof"`
+}
+
+// LoadBalancerConfig representa a distribuição das chamadas entre as
+// instâncias do Serviço B, a ejeção de instâncias com falhas consecutivas e
+// os health checks ativos. Valores zero desabilitam a ejeção e os health checks
+type LoadBalancerConfig struct {
+	Strategy                   string        `yaml:"strategy"`
+	OutlierConsecutiveFailures int           `yaml:"outlier_consecutive_failures"`
+	OutlierEjectionDuration    time.Duration `yaml:"outlier_ejection_duration"`
+	HealthCheckInterval        time.Duration `yaml:"health_check_interval"`
+	HealthCheckTimeout         time.Duration `yaml:"health_check_timeout"`
+}
+
+// HedgeConfig representa o envio de uma segunda chamada a outra instância
+// do Serviço B quando a primeira demora. Com Percentile maior que zero, o
+// atraso acompanha o percentil das latências observadas e Delay é usado até
+// haver amostras suficientes. MaxRatio limita a fração de requisições com hedge
+type HedgeConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	Delay      time.Duration `yaml:"delay"`
+	Percentile float64       `yaml:"percentile"`
+	MaxRatio   float64       `yaml:"max_ratio"`
+}
+
+// CacheConfig representa o cache de temperaturas por CEP. Após o TTL, a
+// resposta ainda é servida por StaleWhileRevalidate enquanto é atualizada em
+// segundo plano, e por StaleIfError quando o Serviço B falha
+type CacheConfig struct {
+	Enabled              bool          `yaml:"enabled"`
+	TTL                  time.Duration `yaml:"ttl"`
+	StaleWhileRevalidate time.Duration `yaml:"stale_while_revalidate"`
+	StaleIfError         time.Duration `yaml:"stale_if_error"`
+	MaxEntries           int           `yaml:"max_entries"`
+}
+
+// ConcurrencyLimitConfig representa o limite adaptativo de requisições
+// simultâneas. O limite cresce enquanto as respostas são rápidas e é reduzido
+// quando a latência passa de LatencyThreshold ou o prazo se esgota
+type ConcurrencyLimitConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	InitialLimit     int           `yaml:"initial_limit"`
+	MinLimit         int           `yaml:"min_limit"`
+	MaxLimit         int           `yaml:"max_limit"`
+	LatencyThreshold time.Duration `yaml:"latency_threshold"`
+	BackoffRatio     float64       `yaml:"backoff_ratio"`
+}
+
+// CORSConfig representa a política CORS aplicada às rotas públicas
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+// AuthConfig representa a autenticação das rotas públicas por API key ou
+// por token JWT. As chaves vêm de APIKeys ou do arquivo APIKeysFile, nunca
+// dos dois
+type AuthConfig struct {
+	Enabled     bool           `yaml:"enabled"`
+	Header      string         `yaml:"header"`
+	APIKeysFile string         `yaml:"api_keys_file"`
+	APIKeys     []APIKeyConfig `yaml:"api_keys"`
+	JWT         JWTConfig      `yaml:"jwt"`
+}
+
+// JWTConfig representa a validação de tokens JWT enviados como bearer. As
+// chaves públicas vêm de um JWKS publicado em JWKSURL ou gravado em
+// JWKSFile, relido após JWKSCacheTTL ou ao receber um kid desconhecido
+type JWTConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Issuer        string        `yaml:"issuer"`
+	Audience      string        `yaml:"audience"`
+	JWKSURL       string        `yaml:"jwks_url"`
+	JWKSFile      string        `yaml:"jwks_file"`
+	JWKSCacheTTL  time.Duration `yaml:"jwks_cache_ttl"`
+	ClockSkew     time.Duration `yaml:"clock_skew"`
+	RequiredScope string        `yaml:"required_scope"`
+}
+
+// APIKeyConfig representa uma API key cadastrada. Hash é o SHA-256 da chave
+// no formato sha256:<hex>; a chave em si nunca é armazenada. Enabled omitido
+// habilita a chave, rate limit zero usa o limite global e AllowedEndpoints
+// vazio permite todas as rotas
+type APIKeyConfig struct {
+	ID                string   `yaml:"id"`
+	Owner             string   `yaml:"owner"`
+	Hash              string   `yaml:"hash"`
+	Enabled           *bool    `yaml:"enabled,omitempty"`
+	RequestsPerSecond float64  `yaml:"requests_per_second"`
+	Burst             int      `yaml:"burst"`
+	AllowedEndpoints  []string `yaml:"allowed_endpoints"`
+}
+
+// IsEnabled indica se a chave está habilitada
+func (k APIKeyConfig) IsEnabled() bool {
+	return k.Enabled == nil || *k.Enabled
 }
 
-func LoadConfig() *Config {
+// RateLimitConfig representa o limite de requisições por cliente. Clientes
+// são identificados pela credencial autenticada ou, sem ela, pelo IP de
+// origem
+type RateLimitConfig struct {
+	Enabled           bool     `yaml:"enabled"`
+	RequestsPerSecond float64  `yaml:"requests_per_second"`
+	Burst             int      `yaml:"burst"`
+	TrustedProxies    []string `yaml:"trusted_proxies"`
+}
+
+// CircuitBreakerConfig representa as políticas do circuit breaker das
+// chamadas ao Serviço B. Políticas com valor zero ficam desabilitadas
+type CircuitBreakerConfig struct {
+	Enabled             bool          `yaml:"enabled"`
+	ConsecutiveFailures int           `yaml:"consecutive_failures"`
+	FailureRatio        float64       `yaml:"failure_ratio"`
+	MinRequests         int           `yaml:"min_requests"`
+	Window              time.Duration `yaml:"window"`
+	OpenTimeout         time.Duration `yaml:"open_timeout"`
+	HalfOpenMaxRequests int           `yaml:"half_open_max_requests"`
+}
+
+// RetryConfig representa a política de retentativas das chamadas ao
+// Serviço B. O orçamento limita as retentativas a uma fração das chamadas
+type RetryConfig struct {
+	MaxAttempts     int           `yaml:"max_attempts"`
+	InitialBackoff  time.Duration `yaml:"initial_backoff"`
+	MaxBackoff      time.Duration `yaml:"max_backoff"`
+	BudgetRatio     float64       `yaml:"budget_ratio"`
+	BudgetMaxTokens float64       `yaml:"budget_max_tokens"`
+}
+
+// Default retorna a configuração com os valores padrão
+func Default() *Config {
 	return &Config{
-		Port:        os.Getenv("PORT"),
-		ServiceBURL: os.Getenv("SERVICE_B_URL"),
+		Port:                "8080",
+		RequestTimeout:      10 * time.Second,
+		ServiceBURLs:        []string{"http://localhost:8081"},
+		ServiceBTransport:   "http",
+		ServiceBGRPCPort:    "9081",
+		ZipkinEndpoint:      "http://localhost:9411/api/v2/spans",
+		LogLevel:            "info",
+		ConfigWatchInterval: 5 * time.Second,
+		ServiceBTLS: TLSConfig{
+			Enabled:        false,
+			ReloadInterval: 30 * time.Second,
+		},
+		Readiness: ReadinessConfig{
+			CacheTTL: 5 * time.Second,
+			Timeout:  2 * time.Second,
+		},
+		Admin: AdminConfig{
+			Enabled:     false,
+			BindAddress: "127.0.0.1",
+			Port:        "9090",
+			Pprof:       true,
+		},
+		LoadBalancer: LoadBalancerConfig{
+			Strategy:                   "round_robin",
+			OutlierConsecutiveFailures: 3,
+			OutlierEjectionDuration:    30 * time.Second,
+			HealthCheckInterval:        10 * time.Second,
+			HealthCheckTimeout:         2 * time.Second,
+		},
+		Hedge: HedgeConfig{
+			Enabled:    false,
+			Delay:      500 * time.Millisecond,
+			Percentile: 0.95,
+			MaxRatio:   0.1,
+		},
+		Cache: CacheConfig{
+			Enabled:              true,
+			TTL:                  5 * time.Minute,
+			StaleWhileRevalidate: time.Minute,
+			StaleIfError:         time.Hour,
+			MaxEntries:           10000,
+		},
+		Concurrency: ConcurrencyLimitConfig{
+			Enabled:          true,
+			InitialLimit:     20,
+			MinLimit:         5,
+			MaxLimit:         200,
+			LatencyThreshold: 2 * time.Second,
+			BackoffRatio:     0.9,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   []string{"*"},
+			AllowedMethods:   []string{"POST"},
+			AllowedHeaders:   []string{"Content-Type", "Accept", "Accept-Language", "X-Request-ID", "X-Request-Timeout", "X-API-Key", "Authorization"},
+			ExposedHeaders:   []string{"X-Trace-Id", "X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "X-Cache", "Age"},
+			AllowCredentials: false,
+			MaxAge:           10 * time.Minute,
+		},
+		Auth: AuthConfig{
+			Enabled: false,
+			Header:  "X-API-Key",
+			JWT: JWTConfig{
+				Enabled:       false,
+				JWKSCacheTTL:  15 * time.Minute,
+				ClockSkew:     30 * time.Second,
+				RequiredScope: "temperature:read",
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:           true,
+			RequestsPerSecond: 5,
+			Burst:             10,
+		},
+		Breaker: CircuitBreakerConfig{
+			Enabled:             true,
+			ConsecutiveFailures: 5,
+			FailureRatio:        0.5,
+			MinRequests:         10,
+			Window:              30 * time.Second,
+			OpenTimeout:         15 * time.Second,
+			HalfOpenMaxRequests: 1,
+		},
+		Retry: RetryConfig{
+			MaxAttempts:     3,
+			InitialBackoff:  100 * time.Millisecond,
+			MaxBackoff:      2 * time.Second,
+			BudgetRatio:     0.2,
+			BudgetMaxTokens: 10,
+		},
+	}
+}
+
+// Load carrega a configuração a partir dos valores padrão, do arquivo YAML
+// em path (opcional) e das variáveis de ambiente, nessa ordem de
+// precedência crescente. Todos os erros de leitura e de validação são
+// retornados juntos
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	var errs []error
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	env := &envLoader{}
+	cfg.applyEnv(env)
+	errs = append(errs, env.errs...)
+	if err := cfg.loadAPIKeys(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := cfg.loadAdminToken(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return cfg, nil
+}
+
+// loadFile sobrescreve a configuração com os campos presentes no arquivo.
+// Campos desconhecidos são rejeitados para que erros de digitação não passem
+// despercebidos
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("erro ao ler o arquivo de configuração: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("arquivo de configuração %s inválido: %w", path, err)
+	}
+	return nil
+}
+
+// loadAPIKeys lê as API keys do arquivo informado em APIKeysFile, que
+// contém uma lista YAML no mesmo formato de auth.api_keys
+func (c *Config) loadAPIKeys() error {
+	if c.Auth.APIKeysFile == "" {
+		return nil
+	}
+	if len(c.Auth.APIKeys) > 0 {
+		return errors.New("AUTH_API_KEYS_FILE: não pode ser usado junto com AUTH_API_KEYS ou auth.api_keys")
+	}
+
+	data, err := os.ReadFile(c.Auth.APIKeysFile)
+	if err != nil {
+		return fmt.Errorf("AUTH_API_KEYS_FILE: erro ao ler o arquivo: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c.Auth.APIKeys); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("AUTH_API_KEYS_FILE: arquivo %s inválido: %w", c.Auth.APIKeysFile, err)
+	}
+	return nil
+}
+
+// loadAdminToken lê o token do admin do arquivo informado em TokenFile,
+// sem os espaços e quebras de linha das extremidades
+func (c *Config) loadAdminToken() error {
+	if c.Admin.TokenFile == "" {
+		return nil
+	}
+	if c.Admin.Token != "" {
+		return errors.New("ADMIN_TOKEN_FILE: não pode ser usado junto com ADMIN_TOKEN")
+	}
+
+	data, err := os.ReadFile(c.Admin.TokenFile)
+	if err != nil {
+		return fmt.Errorf("ADMIN_TOKEN_FILE: erro ao ler o arquivo: %w", err)
+	}
+	c.Admin.Token = strings.TrimSpace(string(data))
+	if c.Admin.Token == "" {
+		return fmt.Errorf("ADMIN_TOKEN_FILE: o arquivo %s está vazio", c.Admin.TokenFile)
+	}
+	return nil
+}
+
+// watchedFiles retorna os arquivos referenciados pela configuração cuja
+// mudança também dispara uma recarga
+func (c *Config) watchedFiles() []string {
+	var files []string
+	if c.Auth.APIKeysFile != "" {
+		files = append(files, c.Auth.APIKeysFile)
+	}
+	if c.Admin.TokenFile != "" {
+		files = append(files, c.Admin.TokenFile)
+	}
+	return files
+}
+
+// applyEnv sobrescreve a configuração com as variáveis de ambiente definidas
+func (c *Config) applyEnv(env *envLoader) {
+	env.string("PORT", &c.Port)
+	env.duration("REQUEST_TIMEOUT", &c.RequestTimeout)
+	env.list("SERVICE_B_URL", &c.ServiceBURLs)
+	env.string("SERVICE_B_TRANSPORT", &c.ServiceBTransport)
+	env.string("SERVICE_B_GRPC_PORT", &c.ServiceBGRPCPort)
+	env.bool("SERVICE_B_TLS_ENABLED", &c.ServiceBTLS.Enabled)
+	env.string("SERVICE_B_TLS_CERT_FILE", &c.ServiceBTLS.CertFile)
+	env.string("SERVICE_B_TLS_KEY_FILE", &c.ServiceBTLS.KeyFile)
+	env.string("SERVICE_B_TLS_CA_FILE", &c.ServiceBTLS.CAFile)
+	env.string("SERVICE_B_TLS_SERVER_NAME", &c.ServiceBTLS.ServerName)
+	env.duration("SERVICE_B_TLS_RELOAD_INTERVAL", &c.ServiceBTLS.ReloadInterval)
+	env.string("ZIPKIN_ENDPOINT", &c.ZipkinEndpoint)
+	env.string("LOG_LEVEL", &c.LogLevel)
+	env.duration("CONFIG_WATCH_INTERVAL", &c.ConfigWatchInterval)
+
+	env.duration("READINESS_CACHE_TTL", &c.Readiness.CacheTTL)
+	env.duration("READINESS_TIMEOUT", &c.Readiness.Timeout)
+
+	env.bool("ADMIN_ENABLED", &c.Admin.Enabled)
+	env.string("ADMIN_BIND_ADDRESS", &c.Admin.BindAddress)
+	env.string("ADMIN_PORT", &c.Admin.Port)
+	env.string("ADMIN_TOKEN", &c.Admin.Token)
+	env.string("ADMIN_TOKEN_FILE", &c.Admin.TokenFile)
+	env.bool("ADMIN_PPROF_ENABLED", &c.Admin.Pprof)
+
+	env.string("LOAD_BALANCER_STRATEGY", &c.LoadBalancer.Strategy)
+	env.int("OUTLIER_CONSECUTIVE_FAILURES", &c.LoadBalancer.OutlierConsecutiveFailures)
+	env.duration("OUTLIER_EJECTION_DURATION", &c.LoadBalancer.OutlierEjectionDuration)
+	env.duration("HEALTH_CHECK_INTERVAL", &c.LoadBalancer.HealthCheckInterval)
+	env.duration("HEALTH_CHECK_TIMEOUT", &c.LoadBalancer.HealthCheckTimeout)
+
+	env.bool("HEDGE_ENABLED", &c.Hedge.Enabled)
+	env.duration("HEDGE_DELAY", &c.Hedge.Delay)
+	env.float("HEDGE_PERCENTILE", &c.Hedge.Percentile)
+	env.float("HEDGE_MAX_RATIO", &c.Hedge.MaxRatio)
+
+	env.bool("CACHE_ENABLED", &c.Cache.Enabled)
+	env.duration("CACHE_TTL", &c.Cache.TTL)
+	env.duration("CACHE_STALE_WHILE_REVALIDATE", &c.Cache.StaleWhileRevalidate)
+	env.duration("CACHE_STALE_IF_ERROR", &c.Cache.StaleIfError)
+	env.int("CACHE_MAX_ENTRIES", &c.Cache.MaxEntries)
+
+	env.bool("CONCURRENCY_LIMIT_ENABLED", &c.Concurrency.Enabled)
+	env.int("CONCURRENCY_LIMIT_INITIAL", &c.Concurrency.InitialLimit)
+	env.int("CONCURRENCY_LIMIT_MIN", &c.Concurrency.MinLimit)
+	env.int("CONCURRENCY_LIMIT_MAX", &c.Concurrency.MaxLimit)
+	env.duration("CONCURRENCY_LIMIT_LATENCY_THRESHOLD", &c.Concurrency.LatencyThreshold)
+	env.float("CONCURRENCY_LIMIT_BACKOFF_RATIO", &c.Concurrency.BackoffRatio)
+
+	env.list("CORS_ALLOWED_ORIGINS", &c.CORS.AllowedOrigins)
+	env.list("CORS_ALLOWED_METHODS", &c.CORS.AllowedMethods)
+	env.list("CORS_ALLOWED_HEADERS", &c.CORS.AllowedHeaders)
+	env.list("CORS_EXPOSED_HEADERS", &c.CORS.ExposedHeaders)
+	env.bool("CORS_ALLOW_CREDENTIALS", &c.CORS.AllowCredentials)
+	env.duration("CORS_MAX_AGE", &c.CORS.MaxAge)
+
+	env.bool("AUTH_ENABLED", &c.Auth.Enabled)
+	env.string("AUTH_HEADER", &c.Auth.Header)
+	env.string("AUTH_API_KEYS_FILE", &c.Auth.APIKeysFile)
+	env.yaml("AUTH_API_KEYS", &c.Auth.APIKeys)
+	env.bool("AUTH_JWT_ENABLED", &c.Auth.JWT.Enabled)
+	env.string("AUTH_JWT_ISSUER", &c.Auth.JWT.Issuer)
+	env.string("AUTH_JWT_AUDIENCE", &c.Auth.JWT.Audience)
+	env.string("AUTH_JWT_JWKS_URL", &c.Auth.JWT.JWKSURL)
+	env.string("AUTH_JWT_JWKS_FILE", &c.Auth.JWT.JWKSFile)
+	env.duration("AUTH_JWT_JWKS_CACHE_TTL", &c.Auth.JWT.JWKSCacheTTL)
+	env.duration("AUTH_JWT_CLOCK_SKEW", &c.Auth.JWT.ClockSkew)
+	env.string("AUTH_JWT_REQUIRED_SCOPE", &c.Auth.JWT.RequiredScope)
+
+	env.bool("RATE_LIMIT_ENABLED", &c.RateLimit.Enabled)
+	env.float("RATE_LIMIT_RPS", &c.RateLimit.RequestsPerSecond)
+	env.int("RATE_LIMIT_BURST", &c.RateLimit.Burst)
+	env.list("TRUSTED_PROXIES", &c.RateLimit.TrustedProxies)
+
+	env.bool("CIRCUIT_BREAKER_ENABLED", &c.Breaker.Enabled)
+	env.int("CIRCUIT_BREAKER_CONSECUTIVE_FAILURES", &c.Breaker.ConsecutiveFailures)
+	env.float("CIRCUIT_BREAKER_FAILURE_RATIO", &c.Breaker.FailureRatio)
+	env.int("CIRCUIT_BREAKER_MIN_REQUESTS", &c.Breaker.MinRequests)
+	env.duration("CIRCUIT_BREAKER_WINDOW", &c.Breaker.Window)
+	env.duration("CIRCUIT_BREAKER_OPEN_TIMEOUT", &c.Breaker.OpenTimeout)
+	env.int("CIRCUIT_BREAKER_HALF_OPEN_MAX_REQUESTS", &c.Breaker.HalfOpenMaxRequests)
+
+	env.int("RETRY_MAX_ATTEMPTS", &c.Retry.MaxAttempts)
+	env.duration("RETRY_INITIAL_BACKOFF", &c.Retry.InitialBackoff)
+	env.duration("RETRY_MAX_BACKOFF", &c.Retry.MaxBackoff)
+	env.float("RETRY_BUDGET_RATIO", &c.Retry.BudgetRatio)
+	env.float("RETRY_BUDGET_MAX_TOKENS", &c.Retry.BudgetMaxTokens)
+}
+
+// WriteRedacted escreve a configuração em YAML, com credenciais ocultadas
+func (c *Config) WriteRedacted(w io.Writer) error {
+	redacted := *c
+	redacted.ServiceBURLs = make([]string, len(c.ServiceBURLs))
+	for i, u := range c.ServiceBURLs {
+		redacted.ServiceBURLs[i] = redactURL(u)
+	}
+	redacted.ZipkinEndpoint = redactURL(c.ZipkinEndpoint)
+	redacted.Auth.JWT.JWKSURL = redactURL(c.Auth.JWT.JWKSURL)
+	if c.Admin.Token != "" {
+		redacted.Admin.Token = "[REDACTED]"
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&redacted); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// redactURL oculta a senha de URLs com credenciais
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil {
+		return value
 	}
+	return u.Redacted()
 }
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envLoader sobrescreve campos da configuração com as variáveis de ambiente
+// definidas, acumulando os valores inválidos para que todos sejam
+// informados juntos
+type envLoader struct {
+	errs []error
+}
+
+// lookup retorna o valor da variável de ambiente, se definida e não vazia
+func (e *envLoader) lookup(key string) (string, bool) {
+	value := os.Getenv(key)
+	return value, value != ""
+}
+
+// invalid registra um valor inválido, informando o tipo esperado
+func (e *envLoader) invalid(key, value, expected string) {
+	e.errs = append(e.errs, fmt.Errorf("%s: valor inválido %q, esperado %s", key, value, expected))
+}
+
+// string sobrescreve um texto
+func (e *envLoader) string(key string, dst *string) {
+	if value, ok := e.lookup(key); ok {
+		*dst = value
+	}
+}
+
+// list sobrescreve uma lista separada por vírgulas. O valor "none" resulta
+// em uma lista vazia
+func (e *envLoader) list(key string, dst *[]string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	if value == "none" {
+		*dst = nil
+		return
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+// bool sobrescreve um booleano
+func (e *envLoader) bool(key string, dst *bool) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		e.invalid(key, value, "true ou false")
+		return
+	}
+	*dst = parsed
+}
+
+// int sobrescreve um inteiro
+func (e *envLoader) int(key string, dst *int) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		e.invalid(key, value, "um número inteiro")
+		return
+	}
+	*dst = parsed
+}
+
+// float sobrescreve um número decimal
+func (e *envLoader) float(key string, dst *float64) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		e.invalid(key, value, "um número")
+		return
+	}
+	*dst = parsed
+}
+
+// duration sobrescreve uma duração, como "500ms" ou "10s"
+func (e *envLoader) duration(key string, dst *time.Duration) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		e.invalid(key, value, "uma duração como 500ms ou 10s")
+		return
+	}
+	*dst = parsed
+}
+
+// yaml sobrescreve um valor estruturado informado em YAML ou JSON
+func (e *envLoader) yaml(key string, dst any) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+
+	dec := yaml.NewDecoder(strings.NewReader(value))
+	dec.KnownFields(true)
+	if err := dec.Decode(dst); err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: valor inválido, esperado YAML ou JSON: %w", key, err))
+	}
+}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"servico-a/internal/auth"
+)
+
+// Validate verifica todos os campos e retorna os problemas encontrados
+// juntos. Os campos são identificados pelo nome da variável de ambiente
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.port("PORT", c.Port)
+	v.positive("REQUEST_TIMEOUT", c.RequestTimeout)
+	v.check(len(c.ServiceBURLs) > 0, "SERVICE_B_URL", "ao menos uma URL é obrigatória")
+	for _, u := range c.ServiceBURLs {
+		v.url("SERVICE_B_URL", u)
+	}
+	v.oneOf("SERVICE_B_TRANSPORT", c.ServiceBTransport, "http", "grpc")
+	v.port("SERVICE_B_GRPC_PORT", c.ServiceBGRPCPort)
+	v.tls(c.ServiceBTLS, c.ServiceBURLs)
+	v.url("ZIPKIN_ENDPOINT", c.ZipkinEndpoint)
+	v.oneOf("LOG_LEVEL", c.LogLevel, "debug", "info", "warn", "error")
+	v.nonNegative("CONFIG_WATCH_INTERVAL", c.ConfigWatchInterval)
+
+	v.positive("READINESS_CACHE_TTL", c.Readiness.CacheTTL)
+	v.positive("READINESS_TIMEOUT", c.Readiness.Timeout)
+	v.admin(c.Admin, c.Port)
+
+	v.oneOf("LOAD_BALANCER_STRATEGY", c.LoadBalancer.Strategy, "round_robin", "least_outstanding")
+	v.check(c.LoadBalancer.OutlierConsecutiveFailures >= 0, "OUTLIER_CONSECUTIVE_FAILURES", "não pode ser negativo")
+	v.nonNegative("OUTLIER_EJECTION_DURATION", c.LoadBalancer.OutlierEjectionDuration)
+	v.nonNegative("HEALTH_CHECK_INTERVAL", c.LoadBalancer.HealthCheckInterval)
+	if c.LoadBalancer.HealthCheckInterval > 0 {
+		v.positive("HEALTH_CHECK_TIMEOUT", c.LoadBalancer.HealthCheckTimeout)
+	}
+
+	if c.Hedge.Enabled {
+		v.positive("HEDGE_DELAY", c.Hedge.Delay)
+		v.check(c.Hedge.Percentile >= 0 && c.Hedge.Percentile < 1, "HEDGE_PERCENTILE", "deve estar entre 0 e 1")
+		v.ratio("HEDGE_MAX_RATIO", c.Hedge.MaxRatio)
+	}
+
+	if c.Cache.Enabled {
+		v.positive("CACHE_TTL", c.Cache.TTL)
+		v.nonNegative("CACHE_STALE_WHILE_REVALIDATE", c.Cache.StaleWhileRevalidate)
+		v.nonNegative("CACHE_STALE_IF_ERROR", c.Cache.StaleIfError)
+		v.check(c.Cache.MaxEntries >= 0, "CACHE_MAX_ENTRIES", "não pode ser negativo")
+	}
+
+	v.concurrency(c.Concurrency)
+	v.nonNegative("CORS_MAX_AGE", c.CORS.MaxAge)
+	v.auth(c.Auth)
+
+	if c.RateLimit.Enabled {
+		v.check(c.RateLimit.RequestsPerSecond > 0, "RATE_LIMIT_RPS", "deve ser maior que zero")
+		v.check(c.RateLimit.Burst >= 1, "RATE_LIMIT_BURST", "deve ser ao menos 1")
+	}
+	for _, proxy := range c.RateLimit.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		v.check(cidrErr == nil || net.ParseIP(proxy) != nil, "TRUSTED_PROXIES", "%q não é um IP nem um CIDR", proxy)
+	}
+
+	if c.Breaker.Enabled {
+		v.check(c.Breaker.ConsecutiveFailures >= 0, "CIRCUIT_BREAKER_CONSECUTIVE_FAILURES", "não pode ser negativo")
+		v.ratio("CIRCUIT_BREAKER_FAILURE_RATIO", c.Breaker.FailureRatio)
+		v.check(c.Breaker.MinRequests >= 0, "CIRCUIT_BREAKER_MIN_REQUESTS", "não pode ser negativo")
+		v.positive("CIRCUIT_BREAKER_WINDOW", c.Breaker.Window)
+		v.positive("CIRCUIT_BREAKER_OPEN_TIMEOUT", c.Breaker.OpenTimeout)
+		v.check(c.Breaker.HalfOpenMaxRequests >= 1, "CIRCUIT_BREAKER_HALF_OPEN_MAX_REQUESTS", "deve ser ao menos 1")
+	}
+
+	v.retry(c.Retry)
+
+	return errors.Join(v.errs...)
+}
+
+// validator acumula os problemas encontrados na configuração
+type validator struct {
+	errs []error
+}
+
+// check registra o problema quando a condição não é atendida e retorna a
+// condição
+func (v *validator) check(ok bool, field, format string, args ...any) bool {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+	return ok
+}
+
+// port verifica se o valor é uma porta TCP
+func (v *validator) port(field, value string) {
+	port, err := strconv.Atoi(value)
+	v.check(err == nil && port > 0 && port <= 65535, field, "porta inválida %q", value)
+}
+
+// url verifica se o valor é uma URL HTTP absoluta
+func (v *validator) url(field, value string) {
+	u, err := url.Parse(value)
+	v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", field, "URL HTTP inválida %q", redactURL(value))
+}
+
+// oneOf verifica se o valor é uma das opções
+func (v *validator) oneOf(field, value string, options ...string) {
+	for _, option := range options {
+		if value == option {
+			return
+		}
+	}
+	v.check(false, field, "valor %q inválido, use um de %v", value, options)
+}
+
+// positive verifica se a duração é maior que zero
+func (v *validator) positive(field string, d time.Duration) {
+	v.check(d > 0, field, "deve ser maior que zero")
+}
+
+// nonNegative verifica se a duração não é negativa
+func (v *validator) nonNegative(field string, d time.Duration) {
+	v.check(d >= 0, field, "não pode ser negativo")
+}
+
+// ratio verifica se a fração está entre 0 e 1
+func (v *validator) ratio(field string, value float64) {
+	v.check(value >= 0 && value <= 1, field, "deve estar entre 0 e 1")
+}
+
+// concurrency verifica os limites de concorrência
+func (v *validator) concurrency(c ConcurrencyLimitConfig) {
+	if !c.Enabled {
+		return
+	}
+	v.check(c.MinLimit >= 1, "CONCURRENCY_LIMIT_MIN", "deve ser ao menos 1")
+	v.check(c.MaxLimit >= c.MinLimit, "CONCURRENCY_LIMIT_MAX", "deve ser maior ou igual a CONCURRENCY_LIMIT_MIN")
+	v.check(c.InitialLimit >= c.MinLimit && c.InitialLimit <= c.MaxLimit, "CONCURRENCY_LIMIT_INITIAL", "deve estar entre CONCURRENCY_LIMIT_MIN e CONCURRENCY_LIMIT_MAX")
+	v.positive("CONCURRENCY_LIMIT_LATENCY_THRESHOLD", c.LatencyThreshold)
+	v.check(c.BackoffRatio > 0 && c.BackoffRatio < 1, "CONCURRENCY_LIMIT_BACKOFF_RATIO", "deve estar entre 0 e 1, sem incluí-los")
+}
+
+// auth verifica o cabeçalho, as API keys cadastradas e a validação de
+// tokens JWT. As chaves são verificadas mesmo com a autenticação
+// desabilitada, para que erros não apareçam só ao habilitá-la
+func (v *validator) auth(c AuthConfig) {
+	if c.Enabled {
+		v.check(c.Header != "", "AUTH_HEADER", "é obrigatório")
+		v.check(len(c.APIKeys) > 0 || c.JWT.Enabled, "AUTH_API_KEYS", "ao menos uma API key é obrigatória com AUTH_ENABLED, a menos que AUTH_JWT_ENABLED esteja habilitado")
+	}
+	if c.JWT.Enabled {
+		v.check(c.Enabled, "AUTH_JWT_ENABLED", "exige AUTH_ENABLED")
+		v.check(c.JWT.Issuer != "", "AUTH_JWT_ISSUER", "é obrigatório")
+		v.check(c.JWT.Audience != "", "AUTH_JWT_AUDIENCE", "é obrigatório")
+		v.check((c.JWT.JWKSURL == "") != (c.JWT.JWKSFile == ""), "AUTH_JWT_JWKS_URL", "informe AUTH_JWT_JWKS_URL ou AUTH_JWT_JWKS_FILE, não os dois")
+		if c.JWT.JWKSURL != "" {
+			v.url("AUTH_JWT_JWKS_URL", c.JWT.JWKSURL)
+		}
+		v.positive("AUTH_JWT_JWKS_CACHE_TTL", c.JWT.JWKSCacheTTL)
+		v.nonNegative("AUTH_JWT_CLOCK_SKEW", c.JWT.ClockSkew)
+		v.check(c.JWT.RequiredScope != "", "AUTH_JWT_REQUIRED_SCOPE", "é obrigatório")
+	}
+
+	ids := make(map[string]bool, len(c.APIKeys))
+	hashes := make(map[string]bool, len(c.APIKeys))
+	for i, key := range c.APIKeys {
+		if !v.check(key.ID != "", "AUTH_API_KEYS", "a chave %d não tem id", i+1) {
+			continue
+		}
+		v.check(!ids[key.ID], "AUTH_API_KEYS", "id %q repetido", key.ID)
+		ids[key.ID] = true
+
+		_, err := auth.ParseHash(key.Hash)
+		v.check(err == nil, "AUTH_API_KEYS", "chave %q: hash inválido, use sha256:<64 dígitos hexadecimais>", key.ID)
+		v.check(err != nil || !hashes[key.Hash], "AUTH_API_KEYS", "chave %q: hash repetido", key.ID)
+		hashes[key.Hash] = true
+
+		v.check(key.RequestsPerSecond >= 0, "AUTH_API_KEYS", "chave %q: requests_per_second não pode ser negativo", key.ID)
+		v.check(key.RequestsPerSecond == 0 || key.Burst >= 1, "AUTH_API_KEYS", "chave %q: burst deve ser ao menos 1 com requests_per_second", key.ID)
+		for _, endpoint := range key.AllowedEndpoints {
+			_, err := path.Match(endpoint, "/")
+			v.check(err == nil && strings.HasPrefix(endpoint, "/"), "AUTH_API_KEYS", "chave %q: endpoint %q inválido", key.ID, endpoint)
+		}
+	}
+}
+
+// admin verifica o servidor administrativo
+func (v *validator) admin(c AdminConfig, publicPorts ...string) {
+	if !c.Enabled {
+		return
+	}
+	v.port("ADMIN_PORT", c.Port)
+	v.check(!slices.Contains(publicPorts, c.Port), "ADMIN_PORT", "deve ser diferente das portas públicas do serviço")
+	v.check(c.BindAddress == "" || c.BindAddress == "localhost" || net.ParseIP(c.BindAddress) != nil, "ADMIN_BIND_ADDRESS", "endereço IP inválido %q", c.BindAddress)
+	v.check(c.Token != "", "ADMIN_TOKEN", "é obrigatório com ADMIN_ENABLED (ou ADMIN_TOKEN_FILE)")
+}
+
+// tls verifica os arquivos do mTLS com o Serviço B. Com o mTLS habilitado as
+// URLs do Serviço B precisam usar https
+func (v *validator) tls(c TLSConfig, urls []string) {
+	if !c.Enabled {
+		return
+	}
+	v.check(c.CertFile != "", "SERVICE_B_TLS_CERT_FILE", "é obrigatório com SERVICE_B_TLS_ENABLED")
+	v.check(c.KeyFile != "", "SERVICE_B_TLS_KEY_FILE", "é obrigatório com SERVICE_B_TLS_ENABLED")
+	v.check(c.CAFile != "", "SERVICE_B_TLS_CA_FILE", "é obrigatório com SERVICE_B_TLS_ENABLED")
+	v.nonNegative("SERVICE_B_TLS_RELOAD_INTERVAL", c.ReloadInterval)
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		v.check(err != nil || u.Scheme == "https", "SERVICE_B_URL", "%q deve usar https com SERVICE_B_TLS_ENABLED", redactURL(raw))
+	}
+}
+
+// retry verifica a política de retentativas
+func (v *validator) retry(c RetryConfig) {
+	v.check(c.MaxAttempts >= 1, "RETRY_MAX_ATTEMPTS", "deve ser ao menos 1")
+	v.nonNegative("RETRY_INITIAL_BACKOFF", c.InitialBackoff)
+	v.nonNegative("RETRY_MAX_BACKOFF", c.MaxBackoff)
+	v.check(c.BudgetRatio >= 0, "RETRY_BUDGET_RATIO", "não pode ser negativo")
+	v.check(c.BudgetMaxTokens >= 0, "RETRY_BUDGET_MAX_TOKENS", "não pode ser negativo")
+}
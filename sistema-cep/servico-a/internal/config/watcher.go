@@ -0,0 +1,273 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"servico-a/internal/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v3"
+)
+
+// reloadable lista os campos (chaves YAML) aplicados sem reinício. Mudanças
+// nos demais campos são registradas e só passam a valer após reiniciar o
+// serviço
+var reloadable = []string{
+	"request_timeout",
+	"service_b_urls",
+	"log_level",
+	"config_watch_interval",
+	"cors",
+	"auth",
+	"rate_limit",
+	"admin.token",
+	"admin.token_file",
+}
+
+// Watcher recarrega a configuração ao receber SIGHUP ou quando o arquivo de
+// configuração, o arquivo de API keys ou o arquivo do token do admin muda. A
+// nova configuração só é aplicada se for válida; caso contrário a atual é
+// mantida sem nenhuma alteração
+type Watcher struct {
+	path   string
+	load   func(path string) (*Config, error)
+	apply  func(*Config) error
+	tracer trace.Tracer
+
+	mu      sync.Mutex
+	current *Config
+	sum     [sha256.Size]byte
+}
+
+// NewWatcher cria o watcher do arquivo em path, partindo da configuração já
+// carregada. apply recebe cada nova configuração válida e deve trocá-la nos
+// componentes em execução; se retornar erro, nenhum componente pode ter sido
+// alterado e a configuração atual é mantida
+func NewWatcher(path string, current *Config, apply func(*Config) error) *Watcher {
+	w := &Watcher{
+		path:    path,
+		load:    Load,
+		apply:   apply,
+		tracer:  otel.Tracer("servico-a"),
+		current: current,
+	}
+	w.sum = fileSum(w.files(current))
+	return w
+}
+
+// Current retorna a configuração em uso
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.current
+}
+
+// Run recarrega a configuração a cada SIGHUP e a cada mudança dos arquivos,
+// verificados no intervalo ConfigWatchInterval, até o contexto ser cancelado
+func (w *Watcher) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		// O intervalo é lido a cada volta para acompanhar as recargas
+		var tick <-chan time.Time
+		var timer *time.Timer
+		current := w.Current()
+		if interval := current.ConfigWatchInterval; len(w.files(current)) > 0 && interval > 0 {
+			timer = time.NewTimer(interval)
+			tick = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-hup:
+			w.Reload(ctx, "sighup")
+		case <-tick:
+			if fileSum(w.files(current)) != w.lastSum() {
+				w.Reload(ctx, "file_change")
+			}
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// Reload carrega e valida a configuração e, se ela for válida, a aplica.
+// Cada tentativa gera um span config.reload com os campos alterados
+func (w *Watcher) Reload(ctx context.Context, trigger string) error {
+	ctx, span := w.tracer.Start(ctx, "config.reload")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("config.reload.trigger", trigger),
+		attribute.String("config.file", w.path),
+	)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// O hash é lido antes dos arquivos para que uma escrita concorrente gere
+	// uma nova recarga em vez de ser ignorada
+	w.sum = fileSum(w.files(w.current))
+
+	next, err := w.load(w.path)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid configuration")
+		logging.Errorf(ctx, "Recarga da configuração (%s) rejeitada, a configuração atual foi mantida:\n%v", trigger, err)
+		return err
+	}
+
+	changed := changedFields(w.current, next)
+	restart := restartRequired(changed)
+	span.SetAttributes(
+		attribute.StringSlice("config.changed", changed),
+		attribute.StringSlice("config.restart_required", restart),
+	)
+	if len(changed) == 0 {
+		logging.Printf(ctx, "Configuração recarregada (%s) sem alterações", trigger)
+		return nil
+	}
+
+	if err := w.apply(next); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "configuration not applied")
+		logging.Errorf(ctx, "Recarga da configuração (%s) não aplicada, a configuração atual foi mantida: %v", trigger, err)
+		return err
+	}
+	if !slices.Equal(w.files(next), w.files(w.current)) {
+		w.sum = fileSum(w.files(next))
+	}
+	w.current = next
+
+	logging.Printf(ctx, "Configuração recarregada (%s), campos alterados: %s", trigger, strings.Join(changed, ", "))
+	if len(restart) > 0 {
+		logging.Warnf(ctx, "Campos alterados que só valem após reiniciar o serviço: %s", strings.Join(restart, ", "))
+	}
+	return nil
+}
+
+// lastSum retorna o hash dos arquivos lidos na última recarga
+func (w *Watcher) lastSum() [sha256.Size]byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.sum
+}
+
+// files retorna os arquivos que, ao mudar, disparam uma recarga
+func (w *Watcher) files(cfg *Config) []string {
+	var files []string
+	if w.path != "" {
+		files = append(files, w.path)
+	}
+	return append(files, cfg.watchedFiles()...)
+}
+
+// fileSum calcula o hash do conteúdo dos arquivos. Comparar o conteúdo, e
+// não a data de modificação, também detecta a troca do link simbólico usada
+// por ConfigMaps e Secrets do Kubernetes. Arquivos ilegíveis entram no hash
+// como ausentes, para que a recarga registre o erro
+func fileSum(paths []string) [sha256.Size]byte {
+	h := sha256.New()
+	for _, path := range paths {
+		h.Write([]byte(path))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			h.Write([]byte{0})
+			continue
+		}
+		h.Write([]byte{1})
+		h.Write(data)
+	}
+
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// changedFields retorna as chaves YAML dos campos com valores diferentes entre as
+// configurações, em ordem alfabética. Apenas os nomes são retornados, nunca
+// os valores
+func changedFields(old, next *Config) []string {
+	before, after := flatten(old), flatten(next)
+
+	var changed []string
+	for key, value := range after {
+		if !reflect.DeepEqual(before[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// restartRequired filtra os campos alterados que não estão em reloadable
+func restartRequired(changed []string) []string {
+	var restart []string
+	for _, key := range changed {
+		applied := false
+		for _, prefix := range reloadable {
+			if key == prefix || strings.HasPrefix(key, prefix+".") {
+				applied = true
+				break
+			}
+		}
+		if !applied {
+			restart = append(restart, key)
+		}
+	}
+	return restart
+}
+
+// flatten converte a configuração em um mapa de chaves YAML completas, como
+// cors.allowed_origins, para os valores
+func flatten(c *Config) map[string]any {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil
+	}
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil
+	}
+
+	flat := make(map[string]any)
+	var walk func(prefix string, node map[string]any)
+	walk = func(prefix string, node map[string]any) {
+		for key, value := range node {
+			if child, ok := value.(map[string]any); ok {
+				walk(prefix+key+".", child)
+				continue
+			}
+			flat[prefix+key] = value
+		}
+	}
+	walk("", tree)
+	return flat
+}
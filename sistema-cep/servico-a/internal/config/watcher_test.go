@@ -0,0 +1,186 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWatcherReload(t *testing.T) {
+	path := writeConfigFile(t, "request_timeout: 5s\n")
+	current, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() err = %v", err)
+	}
+
+	var applied []*Config
+	var applyErr error
+	w := NewWatcher(path, current, func(cfg *Config) error {
+		if applyErr != nil {
+			return applyErr
+		}
+		applied = append(applied, cfg)
+		return nil
+	})
+	recorder := tracetest.NewSpanRecorder()
+	w.tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	// Configuração válida é aplicada e os campos alterados são registrados
+	os.WriteFile(path, []byte("request_timeout: 3s\nport: 9090\ncors:\n  allowed_origins: [https://example.com]\n"), 0o600)
+	if err := w.Reload(context.Background(), "test"); err != nil {
+		t.Fatalf("Reload() err = %v", err)
+	}
+	if len(applied) != 1 || applied[0].RequestTimeout != 3*time.Second {
+		t.Fatalf("configurações aplicadas = %v, expected uma com timeout de 3s", applied)
+	}
+	if w.Current() != applied[0] {
+		t.Error("Current() não retornou a configuração aplicada")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "config.reload" {
+		t.Fatalf("spans = %v, expected um config.reload", spans)
+	}
+	attrs := attributes(spans[0].Attributes())
+	if got := attrs["config.changed"]; got != "cors.allowed_origins,port,request_timeout" {
+		t.Errorf("config.changed = %q", got)
+	}
+	if got := attrs["config.restart_required"]; got != "port" {
+		t.Errorf("config.restart_required = %q, expected port", got)
+	}
+
+	// Configuração inválida é rejeitada sem chamar apply
+	os.WriteFile(path, []byte("request_timeout: -1s\n"), 0o600)
+	if err := w.Reload(context.Background(), "test"); err == nil || !strings.Contains(err.Error(), "REQUEST_TIMEOUT") {
+		t.Errorf("Reload() err = %v, expected erro de validação", err)
+	}
+	if len(applied) != 1 || w.Current().RequestTimeout != 3*time.Second {
+		t.Errorf("configuração inválida foi aplicada: timeout atual %v", w.Current().RequestTimeout)
+	}
+
+	// Falha ao aplicar também mantém a configuração atual
+	applyErr = errors.New("falha ao aplicar")
+	os.WriteFile(path, []byte("request_timeout: 7s\n"), 0o600)
+	if err := w.Reload(context.Background(), "test"); !errors.Is(err, applyErr) {
+		t.Errorf("Reload() err = %v, expected erro do apply", err)
+	}
+	if w.Current().RequestTimeout != 3*time.Second {
+		t.Errorf("timeout atual = %v, expected 3s mantido", w.Current().RequestTimeout)
+	}
+
+	for _, span := range recorder.Ended()[1:] {
+		if span.Status().Code.String() != "Error" {
+			t.Errorf("span %s com status %v, expected Error", span.Name(), span.Status().Code)
+		}
+	}
+}
+
+func TestWatcherRunDetectsFileChange(t *testing.T) {
+	path := writeConfigFile(t, "config_watch_interval: 10ms\n")
+	current, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() err = %v", err)
+	}
+
+	applied := make(chan *Config, 1)
+	w := NewWatcher(path, current, func(cfg *Config) error {
+		applied <- cfg
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	os.WriteFile(path, []byte("config_watch_interval: 10ms\nlog_level: debug\n"), 0o600)
+
+	select {
+	case cfg := <-applied:
+		if cfg.LogLevel != "debug" {
+			t.Errorf("LogLevel = %q, expected debug", cfg.LogLevel)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("mudança no arquivo não foi aplicada")
+	}
+}
+
+func TestWatcherRunDetectsAPIKeysChange(t *testing.T) {
+	keysFile := filepath.Join(t.TempDir(), "api_keys.yaml")
+	os.WriteFile(keysFile, []byte("- id: antiga\n  hash: sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\n"), 0o600)
+	t.Setenv("AUTH_API_KEYS_FILE", keysFile)
+
+	path := writeConfigFile(t, "config_watch_interval: 10ms\n")
+	current, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() err = %v", err)
+	}
+
+	applied := make(chan *Config, 1)
+	w := NewWatcher(path, current, func(cfg *Config) error {
+		applied <- cfg
+		return nil
+	})
+	recorder := tracetest.NewSpanRecorder()
+	w.tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Só o arquivo de API keys muda, como na rotação de um Secret montado
+	os.WriteFile(keysFile, []byte("- id: nova\n  hash: sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb\n"), 0o600)
+
+	select {
+	case cfg := <-applied:
+		if len(cfg.Auth.APIKeys) != 1 || cfg.Auth.APIKeys[0].ID != "nova" {
+			t.Errorf("API keys = %+v, expected apenas a nova", cfg.Auth.APIKeys)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("mudança no arquivo de API keys não foi aplicada")
+	}
+
+	cancel()
+	<-done
+	for _, span := range recorder.Ended() {
+		attrs := attributes(span.Attributes())
+		if attrs["config.changed"] != "auth.api_keys" || attrs["config.restart_required"] != "" {
+			t.Errorf("config.changed = %q, config.restart_required = %q", attrs["config.changed"], attrs["config.restart_required"])
+		}
+	}
+}
+
+// attributes converte os atributos do span em texto, com listas separadas
+// por vírgula
+func attributes(kvs []attribute.KeyValue) map[string]string {
+	m := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		if kv.Value.Type() == attribute.STRINGSLICE {
+			m[string(kv.Key)] = strings.Join(kv.Value.AsStringSlice(), ",")
+			continue
+		}
+		m[string(kv.Key)] = kv.Value.Emit()
+	}
+	return m
+}
@@ -0,0 +1,59 @@
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Header informa ao serviço chamado quanto tempo resta para a requisição,
+// em milissegundos. Um valor relativo evita depender do relógio do outro
+// serviço
+const Header = "X-Request-Timeout"
+
+// Parse interpreta o valor do header. Valores menores ou iguais a zero
+// indicam que o prazo já terminou
+func Parse(value string) (time.Duration, bool) {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// Format converte o tempo restante no valor do header
+func Format(remaining time.Duration) string {
+	return strconv.FormatInt(remaining.Milliseconds(), 10)
+}
+
+// Transport é um http.RoundTripper que envia o tempo restante do contexto
+// da requisição no header e não faz a chamada quando o prazo já terminou
+type Transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport cria o transport de propagação do prazo sobre o transport base
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{base: base}
+}
+
+// RoundTrip envia a requisição com o tempo restante
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, context.DeadlineExceeded
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, Format(remaining))
+	return t.base.RoundTrip(req)
+}
@@ -1,28 +1,38 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"io"
-	"log"
+	"errors"
 	"net/http"
+	"strconv"
 
+	"servico-a/internal/breaker"
+	"servico-a/internal/cache"
+	"servico-a/internal/logging"
 	"servico-a/internal/models"
+	"servico-a/internal/problem"
 	"servico-a/internal/services"
 	"servico-a/internal/validators"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // CEPHandler é responsável por lidar com requisições de CEP
 type CEPHandler struct {
 	serviceBClient *services.ServiceBClient
+	cache          *cache.Cache
 }
 
-// NewCEPHandler cria uma nova instância do handler de CEP
-func NewCEPHandler(serviceBClient *services.ServiceBClient) *CEPHandler {
+// NewCEPHandler cria uma nova instância do handler de CEP. Com um cache, as
+// temperaturas são servidas dele e o Serviço B só é chamado quando a
+// resposta está ausente ou expirada; nil desabilita o cache
+func NewCEPHandler(serviceBClient *services.ServiceBClient, responseCache *cache.Cache) *CEPHandler {
 	return &CEPHandler{
 		serviceBClient: serviceBClient,
+		cache:          responseCache,
 	}
 }
 
@@ -32,18 +42,8 @@ func (h *CEPHandler) HandleCEP(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tracer.Start(r.Context(), "HandleCEP")
 	defer span.End()
 
-	// Configura headers CORS e Content-Type
+	// Configura Content-Type
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	// Handle preflight requests
-	if r.Method == "OPTIONS" {
-		span.SetAttributes(attribute.String("http.method", r.Method))
-		w.WriteHeader(http.StatusOK)
-		return
-	}
 
 	// Apenas aceita método POST
 	if r.Method != http.MethodPost {
@@ -52,28 +52,26 @@ func (h *CEPHandler) HandleCEP(w http.ResponseWriter, r *http.Request) {
 			attribute.String("error", "method not allowed"),
 		)
 		span.SetStatus(codes.Error, "method not allowed")
-		h.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "method not allowed")
 		return
 	}
 
 	span.SetAttributes(attribute.String("http.method", r.Method))
 
-	// Lê o body da requisição
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Erro ao ler body da requisição: %v", err)
-		span.SetStatus(codes.Error, "failed to read request body")
-		h.writeErrorResponse(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-	defer r.Body.Close()
-
-	// Parse do JSON
+	// Lê e decodifica o body da requisição
 	var cepReq models.CEPRequest
-	if err := json.Unmarshal(body, &cepReq); err != nil {
-		log.Printf("Erro ao fazer parse do JSON: %v", err)
-		span.SetStatus(codes.Error, "invalid json format")
-		h.writeErrorResponse(w, http.StatusBadRequest, "invalid json format")
+	if err := decodeJSONBody(w, r, &cepReq); err != nil {
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			logging.Warnf(ctx, "Body da requisição rejeitado: %v", reqErr)
+			span.SetStatus(codes.Error, "invalid request body")
+			problem.Write(w, r, reqErr.status, reqErr.code, reqErr.message)
+			return
+		}
+
+		logging.Warnf(ctx, "Erro ao ler body da requisição: %v", err)
+		span.SetStatus(codes.Error, "failed to read request body")
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternalError, "internal server error")
 		return
 	}
 
@@ -81,37 +79,81 @@ func (h *CEPHandler) HandleCEP(w http.ResponseWriter, r *http.Request) {
 
 	// Valida o CEP
 	if !validators.ValidateCEP(cepReq.CEP) {
-		log.Printf("CEP inválido recebido: %s", cepReq.CEP)
+		logging.Printf(ctx, "CEP inválido recebido: %s", cepReq.CEP)
 		span.SetAttributes(attribute.Bool("cep.valid", false))
 		span.SetStatus(codes.Error, "invalid zipcode")
-		h.writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid zipcode")
+		problem.Write(w, r, http.StatusUnprocessableEntity, problem.CodeInvalidZipcode, "invalid zipcode")
 		return
 	}
 
 	span.SetAttributes(attribute.Bool("cep.valid", true))
-	log.Printf("CEP válido recebido: %s", cepReq.CEP)
+	logging.Debugf(ctx, "CEP válido recebido: %s", cepReq.CEP)
 
-	// Encaminha para o Serviço B
-	response, err := h.serviceBClient.ForwardCEPRequest(ctx, cepReq)
+	// Busca no cache ou encaminha para o Serviço B
+	temperature, err := h.getTemperature(ctx, w, cepReq)
 	if err != nil {
-		log.Printf("Erro ao comunicar com Serviço B: %v", err)
-		span.SetStatus(codes.Error, "failed to communicate with service B")
-		h.writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		logging.Errorf(ctx, "Erro ao comunicar com Serviço B: %v", err)
+		span.RecordError(err)
+		h.writeServiceBError(w, r, span, err)
 		return
 	}
 
 	span.SetAttributes(
-		attribute.Int("response.status_code", response.StatusCode),
-		attribute.Int("response.body_size", len(response.Body)),
+		attribute.String("city.name", temperature.City),
+		attribute.Float64("temperature.celsius", temperature.TempC),
 	)
 
-	// Retorna a resposta do Serviço B
-	w.WriteHeader(response.StatusCode)
-	w.Write(response.Body)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(temperature)
 }
 
-// writeErrorResponse escreve uma resposta de erro padronizada
-func (h *CEPHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(models.ErrorResponse{Message: message})
+// getTemperature consulta o cache, quando habilitado, ou o Serviço B. A
+// origem da resposta é informada nos headers X-Cache e Age
+func (h *CEPHandler) getTemperature(ctx context.Context, w http.ResponseWriter, cepReq models.CEPRequest) (*models.TemperatureResponse, error) {
+	if h.cache == nil {
+		return h.serviceBClient.ForwardCEPRequest(ctx, cepReq)
+	}
+
+	temperature, result, err := h.cache.Get(ctx, cepReq.CEP)
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Bool("cache.hit", result.Status != cache.StatusMiss),
+		attribute.String("cache.status", string(result.Status)),
+	)
+	w.Header().Set("X-Cache", string(result.Status))
+	if result.Status != cache.StatusMiss {
+		w.Header().Set("Age", strconv.Itoa(int(result.Age.Seconds())))
+	}
+
+	return temperature, err
+}
+
+// writeServiceBError traduz os erros do cliente do Serviço B para o
+// contrato de respostas do Serviço A, registrando o status no span do
+// handler. Falhas de comunicação com o Serviço B mantêm o status 500
+func (h *CEPHandler) writeServiceBError(w http.ResponseWriter, r *http.Request, span trace.Span, err error) {
+
+	switch {
+	case errors.Is(err, services.ErrInvalidZipcode):
+		span.SetStatus(codes.Error, "invalid zipcode")
+		problem.Write(w, r, http.StatusUnprocessableEntity, problem.CodeInvalidZipcode, "invalid zipcode")
+	case errors.Is(err, services.ErrZipcodeNotFound):
+		span.SetStatus(codes.Error, "zipcode not found")
+		problem.Write(w, r, http.StatusNotFound, problem.CodeZipcodeNotFound, "can not find zipcode")
+	case errors.Is(err, context.DeadlineExceeded):
+		span.SetStatus(codes.Error, "deadline exceeded")
+		problem.Write(w, r, http.StatusGatewayTimeout, problem.CodeDeadlineExceeded, "request deadline exceeded")
+	case errors.Is(err, breaker.ErrOpen):
+		span.SetStatus(codes.Error, "circuit breaker open")
+		problem.Write(w, r, http.StatusServiceUnavailable, problem.CodeUpstreamUnavailable, "service temporarily unavailable")
+	case errors.Is(err, services.ErrUnavailable):
+		span.SetStatus(codes.Error, "service B unavailable")
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeUpstreamUnavailable, "upstream service unavailable")
+	case errors.Is(err, services.ErrProtocol):
+		span.SetStatus(codes.Error, "invalid response from service B")
+		problem.Write(w, r, http.StatusBadGateway, problem.CodeUpstreamInvalidResponse, "invalid response from upstream service")
+	default:
+		span.SetStatus(codes.Error, "internal server error")
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternalError, "internal server error")
+	}
 }
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"servico-a/internal/balancer"
+	"servico-a/internal/breaker"
+	"servico-a/internal/cache"
+	"servico-a/internal/models"
+	"servico-a/internal/retry"
+	"servico-a/internal/services"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHandleCEP(t *testing.T) {
+	serviceB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(models.TemperatureResponse{City: "São Paulo", TempC: 20, TempF: 68, TempK: 293.15})
+	}))
+	defer serviceB.Close()
+
+	handler := NewCEPHandler(services.NewServiceBClient(newTestBalancer(t, serviceB.URL), nil, nil, retry.Policy{}, nil), nil)
+
+	tests := []struct {
+		name            string
+		method          string
+		contentType     string
+		body            string
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{
+			name:           "CEP válido é encaminhado ao Serviço B",
+			method:         http.MethodPost,
+			contentType:    "application/json",
+			body:           `{"cep": "01310100"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Content-Type com charset é aceito",
+			method:         http.MethodPost,
+			contentType:    "application/json; charset=utf-8",
+			body:           `{"cep": "01310100"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:            "Método não permitido",
+			method:          http.MethodGet,
+			expectedStatus:  http.StatusMethodNotAllowed,
+			expectedMessage: "method not allowed",
+		},
+		{
+			name:            "Content-Type diferente de JSON",
+			method:          http.MethodPost,
+			contentType:     "text/plain",
+			body:            `{"cep": "01310100"}`,
+			expectedStatus:  http.StatusUnsupportedMediaType,
+			expectedMessage: "Content-Type header must be application/json",
+		},
+		{
+			name:            "Sem Content-Type",
+			method:          http.MethodPost,
+			body:            `{"cep": "01310100"}`,
+			expectedStatus:  http.StatusUnsupportedMediaType,
+			expectedMessage: "Content-Type header must be application/json",
+		},
+		{
+			name:            "Body vazio",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            ``,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "request body must not be empty",
+		},
+		{
+			name:            "JSON malformado",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": "01310100",}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "request body contains badly-formed JSON (at position 20)",
+		},
+		{
+			name:            "JSON incompleto",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": "01310100"`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "request body contains badly-formed JSON",
+		},
+		{
+			name:            "Body que não é um objeto",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `["01310100"]`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "request body must be a JSON object",
+		},
+		{
+			name:            "CEP numérico",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": 12345678}`,
+			expectedStatus:  http.StatusUnprocessableEntity,
+			expectedMessage: `request body contains an invalid value for the "cep" field (expected string)`,
+		},
+		{
+			name:            "Campo desconhecido",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": "01310100", "city": "São Paulo"}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: `request body contains unknown field "city"`,
+		},
+		{
+			name:            "Conteúdo após o objeto JSON",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": "01310100"}{"cep": "01310100"}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "request body must only contain a single JSON object",
+		},
+		{
+			name:            "Body maior que o limite",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": "` + strings.Repeat("1", maxRequestBodyBytes) + `"}`,
+			expectedStatus:  http.StatusRequestEntityTooLarge,
+			expectedMessage: "request body must not be larger than 4096 bytes",
+		},
+		{
+			name:            "CEP com formato inválido",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": "123"}`,
+			expectedStatus:  http.StatusUnprocessableEntity,
+			expectedMessage: "invalid zipcode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.HandleCEP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("HandleCEP() status = %d, expected %d (body: %s)", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+
+			if tt.expectedMessage == "" {
+				return
+			}
+
+			var errResp models.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil {
+				t.Fatalf("resposta de erro não é JSON válido: %v", err)
+			}
+			if errResp.Message != tt.expectedMessage {
+				t.Errorf("HandleCEP() message = %q, expected %q", errResp.Message, tt.expectedMessage)
+			}
+		})
+	}
+}
+
+func TestHandleCEPServiceBError(t *testing.T) {
+	serviceB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Message: "can not find zipcode"})
+	}))
+	defer serviceB.Close()
+
+	handler := NewCEPHandler(services.NewServiceBClient(newTestBalancer(t, serviceB.URL), nil, nil, retry.Policy{}, nil), nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"cep": "99999999"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/problem+json")
+	rec := httptest.NewRecorder()
+
+	handler.HandleCEP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("HandleCEP() status = %d, expected %d", rec.Code, http.StatusNotFound)
+	}
+
+	var p models.ProblemDetails
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("resposta de erro não é JSON válido: %v", err)
+	}
+	if p.Code != "ZIPCODE_NOT_FOUND" || p.Detail != "can not find zipcode" {
+		t.Errorf("HandleCEP() problem = %+v", p)
+	}
+}
+
+func TestHandleCEPServiceBErrorSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	serviceB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Message: "internal server error"})
+	}))
+	defer serviceB.Close()
+
+	handler := NewCEPHandler(services.NewServiceBClient(newTestBalancer(t, serviceB.URL), nil, nil, retry.Policy{}, nil), nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"cep": "01310100"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	handler.HandleCEP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("HandleCEP() status = %d, expected %d", rec.Code, http.StatusInternalServerError)
+	}
+	for _, span := range recorder.Ended() {
+		if span.Name() != "HandleCEP" {
+			continue
+		}
+		if span.Status().Code != codes.Error || span.Status().Description != "service B unavailable" {
+			t.Errorf("status do span HandleCEP = %+v, expected erro do Serviço B", span.Status())
+		}
+		return
+	}
+	t.Fatal("span HandleCEP não encontrado")
+}
+
+func TestHandleCEPCircuitBreakerOpen(t *testing.T) {
+	calls := 0
+	serviceB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Message: "internal server error"})
+	}))
+	defer serviceB.Close()
+
+	circuitBreaker := breaker.New("servico-b", breaker.Settings{ConsecutiveFailures: 1, OpenTimeout: time.Minute})
+	handler := NewCEPHandler(services.NewServiceBClient(newTestBalancer(t, serviceB.URL), circuitBreaker, nil, retry.Policy{}, nil), nil)
+
+	expected := []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusServiceUnavailable}
+	for i, expectedStatus := range expected {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"cep": "01310100"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		handler.HandleCEP(rec, req)
+
+		if rec.Code != expectedStatus {
+			t.Errorf("requisição %d: status = %d, expected %d", i+1, rec.Code, expectedStatus)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("Serviço B recebeu %d chamadas, expected 1", calls)
+	}
+}
+
+func TestHandleCEPCache(t *testing.T) {
+	calls := 0
+	serviceB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.TemperatureResponse{City: "São Paulo", TempC: 20, TempF: 68, TempK: 293.15})
+	}))
+	defer serviceB.Close()
+
+	client := services.NewServiceBClient(newTestBalancer(t, serviceB.URL), nil, nil, retry.Policy{}, nil)
+	responseCache := cache.New(cache.Settings{TTL: time.Minute, MaxEntries: 10}, func(ctx context.Context, cep string) (*models.TemperatureResponse, error) {
+		return client.ForwardCEPRequest(ctx, models.CEPRequest{CEP: cep})
+	})
+	handler := NewCEPHandler(client, responseCache)
+
+	expected := []string{"MISS", "HIT"}
+	for i, expectedCache := range expected {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"cep": "01310100"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		handler.HandleCEP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("requisição %d: status = %d, expected %d", i+1, rec.Code, http.StatusOK)
+		}
+		if got := rec.Header().Get("X-Cache"); got != expectedCache {
+			t.Errorf("requisição %d: X-Cache = %q, expected %q", i+1, got, expectedCache)
+		}
+		if hasAge := rec.Header().Get("Age") != ""; hasAge != (expectedCache == "HIT") {
+			t.Errorf("requisição %d: Age = %q", i+1, rec.Header().Get("Age"))
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("chamadas ao Serviço B = %d, expected 1", calls)
+	}
+}
+
+// newTestBalancer cria um balanceador sem ejeção e sem health checks
+func newTestBalancer(t *testing.T, urls ...string) *balancer.Balancer {
+	t.Helper()
+
+	endpoints, err := balancer.New("servico-b", urls, balancer.Settings{})
+	if err != nil {
+		t.Fatalf("balancer.New() err = %v", err)
+	}
+	return endpoints
+}
@@ -0,0 +1,119 @@
+package hedge
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxSamples é a quantidade de latências recentes usadas no percentil
+	maxSamples = 1000
+	// minSamples é a quantidade mínima de latências para usar o percentil
+	minSamples = 20
+	// recomputeEvery define a cada quantas observações o percentil é recalculado
+	recomputeEvery = 50
+	// maxTokens limita a rajada de hedges após um período sem hedges
+	maxTokens = 10
+)
+
+// Policy define quando uma segunda requisição é enviada. Com Percentile
+// maior que zero, o atraso acompanha o percentil das latências observadas;
+// Delay é usado enquanto não há amostras suficientes. MaxRatio limita os
+// hedges a uma fração das requisições
+type Policy struct {
+	Delay      time.Duration
+	Percentile float64
+	MaxRatio   float64
+}
+
+// Hedger calcula o atraso dos hedges e limita a fração de requisições que
+// recebem um hedge
+type Hedger struct {
+	policy Policy
+
+	mu       sync.Mutex
+	samples  []time.Duration
+	next     int
+	pending  int
+	observed time.Duration
+	tokens   float64
+}
+
+// New cria um Hedger com a política informada
+func New(policy Policy) *Hedger {
+	return &Hedger{
+		policy:   policy,
+		samples:  make([]time.Duration, 0, maxSamples),
+		observed: policy.Delay,
+	}
+}
+
+// Delay retorna quanto tempo aguardar a primeira resposta antes do hedge
+func (h *Hedger) Delay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.policy.Percentile <= 0 || len(h.samples) < minSamples {
+		return h.policy.Delay
+	}
+	return h.observed
+}
+
+// Observe registra a latência de uma resposta recebida
+func (h *Hedger) Observe(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) < maxSamples {
+		h.samples = append(h.samples, latency)
+	} else {
+		h.samples[h.next] = latency
+		h.next = (h.next + 1) % maxSamples
+	}
+
+	h.pending++
+	if h.policy.Percentile > 0 && len(h.samples) >= minSamples &&
+		(h.pending >= recomputeEvery || len(h.samples) == minSamples) {
+		h.observed = percentile(h.samples, h.policy.Percentile)
+		h.pending = 0
+	}
+}
+
+// Request contabiliza uma requisição original, liberando uma fração de hedge
+func (h *Hedger) Request() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.tokens = math.Min(maxTokens, h.tokens+h.policy.MaxRatio)
+}
+
+// Allow indica se um hedge pode ser enviado sem exceder a fração permitida
+func (h *Hedger) Allow() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// A tolerância compensa o erro de arredondamento da soma das frações
+	if h.tokens < 1-1e-9 {
+		return false
+	}
+	h.tokens = math.Max(0, h.tokens-1)
+	return true
+}
+
+// percentile calcula o percentil p (entre 0 e 1) das amostras
+func percentile(samples []time.Duration, p float64) time.Duration {
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	index := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return sorted[index]
+}
@@ -0,0 +1,53 @@
+package hedge
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	h := New(Policy{Delay: 200 * time.Millisecond, Percentile: 0.95, MaxRatio: 0.1})
+
+	// Sem amostras suficientes, usa o atraso configurado
+	if got := h.Delay(); got != 200*time.Millisecond {
+		t.Errorf("Delay() = %v, expected 200ms", got)
+	}
+
+	// 95% das respostas em 10ms e a cauda em 1s
+	for i := 0; i < 95; i++ {
+		h.Observe(10 * time.Millisecond)
+	}
+	for i := 0; i < 5; i++ {
+		h.Observe(time.Second)
+	}
+	if got := h.Delay(); got != 10*time.Millisecond {
+		t.Errorf("Delay() = %v, expected 10ms", got)
+	}
+}
+
+func TestDelayFixed(t *testing.T) {
+	h := New(Policy{Delay: 50 * time.Millisecond, MaxRatio: 0.1})
+
+	for i := 0; i < 100; i++ {
+		h.Observe(time.Second)
+	}
+	if got := h.Delay(); got != 50*time.Millisecond {
+		t.Errorf("Delay() = %v, expected 50ms", got)
+	}
+}
+
+func TestAllowRatio(t *testing.T) {
+	h := New(Policy{Delay: time.Millisecond, MaxRatio: 0.1})
+
+	hedges := 0
+	for i := 0; i < 100; i++ {
+		h.Request()
+		if h.Allow() {
+			hedges++
+		}
+	}
+
+	if hedges != 10 {
+		t.Errorf("hedges = %d, expected 10 para 10%% de 100 requisições", hedges)
+	}
+}
@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"servico-a/internal/auth"
+	"servico-a/internal/requestid"
+)
+
+// Level define a severidade mínima das mensagens registradas
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// level é o nível mínimo atual, alterado em tempo de execução por SetLevel
+var level atomic.Int32
+
+func init() {
+	level.Store(int32(LevelInfo))
+}
+
+// ParseLevel converte o nome do nível (debug, info, warn ou error)
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
+// String retorna o nome do nível
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("Level(%d)", int32(l))
+	}
+}
+
+// SetLevel altera o nível mínimo das mensagens registradas
+func SetLevel(l Level) {
+	level.Store(int32(l))
+}
+
+// CurrentLevel retorna o nível mínimo atual
+func CurrentLevel() Level {
+	return Level(level.Load())
+}
+
+// Enabled indica se mensagens do nível são registradas
+func Enabled(l Level) bool {
+	return l >= Level(level.Load())
+}
+
+// Debugf registra detalhes do processamento, omitidos fora do nível debug
+func Debugf(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, LevelDebug, "[DEBUG] ", format, v...)
+}
+
+// Printf registra a mensagem no log padrão, prefixada com o ID da requisição
+// e a identificação do cliente (ID da API key ou client_id do token)
+// presentes no contexto
+func Printf(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, LevelInfo, "", format, v...)
+}
+
+// Warnf registra situações inesperadas das quais o serviço se recupera
+func Warnf(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, LevelWarn, "[WARN] ", format, v...)
+}
+
+// Errorf registra falhas no atendimento de uma requisição
+func Errorf(ctx context.Context, format string, v ...interface{}) {
+	logf(ctx, LevelError, "[ERROR] ", format, v...)
+}
+
+func logf(ctx context.Context, l Level, prefix, format string, v ...interface{}) {
+	if !Enabled(l) {
+		return
+	}
+
+	var fields []string
+	if id := requestid.FromContext(ctx); id != "" {
+		fields = append(fields, "request_id="+id)
+	}
+	if identity, ok := auth.FromContext(ctx); ok {
+		if identity.KeyID != "" {
+			fields = append(fields, "key_id="+identity.KeyID)
+		}
+		if identity.ClientID != "" {
+			fields = append(fields, "client_id="+identity.ClientID)
+		}
+	}
+	if len(fields) > 0 {
+		log.Printf("%s[%s] %s", prefix, strings.Join(fields, " "), fmt.Sprintf(format, v...))
+		return
+	}
+	log.Printf(prefix+format, v...)
+}
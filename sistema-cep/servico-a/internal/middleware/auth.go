@@ -0,0 +1,293 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"servico-a/internal/auth"
+	"servico-a/internal/config"
+	"servico-a/internal/logging"
+	"servico-a/internal/problem"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// jwksTimeout limita cada consulta ao JWKS publicado pelo emissor
+const jwksTimeout = 5 * time.Second
+
+// Auth autentica as requisições pela API key informada no header
+// configurado ou por um token JWT no header Authorization. Requisições sem
+// credenciais ou com credenciais inválidas recebem 401; chaves desabilitadas
+// ou sem acesso à rota e tokens sem o escopo exigido recebem 403. A
+// configuração pode ser trocada com Update sem interromper as requisições
+type Auth struct {
+	policy   atomic.Pointer[authPolicy]
+	rejected metric.Int64Counter
+	client   *http.Client
+}
+
+// authPolicy é a configuração imutável da autenticação
+type authPolicy struct {
+	enabled bool
+	header  string
+	store   *auth.Store
+	apiKeys bool
+	jwt     *jwtPolicy
+}
+
+// jwtPolicy valida os tokens JWT. O verificador é mantido entre recargas
+// que não alteram a configuração JWT, preservando o cache do JWKS
+type jwtPolicy struct {
+	cfg      config.JWTConfig
+	verifier *auth.Verifier
+}
+
+// NewAuth cria o middleware de autenticação a partir da configuração
+func NewAuth(cfg config.AuthConfig) (*Auth, error) {
+	rejected, err := otel.Meter("servico-a").Int64Counter(
+		"http.server.auth.rejected",
+		metric.WithDescription("Requisições rejeitadas pela autenticação"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	a := &Auth{
+		rejected: rejected,
+		client: &http.Client{
+			Timeout:   jwksTimeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+	}
+	if err := a.Update(cfg); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Update troca as chaves, o header e a validação de tokens usados nas
+// próximas requisições. Em caso de erro a configuração atual é mantida
+func (a *Auth) Update(cfg config.AuthConfig) error {
+	apply, err := a.Prepare(cfg)
+	if err != nil {
+		return err
+	}
+	apply()
+	return nil
+}
+
+// Prepare valida a configuração e retorna a função que a aplica às
+// próximas requisições. Nada é alterado até a função ser chamada
+func (a *Auth) Prepare(cfg config.AuthConfig) (func(), error) {
+	keys := make([]auth.Key, len(cfg.APIKeys))
+	for i, key := range cfg.APIKeys {
+		keys[i] = auth.Key{
+			ID:                key.ID,
+			Owner:             key.Owner,
+			Hash:              key.Hash,
+			Enabled:           key.IsEnabled(),
+			RequestsPerSecond: key.RequestsPerSecond,
+			Burst:             key.Burst,
+			AllowedEndpoints:  key.AllowedEndpoints,
+		}
+	}
+
+	store, err := auth.NewStore(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &authPolicy{
+		enabled: cfg.Enabled,
+		header:  cfg.Header,
+		store:   store,
+		apiKeys: len(keys) > 0,
+	}
+	if cfg.JWT.Enabled {
+		policy.jwt = a.jwtPolicy(cfg.JWT)
+	}
+	return func() { a.policy.Store(policy) }, nil
+}
+
+// jwtPolicy reaproveita o verificador atual se a configuração JWT não mudou
+func (a *Auth) jwtPolicy(cfg config.JWTConfig) *jwtPolicy {
+	if current := a.policy.Load(); current != nil && current.jwt != nil && current.jwt.cfg == cfg {
+		return current.jwt
+	}
+
+	var keys *auth.KeySet
+	if cfg.JWKSFile != "" {
+		keys = auth.NewFileKeySet(cfg.JWKSFile, cfg.JWKSCacheTTL)
+	} else {
+		keys = auth.NewURLKeySet(cfg.JWKSURL, cfg.JWKSCacheTTL, a.client)
+	}
+	return &jwtPolicy{
+		cfg: cfg,
+		verifier: auth.NewVerifier(keys, auth.VerifierSettings{
+			Issuer:    cfg.Issuer,
+			Audience:  cfg.Audience,
+			ClockSkew: cfg.ClockSkew,
+		}),
+	}
+}
+
+// Handler envolve o próximo handler exigindo uma API key ou um token JWT
+// válidos. A identidade do cliente fica disponível no contexto com
+// auth.FromContext
+func (a *Auth) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := a.policy.Load()
+		if !policy.enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var identity *auth.Identity
+		if token, ok := bearerToken(r); ok && policy.jwt != nil {
+			identity = a.authenticateJWT(w, r, policy, token)
+		} else {
+			identity = a.authenticateAPIKey(w, r, policy)
+		}
+		if identity == nil {
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), identity)))
+	})
+}
+
+// authenticateAPIKey autentica a API key do header configurado. Se a chave
+// for rejeitada, escreve a resposta de erro e retorna nil
+func (a *Auth) authenticateAPIKey(w http.ResponseWriter, r *http.Request, policy *authPolicy) *auth.Identity {
+	apiKey := r.Header.Get(policy.header)
+	key, err := policy.store.Authenticate(apiKey)
+	if key != nil {
+		// Apenas o ID da chave é registrado, nunca a chave
+		trace.SpanFromContext(r.Context()).SetAttributes(
+			attribute.String("auth.method", auth.MethodAPIKey),
+			attribute.String("auth.key_id", key.ID),
+		)
+	}
+
+	switch {
+	case errors.Is(err, auth.ErrUnknownKey):
+		reason := "invalid_key"
+		if apiKey == "" {
+			reason = "missing_key"
+		}
+		a.reject(r, reason, "")
+		policy.challenge(w, "")
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "missing or invalid credentials")
+		return nil
+	case errors.Is(err, auth.ErrKeyDisabled):
+		a.reject(r, "key_disabled", "API key "+key.ID)
+		problem.Write(w, r, http.StatusForbidden, problem.CodeForbidden, "API key disabled")
+		return nil
+	case !key.Allows(r.URL.Path):
+		a.reject(r, "endpoint_not_allowed", "API key "+key.ID)
+		problem.Write(w, r, http.StatusForbidden, problem.CodeForbidden, "API key not allowed to access this endpoint")
+		return nil
+	}
+
+	return &auth.Identity{
+		Method:            auth.MethodAPIKey,
+		KeyID:             key.ID,
+		Owner:             key.Owner,
+		RequestsPerSecond: key.RequestsPerSecond,
+		Burst:             key.Burst,
+	}
+}
+
+// authenticateJWT valida o token e exige o escopo configurado. Se o token
+// for rejeitado, escreve a resposta de erro e retorna nil
+func (a *Auth) authenticateJWT(w http.ResponseWriter, r *http.Request, policy *authPolicy, token string) *auth.Identity {
+	claims, err := policy.jwt.verifier.Verify(r.Context(), token)
+	switch {
+	case errors.Is(err, auth.ErrInvalidToken):
+		a.reject(r, "invalid_token", "")
+		logging.Debugf(r.Context(), "Token JWT rejeitado: %v", err)
+		policy.challenge(w, `error="invalid_token"`)
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "missing or invalid credentials")
+		return nil
+	case err != nil:
+		// Sem o JWKS nenhum token pode ser validado; o cliente pode tentar
+		// novamente, então a falha não é tratada como credencial inválida
+		a.reject(r, "jwks_unavailable", "")
+		logging.Errorf(r.Context(), "Erro ao obter o JWKS para validar o token: %v", err)
+		w.Header().Set("Retry-After", "1")
+		problem.Write(w, r, http.StatusServiceUnavailable, problem.CodeUpstreamUnavailable, "authentication temporarily unavailable")
+		return nil
+	}
+
+	trace.SpanFromContext(r.Context()).SetAttributes(
+		attribute.String("auth.method", auth.MethodJWT),
+		attribute.String("auth.client_id", claims.ClientID),
+	)
+
+	if scope := policy.jwt.cfg.RequiredScope; !claims.HasScope(scope) {
+		a.reject(r, "insufficient_scope", "cliente "+claims.ClientID)
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope=`+strconv.Quote(scope))
+		problem.Write(w, r, http.StatusForbidden, problem.CodeForbidden, "token missing required scope "+scope)
+		return nil
+	}
+
+	return &auth.Identity{
+		Method:   auth.MethodJWT,
+		Owner:    claims.Subject,
+		ClientID: claims.ClientID,
+		Scopes:   claims.Scopes,
+	}
+}
+
+// challenge anuncia no WWW-Authenticate os métodos aceitos. bearerError
+// detalha por que o token recebido foi rejeitado
+func (p *authPolicy) challenge(w http.ResponseWriter, bearerError string) {
+	if p.apiKeys {
+		w.Header().Add("WWW-Authenticate", "APIKey header="+strconv.Quote(p.header))
+	}
+	if p.jwt != nil {
+		challenge := "Bearer"
+		if bearerError != "" {
+			challenge += " " + bearerError
+		}
+		w.Header().Add("WWW-Authenticate", challenge)
+	}
+}
+
+// bearerToken extrai o token do header Authorization: Bearer
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// reject registra a rejeição no span, na métrica e no log. client identifica
+// a API key ou o cliente do token, quando conhecidos
+func (a *Auth) reject(r *http.Request, reason, client string) {
+	trace.SpanFromContext(r.Context()).AddEvent("auth.rejected", trace.WithAttributes(
+		attribute.String("auth.reason", reason),
+	))
+	if a.rejected != nil {
+		a.rejected.Add(r.Context(), 1, metric.WithAttributes(
+			attribute.String("auth.reason", reason),
+		))
+	}
+
+	if client == "" {
+		logging.Warnf(r.Context(), "Requisição para %s rejeitada pela autenticação (%s)", r.URL.Path, reason)
+		return
+	}
+	logging.Warnf(r.Context(), "Requisição para %s com %s rejeitada (%s)", r.URL.Path, client, reason)
+}
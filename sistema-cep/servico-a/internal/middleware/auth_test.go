@@ -0,0 +1,371 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"servico-a/internal/auth"
+	"servico-a/internal/config"
+	"servico-a/internal/logging"
+	"servico-a/internal/models"
+	"servico-a/internal/problem"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestAuth(t *testing.T) {
+	disabled := false
+	cfg := config.AuthConfig{
+		Enabled: true,
+		Header:  "X-API-Key",
+		APIKeys: []config.APIKeyConfig{
+			{ID: "parceiro", Owner: "Parceiro", Hash: auth.Hash("chave-parceiro"), RequestsPerSecond: 20, Burst: 40},
+			{ID: "legado", Owner: "Legado", Hash: auth.Hash("chave-legado"), Enabled: &disabled},
+			{ID: "restrita", Owner: "Restrita", Hash: auth.Hash("chave-restrita"), AllowedEndpoints: []string{"/v2/*"}},
+		},
+	}
+	authenticator, err := NewAuth(cfg)
+	if err != nil {
+		t.Fatalf("NewAuth() err = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		apiKey         string
+		expectedStatus int
+		expectedCode   string
+		expectedKeyID  string
+	}{
+		{
+			name:           "Sem API key",
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "UNAUTHORIZED",
+		},
+		{
+			name:           "API key desconhecida",
+			apiKey:         "chave-desconhecida",
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "UNAUTHORIZED",
+		},
+		{
+			name:           "API key desabilitada",
+			apiKey:         "chave-legado",
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "FORBIDDEN",
+			expectedKeyID:  "legado",
+		},
+		{
+			name:           "API key sem acesso à rota",
+			apiKey:         "chave-restrita",
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "FORBIDDEN",
+			expectedKeyID:  "restrita",
+		},
+		{
+			name:           "API key válida",
+			apiKey:         "chave-parceiro",
+			expectedStatus: http.StatusOK,
+			expectedKeyID:  "parceiro",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var identity *auth.Identity
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				identity, _ = auth.FromContext(r.Context())
+				logging.Printf(r.Context(), "requisição autenticada")
+				w.WriteHeader(http.StatusOK)
+			})
+
+			recorder := tracetest.NewSpanRecorder()
+			ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(t.Context(), "request")
+
+			var logs bytes.Buffer
+			defer log.SetOutput(log.Writer())
+			log.SetOutput(&logs)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil).WithContext(ctx)
+			req.Header.Set("Accept", problem.ContentType)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			authenticator.Handler(next).ServeHTTP(rec, req)
+			span.End()
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+
+			if tt.expectedCode != "" {
+				var body models.ProblemDetails
+				if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+					t.Fatalf("resposta não é problem+json: %v", err)
+				}
+				if body.Code != tt.expectedCode || rec.Header().Get("Content-Type") != problem.ContentType {
+					t.Errorf("code = %q, Content-Type = %q", body.Code, rec.Header().Get("Content-Type"))
+				}
+				if identity != nil {
+					t.Error("requisição rejeitada chegou ao próximo handler")
+				}
+			}
+			if tt.expectedStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") != `APIKey header="X-API-Key"` {
+				t.Errorf("WWW-Authenticate = %q", rec.Header().Get("WWW-Authenticate"))
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				if identity == nil || identity.KeyID != "parceiro" || identity.RequestsPerSecond != 20 || identity.Burst != 40 {
+					t.Fatalf("identidade no contexto = %+v", identity)
+				}
+				if !strings.Contains(logs.String(), "key_id=parceiro") {
+					t.Errorf("log sem o ID da chave:\n%s", logs.String())
+				}
+			}
+
+			// O ID da chave aparece no span, mas a chave em si nunca aparece
+			// no span nem no log
+			spans := recorder.Ended()
+			var keyID string
+			for _, attr := range spans[0].Attributes() {
+				if attr.Key == "auth.key_id" {
+					keyID = attr.Value.AsString()
+				}
+			}
+			if keyID != tt.expectedKeyID {
+				t.Errorf("auth.key_id = %q, expected %q", keyID, tt.expectedKeyID)
+			}
+			if tt.apiKey != "" {
+				for name, output := range map[string]string{"span": fmt.Sprintf("%+v", spans[0]), "log": logs.String()} {
+					if strings.Contains(output, tt.apiKey) {
+						t.Errorf("%s contém a API key:\n%s", name, output)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAuthDisabled(t *testing.T) {
+	authenticator, err := NewAuth(config.AuthConfig{Enabled: false, Header: "X-API-Key"})
+	if err != nil {
+		t.Fatalf("NewAuth() err = %v", err)
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	rec := httptest.NewRecorder()
+	authenticator.Handler(next).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, expected 200 com autenticação desabilitada", rec.Code)
+	}
+}
+
+func TestAuthUpdate(t *testing.T) {
+	authenticator, err := NewAuth(config.AuthConfig{
+		Enabled: true,
+		Header:  "X-API-Key",
+		APIKeys: []config.APIKeyConfig{{ID: "antiga", Hash: auth.Hash("chave-antiga")}},
+	})
+	if err != nil {
+		t.Fatalf("NewAuth() err = %v", err)
+	}
+	handler := authenticator.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(apiKey string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Configuração inválida é rejeitada e a atual é mantida
+	invalid := config.AuthConfig{Enabled: true, Header: "X-API-Key", APIKeys: []config.APIKeyConfig{{ID: "nova", Hash: "md5:abc"}}}
+	if err := authenticator.Update(invalid); err == nil {
+		t.Fatal("Update() err = nil, expected erro de hash inválido")
+	}
+	if status := send("chave-antiga"); status != http.StatusOK {
+		t.Fatalf("chave antiga após Update inválido: status = %d, expected 200", status)
+	}
+
+	// A rotação troca as chaves das próximas requisições
+	err = authenticator.Update(config.AuthConfig{
+		Enabled: true,
+		Header:  "X-API-Key",
+		APIKeys: []config.APIKeyConfig{{ID: "nova", Hash: auth.Hash("chave-nova")}},
+	})
+	if err != nil {
+		t.Fatalf("Update() err = %v", err)
+	}
+	if status := send("chave-antiga"); status != http.StatusUnauthorized {
+		t.Errorf("chave antiga após rotação: status = %d, expected 401", status)
+	}
+	if status := send("chave-nova"); status != http.StatusOK {
+		t.Errorf("chave nova após rotação: status = %d, expected 200", status)
+	}
+}
+
+func TestAuthJWT(t *testing.T) {
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() err = %v", err)
+	}
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	os.WriteFile(jwksFile, []byte(fmt.Sprintf(`{"keys": [{"kty": "EC", "kid": "k1", "use": "sig", "alg": "ES256", "crv": "P-256", "x": %q, "y": %q}]}`,
+		base64.RawURLEncoding.EncodeToString(signingKey.X.Bytes()),
+		base64.RawURLEncoding.EncodeToString(signingKey.Y.Bytes()),
+	)), 0o600)
+
+	// sign gera um token ES256 assinado com a chave local
+	sign := func(claims map[string]any) string {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","kid":"k1","typ":"JWT"}`))
+		payload, _ := json.Marshal(claims)
+		signed := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, signingKey, digest[:])
+		if err != nil {
+			t.Fatalf("ecdsa.Sign() err = %v", err)
+		}
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+	}
+	claims := func(scope string, exp time.Time) map[string]any {
+		return map[string]any{"iss": "https://idp.example.com", "aud": "servico-a", "sub": "svc-relatorios", "client_id": "relatorios", "scope": scope, "exp": exp.Unix()}
+	}
+
+	jwtConfig := config.JWTConfig{
+		Enabled:       true,
+		Issuer:        "https://idp.example.com",
+		Audience:      "servico-a",
+		JWKSFile:      jwksFile,
+		JWKSCacheTTL:  time.Minute,
+		RequiredScope: "temperature:read",
+	}
+	unavailable := jwtConfig
+	unavailable.JWKSFile = filepath.Join(t.TempDir(), "inexistente.json")
+
+	valid := sign(claims("temperature:read", time.Now().Add(time.Hour)))
+
+	tests := []struct {
+		name              string
+		jwt               config.JWTConfig
+		authorization     string
+		expectedStatus    int
+		expectedChallenge string
+	}{
+		{
+			name:           "Token com o escopo exigido",
+			jwt:            jwtConfig,
+			authorization:  "Bearer " + valid,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:              "Token sem o escopo exigido",
+			jwt:               jwtConfig,
+			authorization:     "Bearer " + sign(claims("profile", time.Now().Add(time.Hour))),
+			expectedStatus:    http.StatusForbidden,
+			expectedChallenge: `Bearer error="insufficient_scope", scope="temperature:read"`,
+		},
+		{
+			name:              "Token expirado",
+			jwt:               jwtConfig,
+			authorization:     "Bearer " + sign(claims("temperature:read", time.Now().Add(-time.Hour))),
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer error="invalid_token"`,
+		},
+		{
+			name:              "Sem credenciais",
+			jwt:               jwtConfig,
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: "Bearer",
+		},
+		{
+			name:           "JWKS indisponível",
+			jwt:            unavailable,
+			authorization:  "Bearer " + valid,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator, err := NewAuth(config.AuthConfig{Enabled: true, Header: "X-API-Key", JWT: tt.jwt})
+			if err != nil {
+				t.Fatalf("NewAuth() err = %v", err)
+			}
+
+			var identity *auth.Identity
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				identity, _ = auth.FromContext(r.Context())
+				logging.Printf(r.Context(), "requisição autenticada")
+				w.WriteHeader(http.StatusOK)
+			})
+
+			recorder := tracetest.NewSpanRecorder()
+			ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(t.Context(), "request")
+
+			var logs bytes.Buffer
+			defer log.SetOutput(log.Writer())
+			log.SetOutput(&logs)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil).WithContext(ctx)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			authenticator.Handler(next).ServeHTTP(rec, req)
+			span.End()
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+			if challenge := rec.Header().Get("WWW-Authenticate"); challenge != tt.expectedChallenge {
+				t.Errorf("WWW-Authenticate = %q, expected %q", challenge, tt.expectedChallenge)
+			}
+			if tt.authorization != "" && strings.Contains(logs.String()+fmt.Sprintf("%+v", recorder.Ended()), strings.TrimPrefix(tt.authorization, "Bearer ")) {
+				t.Error("token registrado no log ou no span")
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			if identity == nil || identity.Method != auth.MethodJWT || identity.ClientID != "relatorios" {
+				t.Fatalf("identidade no contexto = %+v", identity)
+			}
+			if !strings.Contains(logs.String(), "client_id=relatorios") {
+				t.Errorf("log sem o client_id:\n%s", logs.String())
+			}
+			var clientID string
+			for _, attr := range recorder.Ended()[0].Attributes() {
+				if attr.Key == "auth.client_id" {
+					clientID = attr.Value.AsString()
+				}
+			}
+			if clientID != "relatorios" {
+				t.Errorf("auth.client_id = %q, expected relatorios", clientID)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies identifica os proxies reversos cujo X-Forwarded-For é confiável
+type TrustedProxies struct {
+	networks []*net.IPNet
+}
+
+// NewTrustedProxies interpreta a lista de IPs ou redes CIDR dos proxies
+// confiáveis. Entradas inválidas são ignoradas e registradas no log
+func NewTrustedProxies(entries []string) *TrustedProxies {
+	t := &TrustedProxies{}
+
+	for _, entry := range entries {
+		cidr := entry
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Printf("Proxy confiável inválido ignorado: %q", entry)
+			continue
+		}
+		t.networks = append(t.networks, network)
+	}
+
+	return t
+}
+
+// contains verifica se o IP pertence a algum proxy confiável
+func (t *TrustedProxies) contains(ip net.IP) bool {
+	for _, network := range t.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP retorna o IP do cliente. O X-Forwarded-For só é considerado
+// quando a conexão vem de um proxy confiável, e é percorrido da direita
+// para a esquerda até o primeiro endereço que não é de um proxy confiável
+func (t *TrustedProxies) ClientIP(r *http.Request) string {
+	remoteIP := parseIP(r.RemoteAddr)
+	if remoteIP == nil {
+		return r.RemoteAddr
+	}
+	if !t.contains(remoteIP) {
+		return remoteIP.String()
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		if !t.contains(ip) {
+			return ip.String()
+		}
+		remoteIP = ip
+	}
+
+	return remoteIP.String()
+}
+
+// parseIP interpreta um endereço com ou sem porta
+func parseIP(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(addr)
+}
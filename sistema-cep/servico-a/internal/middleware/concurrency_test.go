@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"servico-a/internal/config"
+	"servico-a/internal/models"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	limiter := NewConcurrencyLimiter(config.ConcurrencyLimitConfig{
+		Enabled:          true,
+		InitialLimit:     2,
+		MinLimit:         1,
+		MaxLimit:         2,
+		LatencyThreshold: time.Minute,
+		BackoffRatio:     0.9,
+	})
+
+	release := make(chan struct{})
+	var started sync.WaitGroup
+	started.Add(2)
+	handler := limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started.Done()
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Ocupa o limite com duas requisições em andamento
+	var done sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+		}()
+	}
+	started.Wait()
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Accept", "application/problem+json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, expected %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, expected \"1\"", got)
+	}
+	var p models.ProblemDetails
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("resposta de erro não é JSON válido: %v", err)
+	}
+	if p.Code != "SERVER_OVERLOADED" {
+		t.Errorf("code = %q, expected SERVER_OVERLOADED", p.Code)
+	}
+
+	close(release)
+	done.Wait()
+
+	// Com as requisições concluídas, novas requisições voltam a ser aceitas
+	started.Add(1)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status após liberar = %d, expected %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestConcurrencyLimiterDeadline(t *testing.T) {
+	limiter := NewConcurrencyLimiter(config.ConcurrencyLimitConfig{
+		Enabled:          true,
+		InitialLimit:     4,
+		MinLimit:         1,
+		MaxLimit:         4,
+		LatencyThreshold: time.Minute,
+		BackoffRatio:     0.5,
+	})
+	handler := limiter.Handler(DeadlineFunc(func() time.Duration { return 10 * time.Millisecond }, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		w.WriteHeader(http.StatusGatewayTimeout)
+	})))
+
+	// O prazo é aplicado dentro do limite e só chega a ele pelo status 504
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if got := limiter.limiter.Limit(); got != 2 {
+		t.Errorf("Limit() = %d, expected 2 após o prazo esgotado", got)
+	}
+}
+
+func TestConcurrencyLimiterResponseController(t *testing.T) {
+	limiter := NewConcurrencyLimiter(config.ConcurrencyLimitConfig{
+		Enabled:          true,
+		InitialLimit:     1,
+		MinLimit:         1,
+		MaxLimit:         1,
+		LatencyThreshold: time.Minute,
+		BackoffRatio:     0.5,
+	})
+	handler := limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush() err = %v", err)
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if !rec.Flushed {
+		t.Error("resposta não foi enviada pelo Flush do ResponseWriter original")
+	}
+}
+
+func TestConcurrencyLimiterRelease(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		panics        bool
+		expectedLimit int
+	}{
+		{name: "Sucesso", status: http.StatusOK, expectedLimit: 4},
+		{name: "Erro do cliente", status: http.StatusNotFound, expectedLimit: 4},
+		{name: "Erro interno", status: http.StatusInternalServerError, expectedLimit: 2},
+		{name: "Serviço indisponível", status: http.StatusServiceUnavailable, expectedLimit: 2},
+		{name: "Prazo esgotado", status: http.StatusGatewayTimeout, expectedLimit: 2},
+		{name: "Panic no handler", panics: true, expectedLimit: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewConcurrencyLimiter(config.ConcurrencyLimitConfig{
+				Enabled:          true,
+				InitialLimit:     4,
+				MinLimit:         1,
+				MaxLimit:         4,
+				LatencyThreshold: time.Minute,
+				BackoffRatio:     0.5,
+			})
+			handler := limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.panics {
+					panic("falha no handler")
+				}
+				w.WriteHeader(tt.status)
+			}))
+
+			func() {
+				defer func() {
+					if r := recover(); (r != nil) != tt.panics {
+						t.Errorf("recover() = %v, expected panic = %v", r, tt.panics)
+					}
+				}()
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+			}()
+
+			if got := limiter.limiter.InFlight(); got != 0 {
+				t.Errorf("InFlight() = %d, expected 0", got)
+			}
+			if got := limiter.limiter.Limit(); got != tt.expectedLimit {
+				t.Errorf("Limit() = %d, expected %d", got, tt.expectedLimit)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"servico-a/internal/config"
+)
+
+// CORS aplica a política de Cross-Origin Resource Sharing configurada. A
+// política pode ser trocada com Update sem interromper as requisições
+type CORS struct {
+	policy atomic.Pointer[corsPolicy]
+}
+
+// corsPolicy é uma política CORS imutável
+type corsPolicy struct {
+	allowedOrigins   map[string]bool
+	allowAllOrigins  bool
+	allowedMethods   map[string]bool
+	allowedHeaders   map[string]bool
+	methods          string
+	headers          string
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+}
+
+// NewCORS cria o middleware CORS a partir da configuração. Sem origens
+// permitidas o middleware não adiciona nenhum header CORS
+func NewCORS(cfg config.CORSConfig) *CORS {
+	c := &CORS{}
+	c.Update(cfg)
+	return c
+}
+
+// Update troca a política aplicada às próximas requisições
+func (c *CORS) Update(cfg config.CORSConfig) {
+	c.policy.Store(newCORSPolicy(cfg))
+}
+
+// newCORSPolicy monta a política a partir da configuração
+func newCORSPolicy(cfg config.CORSConfig) *corsPolicy {
+	c := &corsPolicy{
+		allowedOrigins:   make(map[string]bool),
+		allowedMethods:   make(map[string]bool),
+		allowedHeaders:   make(map[string]bool),
+		methods:          strings.Join(cfg.AllowedMethods, ", "),
+		headers:          strings.Join(cfg.AllowedHeaders, ", "),
+		exposedHeaders:   strings.Join(cfg.ExposedHeaders, ", "),
+		allowCredentials: cfg.AllowCredentials,
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			c.allowAllOrigins = true
+			continue
+		}
+		c.allowedOrigins[strings.ToLower(origin)] = true
+	}
+	for _, method := range cfg.AllowedMethods {
+		c.allowedMethods[strings.ToUpper(method)] = true
+	}
+	for _, header := range cfg.AllowedHeaders {
+		c.allowedHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+	if cfg.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	return c
+}
+
+// Handler envolve o próximo handler aplicando a política CORS
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := c.policy.Load()
+
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			policy.handlePreflight(w, r, origin)
+			return
+		}
+
+		if policy.isOriginAllowed(origin) {
+			policy.setOriginHeaders(w, origin)
+			if policy.exposedHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", policy.exposedHeaders)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handlePreflight responde requisições preflight sem repassá-las ao handler.
+// Requisições não permitidas recebem 204 sem headers CORS, o que faz o
+// navegador bloquear a chamada
+func (c *corsPolicy) handlePreflight(w http.ResponseWriter, r *http.Request, origin string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	if !c.isOriginAllowed(origin) ||
+		!c.allowedMethods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] ||
+		!c.areHeadersAllowed(r.Header.Get("Access-Control-Request-Headers")) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	c.setOriginHeaders(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", c.methods)
+	if c.headers != "" {
+		w.Header().Set("Access-Control-Allow-Headers", c.headers)
+	}
+	if c.maxAge != "" {
+		w.Header().Set("Access-Control-Max-Age", c.maxAge)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setOriginHeaders define a origem permitida e o uso de credenciais. O
+// curinga nunca é combinado com credenciais, pois os navegadores rejeitam
+// essa combinação
+func (c *corsPolicy) setOriginHeaders(w http.ResponseWriter, origin string) {
+	if c.allowAllOrigins {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if c.allowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// isOriginAllowed verifica se a origem está na lista de origens permitidas
+func (c *corsPolicy) isOriginAllowed(origin string) bool {
+	return c.allowAllOrigins || c.allowedOrigins[strings.ToLower(origin)]
+}
+
+// areHeadersAllowed verifica se todos os headers solicitados no preflight
+// estão na lista de headers permitidos
+func (c *corsPolicy) areHeadersAllowed(requested string) bool {
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		if !c.allowedHeaders[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
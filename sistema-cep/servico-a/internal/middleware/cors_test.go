@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"servico-a/internal/config"
+)
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	restricted := config.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedMethods:   []string{"POST"},
+		AllowedHeaders:   []string{"Content-Type"},
+		ExposedHeaders:   []string{"X-Trace-Id"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	tests := []struct {
+		name            string
+		cfg             config.CORSConfig
+		method          string
+		headers         map[string]string
+		expectedStatus  int
+		expectedHeaders map[string]string
+	}{
+		{
+			name:           "Requisição sem Origin não recebe headers CORS",
+			cfg:            restricted,
+			method:         http.MethodPost,
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:           "Origem permitida recebe headers CORS",
+			cfg:            restricted,
+			method:         http.MethodPost,
+			headers:        map[string]string{"Origin": "https://app.example.com"},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Trace-Id",
+			},
+		},
+		{
+			name:           "Origem não permitida não recebe headers CORS",
+			cfg:            restricted,
+			method:         http.MethodPost,
+			headers:        map[string]string{"Origin": "https://evil.example.com"},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:   "Preflight de origem permitida",
+			cfg:    restricted,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "content-type",
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "POST",
+				"Access-Control-Allow-Headers": "Content-Type",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:   "Preflight com método não permitido",
+			cfg:    restricted,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		{
+			name:   "Preflight com header não permitido",
+			cfg:    restricted,
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "X-Custom",
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name: "Curinga nunca é combinado com credenciais",
+			cfg: config.CORSConfig{
+				AllowedOrigins:   []string{"*"},
+				AllowedMethods:   []string{"POST"},
+				AllowCredentials: true,
+			},
+			method:         http.MethodPost,
+			headers:        map[string]string{"Origin": "https://app.example.com"},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+		{
+			name:   "Sem origens configuradas o CORS fica desabilitado",
+			cfg:    config.CORSConfig{},
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "POST",
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+
+			NewCORS(tt.cfg).Handler(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+			for key, expected := range tt.expectedHeaders {
+				if value := rec.Header().Get(key); value != expected {
+					t.Errorf("header %s = %q, expected %q", key, value, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSUpdate(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	cors := NewCORS(config.CORSConfig{AllowedOrigins: []string{"https://old.example.com"}})
+	handler := cors.Handler(next)
+
+	allowedOrigin := func(origin string) string {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if got := allowedOrigin("https://new.example.com"); got != "" {
+		t.Fatalf("origem nova permitida antes do Update: %q", got)
+	}
+
+	// O handler já montado passa a usar a nova política
+	cors.Update(config.CORSConfig{AllowedOrigins: []string{"https://new.example.com"}})
+
+	if got := allowedOrigin("https://new.example.com"); got != "https://new.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, expected origem nova", got)
+	}
+	if got := allowedOrigin("https://old.example.com"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, expected origem antiga bloqueada", got)
+	}
+}
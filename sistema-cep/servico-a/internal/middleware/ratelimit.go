@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"servico-a/internal/auth"
+	"servico-a/internal/config"
+	"servico-a/internal/logging"
+	"servico-a/internal/problem"
+	"servico-a/internal/ratelimit"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RateLimiter limita a taxa de requisições por cliente. A configuração pode
+// ser trocada com Update sem perder os buckets dos clientes
+type RateLimiter struct {
+	limiter  *ratelimit.Limiter
+	settings atomic.Pointer[rateLimitSettings]
+	rejected metric.Int64Counter
+}
+
+// rateLimitSettings identifica os clientes e habilita o limite
+type rateLimitSettings struct {
+	enabled        bool
+	trustedProxies *TrustedProxies
+}
+
+// NewRateLimiter cria o middleware de rate limiting a partir da configuração
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	rejected, err := otel.Meter("servico-a").Int64Counter(
+		"http.server.rate_limited",
+		metric.WithDescription("Requisições rejeitadas pelo rate limiting"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	rl := &RateLimiter{
+		limiter:  ratelimit.NewLimiter(cfg.RequestsPerSecond, cfg.Burst),
+		rejected: rejected,
+	}
+	rl.settings.Store(newRateLimitSettings(cfg))
+	return rl
+}
+
+// newRateLimitSettings extrai da configuração a identificação dos clientes
+func newRateLimitSettings(cfg config.RateLimitConfig) *rateLimitSettings {
+	return &rateLimitSettings{
+		enabled:        cfg.Enabled,
+		trustedProxies: NewTrustedProxies(cfg.TrustedProxies),
+	}
+}
+
+// Update aplica a nova configuração às próximas requisições
+func (rl *RateLimiter) Update(cfg config.RateLimitConfig) {
+	rl.limiter.SetLimits(cfg.RequestsPerSecond, cfg.Burst)
+	rl.settings.Store(newRateLimitSettings(cfg))
+}
+
+// Handler envolve o próximo handler aplicando o limite por cliente
+func (rl *RateLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		settings := rl.settings.Load()
+		if !settings.enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var result ratelimit.Result
+		keyType, key := settings.clientKey(r)
+		if identity, ok := auth.FromContext(r.Context()); ok && identity.RequestsPerSecond > 0 {
+			result = rl.limiter.AllowLimit(keyType+":"+key, identity.RequestsPerSecond, identity.Burst)
+		} else {
+			result = rl.limiter.Allow(keyType + ":" + key)
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		if result.Allowed {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		retryAfter := ceilSeconds(result.RetryAfter)
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+
+		trace.SpanFromContext(r.Context()).AddEvent("rate_limit.throttled", trace.WithAttributes(
+			attribute.String("rate_limit.key_type", keyType),
+			attribute.Int("rate_limit.limit", result.Limit),
+			attribute.Int("rate_limit.retry_after_seconds", retryAfter),
+		))
+		if rl.rejected != nil {
+			rl.rejected.Add(r.Context(), 1, metric.WithAttributes(
+				attribute.String("rate_limit.key_type", keyType),
+			))
+		}
+
+		logging.Warnf(r.Context(), "Requisição limitada por rate limiting (%s), tente novamente em %ds", keyType, retryAfter)
+		problem.Write(w, r, http.StatusTooManyRequests, problem.CodeRateLimited, "too many requests")
+	})
+}
+
+// clientKey identifica o cliente autenticado pelo ID da API key ou pelo
+// client_id do token e, sem autenticação, pelo IP. Credenciais não
+// autenticadas nunca viram chave, pois o cliente poderia trocá-las a cada
+// requisição para escapar do limite e criar buckets sem fim
+func (s *rateLimitSettings) clientKey(r *http.Request) (string, string) {
+	if identity, ok := auth.FromContext(r.Context()); ok {
+		if identity.Method == auth.MethodJWT {
+			return "client_id", identity.ClientID
+		}
+		return "api_key_id", identity.KeyID
+	}
+	return "ip", s.trustedProxies.ClientIP(r)
+}
+
+// ceilSeconds arredonda a duração para cima em segundos inteiros
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"servico-a/internal/auth"
+	"servico-a/internal/config"
+)
+
+func TestRateLimiter(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rl := NewRateLimiter(config.RateLimitConfig{
+		Enabled:           true,
+		RequestsPerSecond: 1,
+		Burst:             1,
+		TrustedProxies:    []string{"10.0.0.0/8"},
+	})
+	handler := rl.Handler(next)
+
+	send := func(remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remoteAddr
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send("192.0.2.1:1234", nil); rec.Code != http.StatusOK {
+		t.Fatalf("primeira requisição: status = %d, expected 200", rec.Code)
+	}
+
+	rec := send("192.0.2.1:1234", nil)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("segunda requisição: status = %d, expected 429", rec.Code)
+	}
+	if retryAfter := rec.Header().Get("Retry-After"); retryAfter != "1" {
+		t.Errorf("Retry-After = %q, expected 1", retryAfter)
+	}
+	if limit := rec.Header().Get("RateLimit-Limit"); limit != "1" {
+		t.Errorf("RateLimit-Limit = %q, expected 1", limit)
+	}
+	if remaining := rec.Header().Get("RateLimit-Remaining"); remaining != "0" {
+		t.Errorf("RateLimit-Remaining = %q, expected 0", remaining)
+	}
+
+	// API keys não autenticadas não identificam o cliente: trocá-las a cada
+	// requisição não escapa do limite do IP
+	for _, apiKey := range []string{"chave-1", "chave-2", "chave-3"} {
+		if rec := send("192.0.2.1:1234", map[string]string{"X-API-Key": apiKey}); rec.Code != http.StatusTooManyRequests {
+			t.Errorf("API key %s não autenticada: status = %d, expected 429", apiKey, rec.Code)
+		}
+	}
+
+	// X-Forwarded-For de cliente não confiável é ignorado
+	if rec := send("192.0.2.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.7"}); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("X-Forwarded-For não confiável: status = %d, expected 429", rec.Code)
+	}
+
+	// Atrás de um proxy confiável o cliente é identificado pelo X-Forwarded-For
+	if rec := send("10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.7, 10.0.0.2"}); rec.Code != http.StatusOK {
+		t.Errorf("X-Forwarded-For confiável: status = %d, expected 200", rec.Code)
+	}
+	if rec := send("10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.7"}); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("mesmo cliente atrás do proxy: status = %d, expected 429", rec.Code)
+	}
+}
+
+func TestRateLimiterUpdate(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rl := NewRateLimiter(config.RateLimitConfig{Enabled: true, RequestsPerSecond: 0.001, Burst: 1})
+	handler := rl.Handler(next)
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	send("192.0.2.1:1234")
+	if rec := send("192.0.2.1:1234"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, expected 429 antes do Update", rec.Code)
+	}
+
+	// Desabilitado, o limite deixa de ser aplicado pelo handler já montado
+	rl.Update(config.RateLimitConfig{Enabled: false, RequestsPerSecond: 0.001, Burst: 1})
+	if rec := send("192.0.2.1:1234"); rec.Code != http.StatusOK {
+		t.Errorf("status = %d, expected 200 com rate limiting desabilitado", rec.Code)
+	}
+
+	// Reabilitado com uma rajada maior, o novo limite é anunciado e aplicado
+	rl.Update(config.RateLimitConfig{Enabled: true, RequestsPerSecond: 0.001, Burst: 3})
+	for i := 0; i < 3; i++ {
+		rec := send("192.0.2.2:1234")
+		if rec.Code != http.StatusOK {
+			t.Errorf("requisição %d: status = %d, expected 200 dentro da nova rajada", i+1, rec.Code)
+		}
+		if limit := rec.Header().Get("RateLimit-Limit"); limit != "3" {
+			t.Errorf("RateLimit-Limit = %q, expected 3", limit)
+		}
+	}
+	if rec := send("192.0.2.2:1234"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, expected 429 após a nova rajada", rec.Code)
+	}
+}
+
+func TestRateLimiterPerKeyLimits(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := NewRateLimiter(config.RateLimitConfig{Enabled: true, RequestsPerSecond: 0.001, Burst: 1}).Handler(next)
+
+	send := func(identity *auth.Identity) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req = req.WithContext(auth.NewContext(req.Context(), identity))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// A chave com limite próprio usa a sua rajada, independente do IP
+	partner := &auth.Identity{KeyID: "parceiro", RequestsPerSecond: 0.001, Burst: 3}
+	for i := 0; i < 3; i++ {
+		if rec := send(partner); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "3" {
+			t.Fatalf("requisição %d: status = %d, RateLimit-Limit = %q", i+1, rec.Code, rec.Header().Get("RateLimit-Limit"))
+		}
+	}
+	if rec := send(partner); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, expected 429 após a rajada da chave", rec.Code)
+	}
+
+	// A chave sem limite próprio usa o global, com bucket separado
+	if rec := send(&auth.Identity{KeyID: "outra"}); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "1" {
+		t.Errorf("chave com limite global: status = %d, RateLimit-Limit = %q", rec.Code, rec.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies := NewTrustedProxies([]string{"10.0.0.0/8", "192.0.2.10"})
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		expectedIP   string
+	}{
+		{name: "Conexão direta", remoteAddr: "198.51.100.7:4000", expectedIP: "198.51.100.7"},
+		{name: "Conexão direta ignora X-Forwarded-For", remoteAddr: "198.51.100.7:4000", forwardedFor: "203.0.113.9", expectedIP: "198.51.100.7"},
+		{name: "Proxy confiável", remoteAddr: "10.1.2.3:4000", forwardedFor: "203.0.113.9", expectedIP: "203.0.113.9"},
+		{name: "Cadeia de proxies confiáveis", remoteAddr: "10.1.2.3:4000", forwardedFor: "203.0.113.9, 192.0.2.10, 10.0.0.5", expectedIP: "203.0.113.9"},
+		{name: "Endereço forjado à esquerda é ignorado", remoteAddr: "10.1.2.3:4000", forwardedFor: "1.1.1.1, 203.0.113.9", expectedIP: "203.0.113.9"},
+		{name: "X-Forwarded-For inválido", remoteAddr: "10.1.2.3:4000", forwardedFor: "unknown", expectedIP: "10.1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+
+			if ip := proxies.ClientIP(req); ip != tt.expectedIP {
+				t.Errorf("ClientIP() = %q, expected %q", ip, tt.expectedIP)
+			}
+		})
+	}
+}
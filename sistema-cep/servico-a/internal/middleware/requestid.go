@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"servico-a/internal/requestid"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestID reaproveita o X-Request-ID recebido ou gera um novo, disponibiliza
+// o ID no contexto, no span da requisição e no header de resposta
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.IsValid(id) {
+			id = requestid.New()
+		}
+
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("request.id", id))
+		w.Header().Set(requestid.Header, id)
+
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
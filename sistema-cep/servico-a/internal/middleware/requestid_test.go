@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"servico-a/internal/requestid"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name       string
+		incoming   string
+		expectSame bool
+	}{
+		{
+			name:       "Gera ID quando o header está ausente",
+			incoming:   "",
+			expectSame: false,
+		},
+		{
+			name:       "Reaproveita ID válido recebido",
+			incoming:   "abc123-XYZ.9",
+			expectSame: true,
+		},
+		{
+			name:       "Substitui ID com caracteres inválidos",
+			incoming:   "abc 123\n",
+			expectSame: false,
+		},
+		{
+			name:       "Substitui ID longo demais",
+			incoming:   "0123456789012345678901234567890123456789012345678901234567890123456789",
+			expectSame: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contextID string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contextID = requestid.FromContext(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(requestid.Header, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+
+			RequestID(next).ServeHTTP(rec, req)
+
+			headerID := rec.Header().Get(requestid.Header)
+			if !requestid.IsValid(headerID) {
+				t.Fatalf("header %s = %q não é um ID válido", requestid.Header, headerID)
+			}
+			if contextID != headerID {
+				t.Errorf("ID no contexto = %q, expected %q", contextID, headerID)
+			}
+			if tt.expectSame && headerID != tt.incoming {
+				t.Errorf("ID = %q, expected %q", headerID, tt.incoming)
+			}
+			if !tt.expectSame && headerID == tt.incoming {
+				t.Errorf("ID inválido %q foi reaproveitado", tt.incoming)
+			}
+		})
+	}
+}
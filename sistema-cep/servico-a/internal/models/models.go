@@ -5,9 +5,11 @@ type CEPRequest struct {
 	CEP string `json:"cep"`
 }
 
-// ErrorResponse representa a estrutura de resposta de erro
+// ErrorResponse representa a estrutura de resposta de erro. RequestID é
+// opcional, para que o formato legado continue compatível
 type ErrorResponse struct {
-	Message string `json:"message"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // TemperatureResponse representa a resposta com dados de temperatura
@@ -18,8 +20,13 @@ type TemperatureResponse struct {
 	TempK float64 `json:"temp_K"`
 }
 
-// ServiceBResponse representa a resposta do Serviço B
-type ServiceBResponse struct {
-	StatusCode int
-	Body       []byte
+// ProblemDetails representa uma resposta de erro no formato RFC 7807
+type ProblemDetails struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Code      string `json:"code"`
+	TraceID   string `json:"trace_id,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
 }
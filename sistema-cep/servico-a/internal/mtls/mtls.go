@@ -0,0 +1,301 @@
+package mtls
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"servico-a/internal/logging"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/credentials"
+)
+
+// Settings define os arquivos PEM do certificado do serviço, da chave
+// privada e das CAs aceitas para o certificado do outro lado. ServerName é o
+// nome esperado no certificado do servidor; vazio usa o host do endereço
+type Settings struct {
+	CertFile   string
+	KeyFile    string
+	CAFile     string
+	ServerName string
+}
+
+// Reloader mantém o certificado e as CAs lidos dos arquivos, relendo-os
+// quando mudam. As conexões novas usam sempre os arquivos mais recentes; as
+// já estabelecidas não são afetadas
+type Reloader struct {
+	settings Settings
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	pool *x509.CertPool
+	sum  [sha256.Size]byte
+}
+
+// NewReloader carrega os arquivos e retorna o erro de leitura, se houver
+func NewReloader(settings Settings) (*Reloader, error) {
+	r := &Reloader{settings: settings}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload relê os arquivos. Em caso de erro o certificado e as CAs atuais
+// são mantidos
+func (r *Reloader) Reload() error {
+	// O hash é lido antes dos arquivos para que uma escrita concorrente gere
+	// uma nova recarga em vez de ser ignorada
+	sum := r.fileSum()
+
+	r.mu.Lock()
+	r.sum = sum
+	r.mu.Unlock()
+
+	cert, err := tls.LoadX509KeyPair(r.settings.CertFile, r.settings.KeyFile)
+	if err != nil {
+		return fmt.Errorf("erro ao carregar o certificado %s: %w", r.settings.CertFile, err)
+	}
+	caPEM, err := os.ReadFile(r.settings.CAFile)
+	if err != nil {
+		return fmt.Errorf("erro ao ler as CAs: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return fmt.Errorf("nenhum certificado PEM encontrado em %s", r.settings.CAFile)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = &cert
+	r.pool = pool
+	return nil
+}
+
+// Run relê os arquivos quando o conteúdo muda, verificando-os no intervalo
+// informado até o contexto ser cancelado. Intervalo zero desabilita a
+// verificação
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if r.fileSum() == r.lastSum() {
+			continue
+		}
+		if err := r.Reload(); err != nil {
+			logging.Errorf(ctx, "Recarga dos certificados TLS rejeitada, os atuais foram mantidos: %v", err)
+			continue
+		}
+		leaf := r.certificate().Leaf
+		logging.Printf(ctx, "Certificados TLS recarregados: %s, válido até %s", Identity(leaf), leaf.NotAfter.Format(time.RFC3339))
+	}
+}
+
+// ServerConfig retorna a configuração TLS do servidor, que exige o
+// certificado do cliente assinado por uma das CAs
+func (r *Reloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.certificate(), nil
+		},
+		// A cadeia é verificada em VerifyConnection para usar as CAs atuais
+		ClientAuth: tls.RequireAnyClientCert,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			return r.verifyClient(state)
+		},
+	}
+}
+
+// ClientConfig retorna a configuração TLS de uma conexão com serverName,
+// com o certificado e as CAs atuais. Deve ser obtida a cada conexão para
+// acompanhar as recargas
+func (r *Reloader) ClientConfig(serverName string) *tls.Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		ServerName:   serverName,
+		Certificates: []tls.Certificate{*r.cert},
+		RootCAs:      r.pool,
+	}
+}
+
+// Transport retorna um transporte HTTP que abre cada conexão TLS com o
+// certificado e as CAs atuais
+func (r *Reloader) Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialer := &tls.Dialer{Config: r.ClientConfig(r.serverName(addr))}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return transport
+}
+
+// TransportCredentials retorna as credenciais gRPC que abrem cada conexão
+// TLS com o certificado e as CAs atuais
+func (r *Reloader) TransportCredentials() credentials.TransportCredentials {
+	return &transportCredentials{reloader: r}
+}
+
+// certificate retorna o certificado atual
+func (r *Reloader) certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert
+}
+
+// serverName retorna o nome esperado no certificado do servidor em addr
+func (r *Reloader) serverName(addr string) string {
+	if r.settings.ServerName != "" {
+		return r.settings.ServerName
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// lastSum retorna o hash dos arquivos lidos na última recarga
+func (r *Reloader) lastSum() [sha256.Size]byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sum
+}
+
+// verifyClient verifica o certificado do cliente contra as CAs atuais
+func (r *Reloader) verifyClient(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("certificado do cliente ausente")
+	}
+
+	r.mu.RLock()
+	pool := r.pool
+	r.mu.RUnlock()
+
+	opts := x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if _, err := state.PeerCertificates[0].Verify(opts); err != nil {
+		return fmt.Errorf("certificado do cliente %s rejeitado: %w", Identity(state.PeerCertificates[0]), err)
+	}
+	return nil
+}
+
+// fileSum calcula o hash do conteúdo dos arquivos. Comparar o conteúdo, e
+// não a data de modificação, também detecta a troca do link simbólico usada
+// pelos Secrets do Kubernetes
+func (r *Reloader) fileSum() [sha256.Size]byte {
+	h := sha256.New()
+	for _, path := range []string{r.settings.CertFile, r.settings.KeyFile, r.settings.CAFile} {
+		h.Write([]byte(path))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			h.Write([]byte{0})
+			continue
+		}
+		h.Write([]byte{1})
+		h.Write(data)
+	}
+
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// Identity retorna a identidade do certificado: a primeira URI, como um ID
+// SPIFFE, ou o Common Name, ou o primeiro nome DNS
+func Identity(cert *x509.Certificate) string {
+	switch {
+	case cert == nil:
+		return ""
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	case cert.Subject.CommonName != "":
+		return cert.Subject.CommonName
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	default:
+		return ""
+	}
+}
+
+// PeerAttributes retorna os atributos de span com a identidade do outro lado
+// da conexão. side é "client" no servidor e "server" no cliente, seguindo os
+// atributos tls.client.* e tls.server.* das convenções do OpenTelemetry
+func PeerAttributes(side string, state *tls.ConnectionState) []attribute.KeyValue {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	cert := state.PeerCertificates[0]
+	return []attribute.KeyValue{
+		attribute.String("tls."+side+".identity", Identity(cert)),
+		attribute.String("tls."+side+".subject", cert.Subject.String()),
+		attribute.String("tls."+side+".issuer", cert.Issuer.String()),
+		attribute.String("tls."+side+".not_after", cert.NotAfter.UTC().Format(time.RFC3339)),
+		attribute.String("tls.protocol.version", tls.VersionName(state.Version)),
+	}
+}
+
+// transportCredentials delega o handshake às credenciais TLS do gRPC,
+// criadas a cada conexão com o certificado e as CAs atuais
+type transportCredentials struct {
+	reloader   *Reloader
+	serverName string
+}
+
+func (c *transportCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	serverName := c.serverName
+	if serverName == "" {
+		serverName = c.reloader.serverName(authority)
+	}
+	return credentials.NewTLS(c.reloader.ClientConfig(serverName)).ClientHandshake(ctx, authority, conn)
+}
+
+func (c *transportCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return credentials.NewTLS(c.reloader.ServerConfig()).ServerHandshake(conn)
+}
+
+func (c *transportCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "tls", SecurityVersion: "1.2", ServerName: c.serverName}
+}
+
+func (c *transportCredentials) Clone() credentials.TransportCredentials {
+	clone := *c
+	return &clone
+}
+
+func (c *transportCredentials) OverrideServerName(serverName string) error {
+	c.serverName = serverName
+	return nil
+}
@@ -0,0 +1,296 @@
+package mtls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+// testCA é uma CA descartável criada para o teste
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() err = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() err = %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue emite um certificado de folha e grava o certificado, a chave e a CA
+// em dir, retornando as configurações com os arquivos
+func (ca *testCA) issue(t *testing.T, dir, name string, usage x509.ExtKeyUsage) Settings {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() err = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		URIs:         []*url.URL{{Scheme: "spiffe", Host: "sistema-cep", Path: "/" + name}},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() err = %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() err = %v", err)
+	}
+
+	settings := Settings{
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+		CAFile:   filepath.Join(dir, "ca.crt"),
+	}
+	writeFile(t, settings.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	writeFile(t, settings.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	writeFile(t, settings.CAFile, ca.pem)
+	return settings
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile() err = %v", err)
+	}
+}
+
+// newTestServer inicia um servidor HTTPS que responde com a identidade do
+// certificado do cliente
+func newTestServer(t *testing.T, reloader *Reloader) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(Identity(r.TLS.PeerCertificates[0])))
+	}))
+	// StartTLS colocaria o certificado do httptest na frente do GetCertificate
+	server.Listener = tls.NewListener(server.Listener, reloader.ServerConfig())
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.Start()
+	server.URL = strings.Replace(server.URL, "http://", "https://", 1)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newReloader(t *testing.T, settings Settings) *Reloader {
+	t.Helper()
+	reloader, err := NewReloader(settings)
+	if err != nil {
+		t.Fatalf("NewReloader() err = %v", err)
+	}
+	return reloader
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t, "sistema-cep CA")
+	other := newTestCA(t, "outra CA")
+
+	server := newTestServer(t, newReloader(t, ca.issue(t, t.TempDir(), "servico-b", x509.ExtKeyUsageServerAuth)))
+
+	tests := []struct {
+		name       string
+		settings   Settings
+		expectedOK bool
+	}{
+		{
+			name:       "cliente com certificado da CA",
+			settings:   ca.issue(t, t.TempDir(), "servico-a", x509.ExtKeyUsageClientAuth),
+			expectedOK: true,
+		},
+		{
+			name:     "cliente com certificado de outra CA",
+			settings: other.issue(t, t.TempDir(), "intruso", x509.ExtKeyUsageClientAuth),
+		},
+		{
+			name:     "certificado sem uso de cliente",
+			settings: ca.issue(t, t.TempDir(), "servico-a", x509.ExtKeyUsageServerAuth),
+		},
+		{
+			name: "nome do servidor diferente do certificado",
+			settings: func() Settings {
+				s := ca.issue(t, t.TempDir(), "servico-a", x509.ExtKeyUsageClientAuth)
+				s.ServerName = "servico-b.example.com"
+				return s
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: newReloader(t, tt.settings).Transport()}
+			resp, err := client.Get(server.URL)
+			if !tt.expectedOK {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("conexão aceita, expected rejeição")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get() err = %v", err)
+			}
+			defer resp.Body.Close()
+
+			body := make([]byte, 64)
+			n, _ := resp.Body.Read(body)
+			if got := string(body[:n]); got != "spiffe://sistema-cep/servico-a" {
+				t.Errorf("identidade vista pelo servidor = %q", got)
+			}
+
+			attrs := make(map[string]string)
+			for _, kv := range PeerAttributes("server", resp.TLS) {
+				attrs[string(kv.Key)] = kv.Value.AsString()
+			}
+			if attrs["tls.server.identity"] != "spiffe://sistema-cep/servico-b" {
+				t.Errorf("tls.server.identity = %q", attrs["tls.server.identity"])
+			}
+			if !strings.Contains(attrs["tls.server.issuer"], "sistema-cep CA") {
+				t.Errorf("tls.server.issuer = %q", attrs["tls.server.issuer"])
+			}
+		})
+	}
+}
+
+func TestTransportCredentials(t *testing.T) {
+	ca := newTestCA(t, "sistema-cep CA")
+	serverReloader := newReloader(t, ca.issue(t, t.TempDir(), "servico-b", x509.ExtKeyUsageServerAuth))
+	clientReloader := newReloader(t, ca.issue(t, t.TempDir(), "servico-a", x509.ExtKeyUsageClientAuth))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() err = %v", err)
+	}
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(serverReloader.ServerConfig())))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(listener)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(clientReloader.TransportCredentials()))
+	if err != nil {
+		t.Fatalf("Dial() err = %v", err)
+	}
+	defer conn.Close()
+
+	var p peer.Peer
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Peer(&p)); err != nil {
+		t.Fatalf("Check() err = %v", err)
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		t.Fatalf("AuthInfo = %T, expected credentials.TLSInfo", p.AuthInfo)
+	}
+	if got := Identity(info.State.PeerCertificates[0]); got != "spiffe://sistema-cep/servico-b" {
+		t.Errorf("identidade do servidor = %q", got)
+	}
+}
+
+func TestReloaderRotation(t *testing.T) {
+	oldCA := newTestCA(t, "CA antiga")
+	newCA := newTestCA(t, "CA nova")
+
+	serverDir, clientDir := t.TempDir(), t.TempDir()
+	serverReloader := newReloader(t, oldCA.issue(t, serverDir, "servico-b", x509.ExtKeyUsageServerAuth))
+	clientReloader := newReloader(t, oldCA.issue(t, clientDir, "servico-a", x509.ExtKeyUsageClientAuth))
+	server := newTestServer(t, serverReloader)
+
+	get := func() error {
+		transport := clientReloader.Transport()
+		defer transport.CloseIdleConnections()
+
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+	if err := get(); err != nil {
+		t.Fatalf("Get() antes da rotação err = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go serverReloader.Run(ctx, 10*time.Millisecond)
+
+	// Só o servidor passa para a CA nova: o certificado antigo do cliente
+	// deixa de ser aceito sem reiniciar o servidor
+	newCA.issue(t, serverDir, "servico-b", x509.ExtKeyUsageServerAuth)
+	deadline := time.Now().Add(2 * time.Second)
+	for get() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("servidor continuou aceitando o certificado da CA antiga")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Com o cliente também na CA nova a conexão volta a funcionar
+	newCA.issue(t, clientDir, "servico-a", x509.ExtKeyUsageClientAuth)
+	if err := clientReloader.Reload(); err != nil {
+		t.Fatalf("Reload() err = %v", err)
+	}
+	if err := get(); err != nil {
+		t.Errorf("Get() após a rotação err = %v", err)
+	}
+
+	// Arquivos inválidos são rejeitados e os certificados atuais mantidos
+	writeFile(t, filepath.Join(clientDir, "tls.key"), []byte("inválido"))
+	if err := clientReloader.Reload(); err == nil {
+		t.Error("Reload() com chave inválida não retornou erro")
+	}
+	if err := get(); err != nil {
+		t.Errorf("Get() com os certificados mantidos err = %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package problem
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"servico-a/internal/models"
+	"servico-a/internal/requestid"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContentType é o media type das respostas no formato RFC 7807
+const ContentType = "application/problem+json"
+
+// Code identifica de forma estável o tipo de erro retornado pela API
+type Code string
+
+const (
+	CodeInvalidRequestBody      Code = "INVALID_REQUEST_BODY"
+	CodeUnsupportedMediaType    Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodePayloadTooLarge         Code = "PAYLOAD_TOO_LARGE"
+	CodeMethodNotAllowed        Code = "METHOD_NOT_ALLOWED"
+	CodeUnauthorized            Code = "UNAUTHORIZED"
+	CodeForbidden               Code = "FORBIDDEN"
+	CodeRateLimited             Code = "RATE_LIMITED"
+	CodeInvalidZipcode          Code = "INVALID_ZIPCODE"
+	CodeZipcodeNotFound         Code = "ZIPCODE_NOT_FOUND"
+	CodeUpstreamUnavailable     Code = "UPSTREAM_UNAVAILABLE"
+	CodeUpstreamInvalidResponse Code = "UPSTREAM_INVALID_RESPONSE"
+	CodeDeadlineExceeded        Code = "DEADLINE_EXCEEDED"
+	CodeOverloaded              Code = "SERVER_OVERLOADED"
+	CodeInternalError           Code = "INTERNAL_ERROR"
+)
+
+// TypeURI retorna a referência de tipo do problema associada ao código
+func (c Code) TypeURI() string {
+	return "/problems/" + strings.ReplaceAll(strings.ToLower(string(c)), "_", "-")
+}
+
+// New monta os detalhes do problema, incluindo o trace ID e o ID da requisição
+func New(r *http.Request, status int, code Code, detail string) models.ProblemDetails {
+	p := models.ProblemDetails{
+		Type:      code.TypeURI(),
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Code:      string(code),
+		RequestID: requestid.FromContext(r.Context()),
+	}
+
+	if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+		p.TraceID = sc.TraceID().String()
+	}
+
+	return p
+}
+
+// Write escreve a resposta de erro no formato e no idioma negociados com o
+// cliente. Clientes que aceitam application/problem+json recebem os detalhes
+// completos; os demais continuam recebendo {"message": "..."}, acrescido do
+// request_id quando a requisição tem um ID
+func Write(w http.ResponseWriter, r *http.Request, status int, code Code, detail string) {
+	language := NegotiateLanguage(r)
+	detail = Localize(language, code, detail)
+
+	w.Header().Add("Vary", "Accept")
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Content-Language", string(language))
+
+	if !AcceptsProblemJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Message:   detail,
+			RequestID: requestid.FromContext(r.Context()),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(New(r, status, code, detail))
+}
+
+// AcceptsProblemJSON indica se o header Accept da requisição inclui
+// application/problem+json com qualidade maior que zero
+func AcceptsProblemJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+			if err != nil || mediaType != ContentType {
+				continue
+			}
+			if q, ok := params["q"]; ok {
+				if quality, err := strconv.ParseFloat(q, 64); err != nil || quality == 0 {
+					continue
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"servico-a/internal/models"
+	"servico-a/internal/requestid"
+)
+
+func TestAcceptsProblemJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		expected bool
+	}{
+		{
+			name:     "Sem header Accept",
+			accept:   "",
+			expected: false,
+		},
+		{
+			name:     "Accept application/json",
+			accept:   "application/json",
+			expected: false,
+		},
+		{
+			name:     "Accept curinga",
+			accept:   "*/*",
+			expected: false,
+		},
+		{
+			name:     "Accept application/problem+json",
+			accept:   "application/problem+json",
+			expected: true,
+		},
+		{
+			name:     "Accept com múltiplos tipos",
+			accept:   "application/json, application/problem+json;q=0.9",
+			expected: true,
+		},
+		{
+			name:     "Accept com qualidade zero",
+			accept:   "application/problem+json;q=0",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			if result := AcceptsProblemJSON(req); result != tt.expected {
+				t.Errorf("AcceptsProblemJSON(%q) = %v, expected %v", tt.accept, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Run("Formato legado", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		rec := httptest.NewRecorder()
+
+		Write(rec, req, http.StatusUnprocessableEntity, CodeInvalidZipcode, "invalid zipcode")
+
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, expected application/json", ct)
+		}
+		if body := rec.Body.String(); body != "{\"message\":\"invalid zipcode\"}\n" {
+			t.Errorf("body = %q", body)
+		}
+	})
+
+	t.Run("Formato legado com ID da requisição", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req = req.WithContext(requestid.NewContext(req.Context(), "req-123"))
+		rec := httptest.NewRecorder()
+
+		Write(rec, req, http.StatusNotFound, CodeZipcodeNotFound, "can not find zipcode")
+
+		expected := "{\"message\":\"can not find zipcode\",\"request_id\":\"req-123\"}\n"
+		if body := rec.Body.String(); body != expected {
+			t.Errorf("body = %q, expected %q", body, expected)
+		}
+	})
+
+	t.Run("Formato problem+json", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Accept", ContentType)
+		rec := httptest.NewRecorder()
+
+		Write(rec, req, http.StatusNotFound, CodeZipcodeNotFound, "can not find zipcode")
+
+		if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+			t.Errorf("Content-Type = %q, expected %s", ct, ContentType)
+		}
+
+		var p models.ProblemDetails
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Fatalf("resposta não é JSON válido: %v", err)
+		}
+
+		expected := models.ProblemDetails{
+			Type:   "/problems/zipcode-not-found",
+			Title:  "Not Found",
+			Status: http.StatusNotFound,
+			Detail: "can not find zipcode",
+			Code:   "ZIPCODE_NOT_FOUND",
+		}
+		if p != expected {
+			t.Errorf("problem = %+v, expected %+v", p, expected)
+		}
+	})
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		expected       Language
+	}{
+		{
+			name:           "Sem header Accept-Language",
+			acceptLanguage: "",
+			expected:       LanguageEnglish,
+		},
+		{
+			name:           "Português do Brasil",
+			acceptLanguage: "pt-BR",
+			expected:       LanguagePortuguese,
+		},
+		{
+			name:           "Português sem região",
+			acceptLanguage: "pt",
+			expected:       LanguagePortuguese,
+		},
+		{
+			name:           "Inglês preferido sobre português",
+			acceptLanguage: "pt-BR;q=0.8, en-US",
+			expected:       LanguageEnglish,
+		},
+		{
+			name:           "Português preferido sobre inglês",
+			acceptLanguage: "en;q=0.5, pt-BR;q=0.9",
+			expected:       LanguagePortuguese,
+		},
+		{
+			name:           "Idioma não suportado",
+			acceptLanguage: "fr-FR, de;q=0.8",
+			expected:       LanguageEnglish,
+		},
+		{
+			name:           "Idioma com qualidade zero",
+			acceptLanguage: "pt-BR;q=0",
+			expected:       LanguageEnglish,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+
+			if result := NegotiateLanguage(req); result != tt.expected {
+				t.Errorf("NegotiateLanguage(%q) = %q, expected %q", tt.acceptLanguage, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteLocalized(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Accept-Language", "pt-BR")
+	rec := httptest.NewRecorder()
+
+	Write(rec, req, http.StatusUnprocessableEntity, CodeInvalidZipcode, "invalid zipcode")
+
+	if lang := rec.Header().Get("Content-Language"); lang != "pt-BR" {
+		t.Errorf("Content-Language = %q, expected pt-BR", lang)
+	}
+	if body := rec.Body.String(); body != "{\"message\":\"CEP inválido\"}\n" {
+		t.Errorf("body = %q", body)
+	}
+}
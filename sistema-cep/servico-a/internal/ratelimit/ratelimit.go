@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// cleanupInterval define a frequência de remoção de buckets ociosos
+const cleanupInterval = time.Minute
+
+// Result descreve o resultado da avaliação de uma requisição pelo limitador
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+// Limiter mantém um token bucket por chave de cliente
+type Limiter struct {
+	mu          sync.Mutex
+	rate        float64
+	burst       int
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// rate e burst são os limites usados na última requisição da chave
+	rate  float64
+	burst int
+}
+
+// NewLimiter cria um limitador que repõe rate tokens por segundo até o
+// máximo de burst tokens por chave
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// SetLimits troca a taxa e a rajada. Os buckets existentes são mantidos e
+// passam a ser repostos e limitados pelos novos valores
+func (l *Limiter) SetLimits(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate = rate
+	l.burst = burst
+}
+
+// Allow consome um token do bucket da chave, se houver
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.allow(key, l.rate, l.burst)
+}
+
+// AllowLimit é o Allow com taxa e rajada próprias da chave, em vez dos
+// limites do limitador
+func (l *Limiter) AllowLimit(key string, rate float64, burst int) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.allow(key, rate, burst)
+}
+
+func (l *Limiter) allow(key string, rate float64, burst int) Result {
+	now := l.now()
+	l.cleanup(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), last: now}
+		l.buckets[key] = b
+	}
+	b.rate, b.burst = rate, burst
+
+	// Repõe os tokens acumulados desde a última requisição
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(burst), b.tokens+elapsed*rate)
+	b.last = now
+
+	result := Result{Limit: burst}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = durationFor(1-b.tokens, rate)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.ResetAfter = durationFor(float64(burst)-b.tokens, rate)
+
+	return result
+}
+
+// durationFor calcula o tempo necessário para repor a quantidade de tokens
+func durationFor(tokens, rate float64) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / rate * float64(time.Second))
+}
+
+// cleanup remove buckets que já estariam cheios, pois equivalem a um
+// bucket novo e só ocupam memória
+func (l *Limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < cleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= durationFor(float64(b.burst)-b.tokens, b.rate) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(1, 2)
+	limiter.now = func() time.Time { return now }
+
+	steps := []struct {
+		name              string
+		advance           time.Duration
+		key               string
+		expectedAllowed   bool
+		expectedRemaining int
+		expectedRetry     time.Duration
+	}{
+		{name: "Primeira requisição consome o burst", key: "a", expectedAllowed: true, expectedRemaining: 1},
+		{name: "Segunda requisição esgota o burst", key: "a", expectedAllowed: true, expectedRemaining: 0},
+		{name: "Terceira requisição é rejeitada", key: "a", expectedAllowed: false, expectedRemaining: 0, expectedRetry: time.Second},
+		{name: "Outra chave tem bucket próprio", key: "b", expectedAllowed: true, expectedRemaining: 1},
+		{name: "Metade do tempo de reposição ainda rejeita", advance: 500 * time.Millisecond, key: "a", expectedAllowed: false, expectedRetry: 500 * time.Millisecond},
+		{name: "Token reposto após um segundo", advance: 500 * time.Millisecond, key: "a", expectedAllowed: true, expectedRemaining: 0},
+		{name: "Bucket não ultrapassa o burst", advance: time.Hour, key: "a", expectedAllowed: true, expectedRemaining: 1},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		result := limiter.Allow(step.key)
+
+		if result.Allowed != step.expectedAllowed {
+			t.Fatalf("%s: Allowed = %v, expected %v", step.name, result.Allowed, step.expectedAllowed)
+		}
+		if result.Remaining != step.expectedRemaining {
+			t.Errorf("%s: Remaining = %d, expected %d", step.name, result.Remaining, step.expectedRemaining)
+		}
+		if result.RetryAfter != step.expectedRetry {
+			t.Errorf("%s: RetryAfter = %v, expected %v", step.name, result.RetryAfter, step.expectedRetry)
+		}
+		if result.Limit != 2 {
+			t.Errorf("%s: Limit = %d, expected 2", step.name, result.Limit)
+		}
+	}
+}
+
+func TestLimiterAllowLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(1, 1)
+	limiter.now = func() time.Time { return now }
+
+	// A chave com limite próprio não é afetada pelo limite global
+	for i := 0; i < 3; i++ {
+		if result := limiter.AllowLimit("parceiro", 10, 3); !result.Allowed || result.Limit != 3 {
+			t.Fatalf("requisição %d: Allowed = %v, Limit = %d, expected permitida com limite 3", i+1, result.Allowed, result.Limit)
+		}
+	}
+	result := limiter.AllowLimit("parceiro", 10, 3)
+	if result.Allowed || result.RetryAfter != 100*time.Millisecond {
+		t.Errorf("quarta requisição: Allowed = %v, RetryAfter = %v, expected rejeitada por 100ms", result.Allowed, result.RetryAfter)
+	}
+
+	if result := limiter.Allow("ip"); !result.Allowed || result.Limit != 1 {
+		t.Errorf("chave com limite global: Allowed = %v, Limit = %d", result.Allowed, result.Limit)
+	}
+}
@@ -0,0 +1,81 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor repete chamadas gRPC com falhas transitórias
+// (UNAVAILABLE e RESOURCE_EXHAUSTED, equivalentes aos 503 e 429 do HTTP)
+// com a mesma política e orçamento do Transport. Cada tentativa gera um span
+// filho
+func UnaryClientInterceptor(name string, policy Policy) grpc.UnaryClientInterceptor {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	budget := NewBudget(policy.BudgetRatio, policy.BudgetMaxTokens)
+	tracer := otel.Tracer("servico-a")
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		budget.deposit()
+
+		for attempt := 1; ; attempt++ {
+			attemptCtx, span := tracer.Start(ctx, name+".attempt", trace.WithAttributes(
+				attribute.Int("retry.attempt", attempt),
+			))
+			err := invoker(attemptCtx, method, req, reply, cc, opts...)
+			if err != nil {
+				span.SetAttributes(attribute.String("rpc.grpc.status_code", status.Code(err).String()))
+				span.SetStatus(otelcodes.Error, status.Code(err).String())
+			}
+			span.End()
+
+			if attempt >= policy.MaxAttempts || !isRetryableCode(ctx, err) {
+				return err
+			}
+
+			delay := backoff(policy, attempt)
+			span = trace.SpanFromContext(ctx)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+				span.AddEvent("retry.deadline_exceeded", trace.WithAttributes(
+					attribute.String("retry.client", name),
+					attribute.Int("retry.attempt", attempt),
+				))
+				return err
+			}
+			if !budget.withdraw() {
+				span.AddEvent("retry.budget_exhausted", trace.WithAttributes(
+					attribute.String("retry.client", name),
+					attribute.Int("retry.attempt", attempt),
+				))
+				return err
+			}
+
+			if err := sleep(ctx, delay); err != nil {
+				return status.FromContextError(err).Err()
+			}
+		}
+	}
+}
+
+// isRetryableCode indica se o erro gRPC é transitório. Erros causados pelo
+// cancelamento ou deadline da própria chamada não são repetidos
+func isRetryableCode(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,85 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryClientInterceptorRetries(t *testing.T) {
+	policy := Policy{
+		MaxAttempts:     3,
+		InitialBackoff:  time.Millisecond,
+		MaxBackoff:      5 * time.Millisecond,
+		BudgetRatio:     0.2,
+		BudgetMaxTokens: 10,
+	}
+
+	tests := []struct {
+		name             string
+		responses        []codes.Code
+		expectedCode     codes.Code
+		expectedAttempts int
+	}{
+		{
+			name:             "Sucesso na primeira tentativa",
+			responses:        []codes.Code{codes.OK},
+			expectedCode:     codes.OK,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "UNAVAILABLE seguido de sucesso",
+			responses:        []codes.Code{codes.Unavailable, codes.OK},
+			expectedCode:     codes.OK,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "RESOURCE_EXHAUSTED seguido de sucesso",
+			responses:        []codes.Code{codes.ResourceExhausted, codes.OK},
+			expectedCode:     codes.OK,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "Tentativas esgotadas",
+			responses:        []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable},
+			expectedCode:     codes.Unavailable,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "NOT_FOUND não é repetido",
+			responses:        []codes.Code{codes.NotFound},
+			expectedCode:     codes.NotFound,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "INTERNAL não é repetido",
+			responses:        []codes.Code{codes.Internal},
+			expectedCode:     codes.Internal,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				code := tt.responses[attempts]
+				attempts++
+				return status.Error(code, code.String())
+			}
+
+			err := UnaryClientInterceptor("servico-b", policy)(context.Background(), "/test", nil, nil, nil, invoker)
+
+			if code := status.Code(err); code != tt.expectedCode {
+				t.Errorf("code = %s, expected %s", code, tt.expectedCode)
+			}
+			if attempts != tt.expectedAttempts {
+				t.Errorf("tentativas = %d, expected %d", attempts, tt.expectedAttempts)
+			}
+		})
+	}
+}
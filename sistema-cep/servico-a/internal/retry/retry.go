@@ -0,0 +1,255 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Policy define quantas vezes e com que intervalo uma chamada é repetida
+type Policy struct {
+	MaxAttempts     int
+	InitialBackoff  time.Duration
+	MaxBackoff      time.Duration
+	BudgetRatio     float64
+	BudgetMaxTokens float64
+}
+
+// Budget limita as retentativas a uma fração das requisições originais,
+// evitando que falhas generalizadas multipliquem a carga no serviço
+// chamado. Cada requisição original deposita ratio tokens e cada
+// retentativa consome um token
+type Budget struct {
+	mu        sync.Mutex
+	tokens    float64
+	maxTokens float64
+	ratio     float64
+}
+
+// NewBudget cria um orçamento de retentativas inicialmente cheio
+func NewBudget(ratio, maxTokens float64) *Budget {
+	return &Budget{
+		tokens:    maxTokens,
+		maxTokens: maxTokens,
+		ratio:     ratio,
+	}
+}
+
+// deposit credita a fração de retentativa de uma requisição original
+func (b *Budget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.maxTokens, b.tokens+b.ratio)
+}
+
+// withdraw consome um token, se houver saldo para uma retentativa
+func (b *Budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Transport é um http.RoundTripper que repete chamadas com falhas
+// transitórias: erros de conexão, 502, 503, 504 e 429, respeitando o
+// Retry-After e o deadline da requisição. Cada tentativa gera um span filho
+type Transport struct {
+	base   http.RoundTripper
+	name   string
+	policy Policy
+	budget *Budget
+	tracer trace.Tracer
+}
+
+// NewTransport cria o transport de retentativas sobre o transport base
+func NewTransport(base http.RoundTripper, name string, policy Policy) *Transport {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+
+	return &Transport{
+		base:   base,
+		name:   name,
+		policy: policy,
+		budget: NewBudget(policy.BudgetRatio, policy.BudgetMaxTokens),
+		tracer: otel.Tracer("servico-a"),
+	}
+}
+
+// RoundTrip executa a requisição, repetindo-a enquanto a falha for
+// transitória e houver tentativas, orçamento e tempo disponíveis
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	t.budget.deposit()
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.attempt(ctx, req, attempt)
+
+		if attempt >= t.policy.MaxAttempts || !isRetryable(ctx, resp, err) {
+			return resp, err
+		}
+
+		// Sem body reaproveitável não é possível repetir a requisição
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, err
+		}
+
+		// O Retry-After do servidor substitui o backoff, limitado a
+		// MaxBackoff; se o servidor pedir mais tempo do que resta até o
+		// deadline, a requisição falha sem esperar
+		delay := t.backoff(attempt)
+		wait := delay
+		if retryAfter, ok := parseRetryAfter(resp); ok {
+			wait = retryAfter
+			delay = retryAfter
+			if t.policy.MaxBackoff > 0 {
+				delay = min(retryAfter, t.policy.MaxBackoff)
+			}
+		}
+
+		span := trace.SpanFromContext(ctx)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			span.AddEvent("retry.deadline_exceeded", trace.WithAttributes(
+				attribute.String("retry.client", t.name),
+				attribute.Int("retry.attempt", attempt),
+			))
+			return resp, err
+		}
+		if !t.budget.withdraw() {
+			span.AddEvent("retry.budget_exhausted", trace.WithAttributes(
+				attribute.String("retry.client", t.name),
+				attribute.Int("retry.attempt", attempt),
+			))
+			return resp, err
+		}
+
+		// Descarta a resposta da tentativa que será repetida
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt executa uma tentativa dentro de um span próprio
+func (t *Transport) attempt(ctx context.Context, req *http.Request, attempt int) (*http.Response, error) {
+	ctx, span := t.tracer.Start(ctx, t.name+".attempt", trace.WithAttributes(
+		attribute.Int("retry.attempt", attempt),
+	))
+	defer span.End()
+
+	attemptReq := req.Clone(ctx)
+	if attempt > 1 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to rewind request body")
+			return nil, err
+		}
+		attemptReq.Body = body
+	}
+
+	resp, err := t.base.RoundTrip(attemptReq)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "request failed")
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if isRetryableStatus(resp.StatusCode) {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// backoff calcula o intervalo exponencial com jitter completo
+func (t *Transport) backoff(attempt int) time.Duration {
+	return backoff(t.policy, attempt)
+}
+
+// backoff calcula o intervalo da política para a tentativa informada
+func backoff(policy Policy, attempt int) time.Duration {
+	backoff := float64(policy.InitialBackoff) * math.Pow(2, float64(attempt-1))
+	if policy.MaxBackoff > 0 {
+		backoff = math.Min(backoff, float64(policy.MaxBackoff))
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// isRetryable indica se a falha é transitória. Erros causados pelo
+// cancelamento ou deadline da própria requisição não são repetidos
+func isRetryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return isRetryableStatus(resp.StatusCode)
+}
+
+// isRetryableStatus indica se o status HTTP representa uma falha transitória
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter interpreta o header Retry-After em segundos ou como data HTTP
+func parseRetryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// sleep aguarda o intervalo ou o cancelamento do contexto
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,215 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransportRetries(t *testing.T) {
+	policy := Policy{
+		MaxAttempts:     3,
+		InitialBackoff:  time.Millisecond,
+		MaxBackoff:      5 * time.Millisecond,
+		BudgetRatio:     0.2,
+		BudgetMaxTokens: 10,
+	}
+
+	tests := []struct {
+		name             string
+		responses        []int
+		retryAfter       string
+		expectedStatus   int
+		expectedAttempts int32
+	}{
+		{
+			name:             "Sucesso na primeira tentativa",
+			responses:        []int{http.StatusOK},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "503 seguido de sucesso",
+			responses:        []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "502 e 504 seguidos de sucesso",
+			responses:        []int{http.StatusBadGateway, http.StatusGatewayTimeout, http.StatusOK},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "Limite de tentativas",
+			responses:        []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "500 não é repetido",
+			responses:        []int{http.StatusInternalServerError, http.StatusOK},
+			expectedStatus:   http.StatusInternalServerError,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "404 não é repetido",
+			responses:        []int{http.StatusNotFound, http.StatusOK},
+			expectedStatus:   http.StatusNotFound,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "429 respeita Retry-After",
+			responses:        []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:       "0",
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != `{"cep":"01310100"}` {
+					t.Errorf("tentativa %d recebeu body %q", n, body)
+				}
+
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.responses[n-1])
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: NewTransport(http.DefaultTransport, "test", policy)}
+			resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"cep":"01310100"}`))
+			if err != nil {
+				t.Fatalf("Post() err = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d", resp.StatusCode, tt.expectedStatus)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.expectedAttempts {
+				t.Errorf("tentativas = %d, expected %d", got, tt.expectedAttempts)
+			}
+		})
+	}
+}
+
+func TestTransportRetriesConnectionErrors(t *testing.T) {
+	var attempts int32
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	transport := NewTransport(base, "test", Policy{MaxAttempts: 2, BudgetRatio: 0.2, BudgetMaxTokens: 1})
+	req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() err = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || attempts != 2 {
+		t.Errorf("status = %d, tentativas = %d", resp.StatusCode, attempts)
+	}
+}
+
+func TestTransportBudget(t *testing.T) {
+	var attempts int32
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&attempts, 1)
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody, Request: req}, nil
+	})
+
+	// Orçamento para apenas uma retentativa
+	transport := NewTransport(base, "test", Policy{MaxAttempts: 5, BudgetRatio: 0, BudgetMaxTokens: 1})
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatalf("RoundTrip() err = %v", err)
+		}
+	}
+
+	// 3 tentativas originais + 1 retentativa permitida pelo orçamento
+	if attempts != 4 {
+		t.Errorf("tentativas = %d, expected 4", attempts)
+	}
+}
+
+func TestTransportRespectsDeadline(t *testing.T) {
+	var attempts int32
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&attempts, 1)
+		resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}, Body: http.NoBody, Request: req}
+		resp.Header.Set("Retry-After", "30")
+		return resp, nil
+	})
+
+	// O limite de MaxBackoff não permite esperar além do deadline
+	transport := NewTransport(base, "test", Policy{MaxAttempts: 3, MaxBackoff: 10 * time.Millisecond, BudgetRatio: 1, BudgetMaxTokens: 10})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.invalid", nil)
+
+	start := time.Now()
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() err = %v", err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests || attempts != 1 {
+		t.Errorf("status = %d, tentativas = %d", resp.StatusCode, attempts)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("retentativa além do deadline aguardou %v", elapsed)
+	}
+}
+
+func TestTransportCapsRetryAfter(t *testing.T) {
+	var attempts int32
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if atomic.AddInt32(&attempts, 1) > 1 {
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+		}
+		resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}, Body: http.NoBody, Request: req}
+		resp.Header.Set("Retry-After", "3600")
+		return resp, nil
+	})
+
+	transport := NewTransport(base, "test", Policy{MaxAttempts: 3, MaxBackoff: 10 * time.Millisecond, BudgetRatio: 1, BudgetMaxTokens: 10})
+	req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+
+	// Sem deadline, o Retry-After longo é limitado a MaxBackoff
+	start := time.Now()
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() err = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || attempts != 2 {
+		t.Errorf("status = %d, tentativas = %d", resp.StatusCode, attempts)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Retry-After sem limite: aguardou %v", elapsed)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
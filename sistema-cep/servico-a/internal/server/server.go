@@ -1,53 +1,121 @@
 package server
 
 import (
+	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 
+	"servico-a/internal/admin"
+	"servico-a/internal/config"
 	"servico-a/internal/handlers"
+	"servico-a/internal/health"
+	"servico-a/internal/middleware"
+
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 // Server representa o servidor HTTP
 type Server struct {
-	port       string
-	cepHandler *handlers.CEPHandler
+	port           string
+	cepHandler     *handlers.CEPHandler
+	metricsHandler http.Handler
+	health         *health.Checker
+	cors           *middleware.CORS
+	auth           *middleware.Auth
+	rateLimiter    *middleware.RateLimiter
+	concurrency    *middleware.ConcurrencyLimiter
+	requestTimeout atomic.Int64
+}
+
+// NewServer cria uma nova instância do servidor. checker responde às sondas
+// de liveness, readiness e startup. metricsHandler nil deixa /metrics fora
+// da porta pública, quando ele é servido pelo admin
+func NewServer(cfg *config.Config, cepHandler *handlers.CEPHandler, metricsHandler http.Handler, checker *health.Checker) (*Server, error) {
+	authenticator, err := middleware.NewAuth(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		port:           cfg.Port,
+		cepHandler:     cepHandler,
+		metricsHandler: metricsHandler,
+		health:         checker,
+		cors:           middleware.NewCORS(cfg.CORS),
+		auth:           authenticator,
+		rateLimiter:    middleware.NewRateLimiter(cfg.RateLimit),
+		concurrency:    middleware.NewConcurrencyLimiter(cfg.Concurrency),
+	}
+	s.requestTimeout.Store(int64(cfg.RequestTimeout))
+	return s, nil
 }
 
-// NewServer cria uma nova instância do servidor
-func NewServer(port string, cepHandler *handlers.CEPHandler) *Server {
-	return &Server{
-		port:       port,
-		cepHandler: cepHandler,
+// Reload aplica às próximas requisições o timeout, o CORS, as API keys e o
+// rate limiting da nova configuração, já validada. Em caso de erro nada é
+// alterado
+func (s *Server) Reload(cfg *config.Config) error {
+	apply, err := s.PrepareReload(cfg)
+	if err != nil {
+		return err
 	}
+	apply()
+	return nil
+}
+
+// PrepareReload faz as etapas da recarga que podem falhar e retorna a
+// função que aplica a nova configuração. Nada é alterado até a função ser
+// chamada, o que permite validar outros componentes antes de aplicá-la
+func (s *Server) PrepareReload(cfg *config.Config) (func(), error) {
+	applyAuth, err := s.auth.Prepare(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		applyAuth()
+		s.requestTimeout.Store(int64(cfg.RequestTimeout))
+		s.cors.Update(cfg.CORS)
+		s.rateLimiter.Update(cfg.RateLimit)
+	}, nil
+}
+
+// timeout retorna o timeout atual das requisições
+func (s *Server) timeout() time.Duration {
+	return time.Duration(s.requestTimeout.Load())
 }
 
-// Start inicia o servidor HTTP
+// Start inicia o servidor HTTP. A sonda de startup passa a responder 200
+// assim que a porta está aberta
 func (s *Server) Start() error {
 	// Configura as rotas
 	mux := s.setupRoutes()
 
-	// Inicia o servidor com instrumentação OpenTelemetry
-	handler := otelhttp.NewHandler(mux, "servico-a")
-	return http.ListenAndServe(":"+s.port, handler)
+	// Aplica os middlewares e inicia o servidor com instrumentação
+	// OpenTelemetry. As sondas não geram spans nem métricas
+	handler := otelhttp.NewHandler(middleware.RequestID(middleware.TraceID(s.cors.Handler(mux))), "servico-a",
+		otelhttp.WithFilter(func(r *http.Request) bool { return !health.IsProbe(r) }))
+
+	listener, err := net.Listen("tcp", ":"+s.port)
+	if err != nil {
+		return err
+	}
+	s.health.MarkStarted()
+	return http.Serve(listener, handler)
+}
+
+// RegisterAdmin registra os endpoints administrativos dos componentes do
+// servidor
+func (s *Server) RegisterAdmin(r *admin.Registry) {
+	s.concurrency.RegisterAdmin(r)
 }
 
 // setupRoutes configura as rotas da aplicação
 func (s *Server) setupRoutes() *http.ServeMux {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/", s.cepHandler.HandleCEP)
-	mux.HandleFunc("/health", s.healthCheck)
-	return mux
-}
-
-// healthCheck endpoint para verificação de saúde da aplicação
-func (s *Server) healthCheck(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
+	mux.Handle("/", s.auth.Handler(s.rateLimiter.Handler(s.concurrency.Handler(middleware.DeadlineFunc(s.timeout, http.HandlerFunc(s.cepHandler.HandleCEP))))))
+	s.health.Register(mux)
+	if s.metricsHandler != nil {
+		mux.Handle("/metrics", s.metricsHandler)
 	}
-
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status": "healthy", "service": "servico-a"}`))
+	return mux
 }
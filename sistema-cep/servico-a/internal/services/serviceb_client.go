@@ -4,38 +4,116 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math"
+	"mime"
 	"net/http"
 	"time"
 
+	"servico-a/internal/balancer"
+	"servico-a/internal/breaker"
+	"servico-a/internal/deadline"
+	"servico-a/internal/hedge"
+	"servico-a/internal/logging"
 	"servico-a/internal/models"
+	"servico-a/internal/mtls"
+	"servico-a/internal/requestid"
+	"servico-a/internal/retry"
+	"servico-a/internal/singleflight"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// maxServiceBResponseBytes limita o tamanho das respostas lidas do Serviço B
+const maxServiceBResponseBytes = 64 * 1024
+
 // ServiceBClient é responsável pela comunicação com o Serviço B
 type ServiceBClient struct {
-	baseURL string
-	client  *http.Client
+	endpoints *balancer.Balancer
+	transport serviceBTransport
+	breaker   *breaker.Breaker
+	hedger    *hedge.Hedger
+	inflight  *singleflight.Group[*models.TemperatureResponse]
 }
 
-// NewServiceBClient cria uma nova instância do cliente do Serviço B
-func NewServiceBClient(baseURL string) *ServiceBClient {
-	return &ServiceBClient{
-		baseURL: baseURL,
+// serviceBTransport executa uma chamada a uma instância do Serviço B,
+// identificada pela URL do balanceador. Falhas são retornadas como
+// *ServiceBError
+type serviceBTransport interface {
+	getTemperature(ctx context.Context, endpoint string, cepReq models.CEPRequest) (*models.TemperatureResponse, error)
+	// retain libera os recursos das instâncias que não estão em endpoints
+	retain(endpoints []string)
+	close() error
+}
+
+// httpTransport chama o POST /temperature do Serviço B
+type httpTransport struct {
+	client *http.Client
+}
+
+// NewServiceBClient cria uma nova instância do cliente do Serviço B. As
+// chamadas são distribuídas entre os endpoints do balanceador. Com um
+// circuit breaker, as chamadas falham imediatamente enquanto o circuito
+// estiver aberto; com um hedger, uma segunda chamada é enviada a outra
+// instância quando a primeira demora. nil desabilita cada um deles. Falhas
+// transitórias são repetidas conforme a política de retentativas. Com
+// tlsReloader as chamadas usam mTLS; nil usa conexões sem TLS ou com TLS
+// comum, conforme as URLs. As chamadas não têm timeout fixo: são limitadas
+// pelo prazo do contexto, propagado ao Serviço B
+func NewServiceBClient(endpoints *balancer.Balancer, circuitBreaker *breaker.Breaker, hedger *hedge.Hedger, retryPolicy retry.Policy, tlsReloader *mtls.Reloader) *ServiceBClient {
+	var base http.RoundTripper = http.DefaultTransport
+	if tlsReloader != nil {
+		base = tlsReloader.Transport()
+	}
+	return newServiceBClient(endpoints, circuitBreaker, hedger, &httpTransport{
 		client: &http.Client{
-			Timeout:   30 * time.Second,
-			Transport: otelhttp.NewTransport(http.DefaultTransport),
+			Transport: retry.NewTransport(deadline.NewTransport(otelhttp.NewTransport(base)), "servico-b", retryPolicy),
 		},
+	})
+}
+
+func newServiceBClient(endpoints *balancer.Balancer, circuitBreaker *breaker.Breaker, hedger *hedge.Hedger, transport serviceBTransport) *ServiceBClient {
+	return &ServiceBClient{
+		endpoints: endpoints,
+		transport: transport,
+		breaker:   circuitBreaker,
+		hedger:    hedger,
+		inflight:  singleflight.New[*models.TemperatureResponse]("ForwardCEPRequest"),
 	}
 }
 
-// ForwardCEPRequest encaminha a requisição de CEP para o Serviço B
-func (s *ServiceBClient) ForwardCEPRequest(ctx context.Context, cepReq models.CEPRequest) (*models.ServiceBResponse, error) {
+// SetEndpoints troca as instâncias do Serviço B usadas nas próximas
+// chamadas. Em caso de erro as instâncias atuais são mantidas
+func (s *ServiceBClient) SetEndpoints(urls []string) error {
+	if err := s.endpoints.SetEndpoints(urls); err != nil {
+		return err
+	}
+	s.transport.retain(s.endpoints.URLs())
+	return nil
+}
+
+// Close libera as conexões mantidas pelo cliente
+func (s *ServiceBClient) Close() error {
+	return s.transport.close()
+}
+
+// attemptResult é o resultado de uma das chamadas de uma requisição
+type attemptResult struct {
+	attempt     int
+	endpoint    string
+	temperature *models.TemperatureResponse
+	err         error
+}
+
+// ForwardCEPRequest encaminha a requisição de CEP para o Serviço B e
+// valida a resposta. Requisições simultâneas do mesmo CEP compartilham a
+// mesma chamada. Falhas são retornadas como *ServiceBError
+func (s *ServiceBClient) ForwardCEPRequest(ctx context.Context, cepReq models.CEPRequest) (*models.TemperatureResponse, error) {
 	tracer := otel.Tracer("servico-a")
 	ctx, span := tracer.Start(ctx, "ForwardCEPRequest")
 	defer span.End()
@@ -45,54 +123,306 @@ func (s *ServiceBClient) ForwardCEPRequest(ctx context.Context, cepReq models.CE
 		attribute.String("cep.value", cepReq.CEP),
 	)
 
+	temperature, _, err := s.inflight.Do(ctx, cepReq.CEP, func(ctx context.Context) (*models.TemperatureResponse, error) {
+		return s.forwardCEPRequest(ctx, cepReq)
+	})
+	if err != nil {
+		// O chamador que desiste antes da resposta recebe o erro do próprio contexto
+		var serviceBErr *ServiceBError
+		if !errors.As(err, &serviceBErr) && ctx.Err() != nil {
+			err = &ServiceBError{Kind: ErrUnavailable, Err: err}
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// Cada chamador recebe sua própria cópia do resultado compartilhado
+	result := *temperature
+	return &result, nil
+}
+
+// forwardCEPRequest executa a chamada ao Serviço B, registrando os detalhes
+// no span do chamador que a iniciou
+func (s *ServiceBClient) forwardCEPRequest(ctx context.Context, cepReq models.CEPRequest) (*models.TemperatureResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
+	// Rejeita a chamada imediatamente se o circuito estiver aberto
+	var result attemptResult
+	if s.breaker != nil {
+		done, err := s.breaker.Allow(ctx)
+		if err != nil {
+			span.SetAttributes(attribute.String("circuit_breaker.state", s.breaker.State().String()))
+			span.SetStatus(codes.Error, "circuit breaker open")
+			return nil, &ServiceBError{Kind: ErrUnavailable, Err: err}
+		}
+		defer func() {
+			switch outcomeFor(ctx, result.err) {
+			case balancer.Success:
+				done(breaker.Success)
+			case balancer.Failure:
+				done(breaker.Failure)
+			default:
+				done(breaker.Ignore)
+			}
+		}()
+	}
+
+	logging.Debugf(ctx, "Encaminhando CEP %s para Serviço B", cepReq.CEP)
+	result = s.forward(ctx, cepReq)
+
+	span.SetAttributes(
+		attribute.String("load_balancer.endpoint", result.endpoint),
+		attribute.Int("hedge.winner", result.attempt),
+	)
+	return result.temperature, result.err
+}
+
+// forward executa a chamada e, se a primeira resposta demorar mais que o
+// atraso do hedge, envia uma segunda chamada a outra instância. A primeira
+// resposta conclusiva é usada e a outra chamada é cancelada
+func (s *ServiceBClient) forward(ctx context.Context, cepReq models.CEPRequest) attemptResult {
+	span := trace.SpanFromContext(ctx)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// O endpoint é escolhido antes de iniciar a chamada para que o hedge
+	// possa evitar a instância da chamada original
+	results := make(chan attemptResult, 2)
+	launch := func(attempt int, exclude ...string) string {
+		endpoint, recordEndpoint := s.endpoints.Pick(ctx, exclude...)
+		go func() { results <- s.attempt(ctx, attempt, endpoint, recordEndpoint, cepReq) }()
+		return endpoint.URL
+	}
+
+	first := launch(1)
+	inFlight := 1
+
+	var hedgeTimer <-chan time.Time
+	if s.hedger != nil && s.endpoints.Len() > 1 {
+		s.hedger.Request()
+		timer := time.NewTimer(s.hedger.Delay())
+		defer timer.Stop()
+		hedgeTimer = timer.C
+	}
+
+	for {
+		select {
+		case <-hedgeTimer:
+			hedgeTimer = nil
+			if !s.hedger.Allow() {
+				span.AddEvent("hedge.budget_exhausted")
+				continue
+			}
+			span.AddEvent("hedge.sent", trace.WithAttributes(attribute.Int("hedge.attempt", 2)))
+			launch(2, first)
+			inFlight++
+
+		case result := <-results:
+			inFlight--
+			// Uma falha da instância só encerra a requisição quando não há
+			// outra chamada em andamento
+			if isConclusive(result.err) || inFlight == 0 {
+				if inFlight > 0 {
+					span.AddEvent("hedge.canceled", trace.WithAttributes(attribute.Int("hedge.attempt", 3-result.attempt)))
+				}
+				return result
+			}
+		}
+	}
+}
+
+// attempt executa uma chamada à instância do Serviço B escolhida pelo
+// balanceador dentro de um span próprio e contabiliza o resultado com
+// recordEndpoint
+func (s *ServiceBClient) attempt(ctx context.Context, attempt int, endpoint *balancer.Endpoint, recordEndpoint func(balancer.Outcome), cepReq models.CEPRequest) (result attemptResult) {
+	tracer := otel.Tracer("servico-a")
+	ctx, span := tracer.Start(ctx, "ForwardCEPRequest.attempt", trace.WithAttributes(
+		attribute.Int("hedge.attempt", attempt),
+	))
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("load_balancer.endpoint", endpoint.URL),
+		attribute.String("load_balancer.strategy", string(s.endpoints.Strategy())),
+	)
+
+	result = attemptResult{attempt: attempt, endpoint: endpoint.URL}
+	start := time.Now()
+	defer func() {
+		recordEndpoint(outcomeFor(ctx, result.err))
+		if isConclusive(result.err) && s.hedger != nil {
+			s.hedger.Observe(time.Since(start))
+		}
+		if result.err != nil {
+			if ctx.Err() != nil {
+				span.SetStatus(codes.Error, "canceled")
+			} else {
+				span.SetStatus(codes.Error, result.err.Error())
+			}
+		}
+	}()
+
+	result.temperature, result.err = s.transport.getTemperature(ctx, endpoint.URL, cepReq)
+	return result
+}
+
+// getTemperature envia o CEP em JSON ao POST /temperature da instância
+func (t *httpTransport) getTemperature(ctx context.Context, endpoint string, cepReq models.CEPRequest) (*models.TemperatureResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
 	// Converte para JSON
-	jsonData, err := json.Marshal(cepReq)
+	body, err := json.Marshal(cepReq)
 	if err != nil {
-		span.SetStatus(codes.Error, "failed to marshal JSON")
 		return nil, fmt.Errorf("erro ao serializar JSON: %w", err)
 	}
 
 	// Cria a requisição para o Serviço B
-	url := s.baseURL + "/temperature"
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	url := endpoint + "/temperature"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
-		span.SetStatus(codes.Error, "failed to create request")
 		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if id := requestid.FromContext(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
 	span.SetAttributes(
 		attribute.String("http.method", "POST"),
 		attribute.String("http.url", url),
 	)
 
 	// Faz a requisição
-	log.Printf("Encaminhando CEP %s para Serviço B: %s", cepReq.CEP, url)
-	resp, err := s.client.Do(req)
+	resp, err := t.client.Do(req)
 	if err != nil {
-		span.SetStatus(codes.Error, "failed to make request")
-		return nil, fmt.Errorf("erro ao fazer requisição para Serviço B: %w", err)
+		return nil, &ServiceBError{Kind: ErrUnavailable, Err: err}
 	}
 	defer resp.Body.Close()
 
 	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	span.SetAttributes(mtls.PeerAttributes("server", resp.TLS)...)
 
-	// Lê a resposta
-	body, err := io.ReadAll(resp.Body)
+	// Lê a resposta, limitando o tamanho aceito
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxServiceBResponseBytes))
 	if err != nil {
-		span.SetStatus(codes.Error, "failed to read response")
-		return nil, fmt.Errorf("erro ao ler resposta do Serviço B: %w", err)
+		return nil, &ServiceBError{Kind: ErrUnavailable, StatusCode: resp.StatusCode, Err: err}
 	}
 
-	if resp.StatusCode == 422 {
-		
+	span.SetAttributes(attribute.Int("response.body_size", len(respBody)))
+	logging.Debugf(ctx, "Resposta do Serviço B (%s) - Status: %d, Body: %s", endpoint, resp.StatusCode, string(respBody))
+
+	return parseServiceBResponse(resp, respBody)
+}
+
+// retain não faz nada: o pool do http.Client descarta sozinho as conexões
+// ociosas das instâncias removidas
+func (t *httpTransport) retain(endpoints []string) {}
+
+// close libera as conexões ociosas do cliente HTTP
+func (t *httpTransport) close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
+
+// isConclusive indica se o resultado encerra a requisição: sucesso ou erro
+// de CEP, que seria o mesmo em qualquer instância
+func isConclusive(err error) bool {
+	return err == nil || errors.Is(err, ErrInvalidZipcode) || errors.Is(err, ErrZipcodeNotFound)
+}
+
+// outcomeFor classifica o resultado para o balanceador e o circuit breaker.
+// Erros de CEP são respostas válidas e cancelamentos pelo cliente não
+// indicam falha do Serviço B
+func outcomeFor(ctx context.Context, err error) balancer.Outcome {
+	switch {
+	case isConclusive(err):
+		return balancer.Success
+	case ctx.Err() != nil:
+		return balancer.Ignore
+	default:
+		return balancer.Failure
 	}
+}
+
+// parseServiceBResponse valida a resposta do Serviço B contra o contrato
+// esperado e a converte no modelo de temperatura ou em um *ServiceBError
+func parseServiceBResponse(resp *http.Response, body []byte) (*models.TemperatureResponse, error) {
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		if !isJSON(resp) {
+			return nil, &ServiceBError{Kind: ErrProtocol, StatusCode: resp.StatusCode, Message: "unexpected content type " + resp.Header.Get("Content-Type")}
+		}
+
+		var temperature models.TemperatureResponse
+		if err := decodeStrict(body, &temperature); err != nil {
+			return nil, &ServiceBError{Kind: ErrProtocol, StatusCode: resp.StatusCode, Err: err}
+		}
+		if err := validateTemperature(&temperature); err != nil {
+			return nil, &ServiceBError{Kind: ErrProtocol, StatusCode: resp.StatusCode, Err: err}
+		}
+		return &temperature, nil
+
+	case resp.StatusCode == http.StatusUnprocessableEntity || resp.StatusCode == http.StatusNotFound:
+		// Erros de CEP só são aceitos com o body de erro do Serviço B; um 404
+		// de um proxy, por exemplo, é tratado como erro de protocolo
+		var errResp models.ErrorResponse
+		if !isJSON(resp) || decodeStrict(body, &errResp) != nil || errResp.Message == "" {
+			return nil, &ServiceBError{Kind: ErrProtocol, StatusCode: resp.StatusCode, Message: "unexpected error body"}
+		}
 
-	span.SetAttributes(attribute.Int("response.body_size", len(body)))
-	log.Printf("Resposta do Serviço B - Status: %d, Body: %s", resp.StatusCode, string(body))
+		kind := ErrInvalidZipcode
+		if resp.StatusCode == http.StatusNotFound {
+			kind = ErrZipcodeNotFound
+		}
+		return nil, &ServiceBError{Kind: kind, StatusCode: resp.StatusCode, Message: errResp.Message}
+
+	case resp.StatusCode == http.StatusGatewayTimeout:
+		// O Serviço B esgotou o prazo propagado no header X-Request-Timeout
+		return nil, &ServiceBError{Kind: ErrUnavailable, StatusCode: resp.StatusCode, Err: context.DeadlineExceeded}
+
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return nil, &ServiceBError{Kind: ErrUnavailable, StatusCode: resp.StatusCode}
+
+	default:
+		return nil, &ServiceBError{Kind: ErrProtocol, StatusCode: resp.StatusCode, Message: "unexpected status code"}
+	}
+}
 
-	return &models.ServiceBResponse{
-		StatusCode: resp.StatusCode,
-		Body:       body,
-	}, nil
+// isJSON verifica se a resposta declara conteúdo JSON
+func isJSON(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// decodeStrict decodifica o body rejeitando campos desconhecidos e conteúdo
+// após o objeto JSON
+func decodeStrict(body []byte, dst interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after JSON object")
+	}
+	return nil
+}
+
+// validateTemperature verifica se os campos obrigatórios têm valores válidos
+func validateTemperature(t *models.TemperatureResponse) error {
+	if t.City == "" {
+		return errors.New("missing city")
+	}
+	for _, value := range []float64{t.TempC, t.TempF, t.TempK} {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return errors.New("invalid temperature value")
+		}
+	}
+	if t.TempK < 0 {
+		return errors.New("temperature below absolute zero")
+	}
+	return nil
 }
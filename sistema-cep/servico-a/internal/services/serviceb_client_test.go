@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"servico-a/internal/balancer"
+	"servico-a/internal/deadline"
+	"servico-a/internal/hedge"
+	"servico-a/internal/models"
+	"servico-a/internal/retry"
+)
+
+func TestForwardCEPRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		contentType  string
+		body         string
+		expectedErr  error
+		expectedCity string
+	}{
+		{
+			name:         "Resposta de sucesso válida",
+			status:       http.StatusOK,
+			contentType:  "application/json",
+			body:         `{"city":"São Paulo","temp_C":20,"temp_F":68,"temp_K":293.15}`,
+			expectedCity: "São Paulo",
+		},
+		{
+			name:        "Sucesso sem cidade",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"city":"","temp_C":20,"temp_F":68,"temp_K":293.15}`,
+			expectedErr: ErrProtocol,
+		},
+		{
+			name:        "Sucesso com campo desconhecido",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"city":"São Paulo","temp_C":20,"temp_F":68,"temp_K":293.15,"extra":1}`,
+			expectedErr: ErrProtocol,
+		},
+		{
+			name:        "Sucesso com body que não é JSON",
+			status:      http.StatusOK,
+			contentType: "text/html",
+			body:        `<html>ok</html>`,
+			expectedErr: ErrProtocol,
+		},
+		{
+			name:        "CEP inválido",
+			status:      http.StatusUnprocessableEntity,
+			contentType: "application/json",
+			body:        `{"message":"invalid zipcode"}`,
+			expectedErr: ErrInvalidZipcode,
+		},
+		{
+			name:        "CEP não encontrado",
+			status:      http.StatusNotFound,
+			contentType: "application/json",
+			body:        `{"message":"can not find zipcode"}`,
+			expectedErr: ErrZipcodeNotFound,
+		},
+		{
+			name:        "404 de um proxy",
+			status:      http.StatusNotFound,
+			contentType: "text/html",
+			body:        `<html>Not Found</html>`,
+			expectedErr: ErrProtocol,
+		},
+		{
+			name:        "Erro interno do Serviço B",
+			status:      http.StatusInternalServerError,
+			contentType: "application/json",
+			body:        `{"message":"internal server error"}`,
+			expectedErr: ErrUnavailable,
+		},
+		{
+			name:        "Página 502 de um proxy",
+			status:      http.StatusBadGateway,
+			contentType: "text/html",
+			body:        `<html><body>502 Bad Gateway</body></html>`,
+			expectedErr: ErrUnavailable,
+		},
+		{
+			name:        "Prazo esgotado no Serviço B",
+			status:      http.StatusGatewayTimeout,
+			contentType: "application/problem+json",
+			body:        `{"code":"DEADLINE_EXCEEDED"}`,
+			expectedErr: context.DeadlineExceeded,
+		},
+		{
+			name:        "Status inesperado",
+			status:      http.StatusTeapot,
+			contentType: "application/json",
+			body:        `{}`,
+			expectedErr: ErrProtocol,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewServiceBClient(newTestBalancer(t, server.URL), nil, nil, retry.Policy{}, nil)
+			temperature, err := client.ForwardCEPRequest(context.Background(), models.CEPRequest{CEP: "01310100"})
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("ForwardCEPRequest() err = %v, expected %v", err, tt.expectedErr)
+				}
+				var serviceBErr *ServiceBError
+				if !errors.As(err, &serviceBErr) || serviceBErr.StatusCode != tt.status {
+					t.Errorf("ForwardCEPRequest() err = %#v, expected *ServiceBError com status %d", err, tt.status)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ForwardCEPRequest() err = %v", err)
+			}
+			if temperature.City != tt.expectedCity {
+				t.Errorf("City = %q, expected %q", temperature.City, tt.expectedCity)
+			}
+		})
+	}
+}
+
+func TestForwardCEPRequestConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client := NewServiceBClient(newTestBalancer(t, server.URL), nil, nil, retry.Policy{}, nil)
+	_, err := client.ForwardCEPRequest(context.Background(), models.CEPRequest{CEP: "01310100"})
+
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("ForwardCEPRequest() err = %v, expected ErrUnavailable", err)
+	}
+}
+
+func TestForwardCEPRequestHedge(t *testing.T) {
+	canceled := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// O servidor só detecta o cancelamento depois que o body foi lido
+		io.ReadAll(r.Body)
+		select {
+		case <-r.Context().Done():
+			close(canceled)
+		case <-time.After(2 * time.Second):
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"city":"Lenta","temp_C":20,"temp_F":68,"temp_K":293.15}`))
+		}
+	}))
+	defer slow.Close()
+
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"city":"Rápida","temp_C":20,"temp_F":68,"temp_K":293.15}`))
+	}))
+	defer fast.Close()
+
+	// O round-robin envia a primeira chamada à instância lenta, e o hedge
+	// sempre evita a instância da chamada original
+	hedger := hedge.New(hedge.Policy{Delay: 20 * time.Millisecond, MaxRatio: 1})
+	client := NewServiceBClient(newTestBalancer(t, slow.URL, fast.URL), nil, hedger, retry.Policy{}, nil)
+
+	start := time.Now()
+	temperature, err := client.ForwardCEPRequest(context.Background(), models.CEPRequest{CEP: "01310100"})
+	if err != nil {
+		t.Fatalf("ForwardCEPRequest() err = %v", err)
+	}
+	if temperature.City != "Rápida" {
+		t.Errorf("City = %q, expected resposta do hedge", temperature.City)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ForwardCEPRequest() levou %v, expected resposta do hedge", elapsed)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("chamada à instância lenta não foi cancelada")
+	}
+}
+
+func TestForwardCEPRequestHedgeConcurrent(t *testing.T) {
+	// Cada instância registra os CEPs recebidos e segura as respostas até
+	// todos os hedges chegarem
+	arrived := make(chan struct{}, 4)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	received := map[string][]string{}
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var cepReq models.CEPRequest
+			json.NewDecoder(r.Body).Decode(&cepReq)
+			mu.Lock()
+			received[name] = append(received[name], cepReq.CEP)
+			mu.Unlock()
+			arrived <- struct{}{}
+
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"city":"São Paulo","temp_C":20,"temp_F":68,"temp_K":293.15}`))
+		}))
+	}
+	a := newServer("a")
+	defer a.Close()
+	b := newServer("b")
+	defer b.Close()
+
+	// Com duas requisições simultâneas, o round-robin sem exclusão levaria o
+	// hedge de cada uma à instância da sua chamada original
+	hedger := hedge.New(hedge.Policy{Delay: 200 * time.Millisecond, MaxRatio: 1})
+	client := NewServiceBClient(newTestBalancer(t, a.URL, b.URL), nil, hedger, retry.Policy{}, nil)
+
+	errs := make(chan error, 2)
+	for _, cep := range []string{"01310100", "20040002"} {
+		go func(cep string) {
+			_, err := client.ForwardCEPRequest(context.Background(), models.CEPRequest{CEP: cep})
+			errs <- err
+		}(cep)
+		<-arrived
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-arrived:
+		case <-time.After(2 * time.Second):
+			t.Fatal("hedges não foram enviados")
+		}
+	}
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("ForwardCEPRequest() err = %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for name, ceps := range received {
+		if len(ceps) != 2 || ceps[0] == ceps[1] {
+			t.Errorf("instância %s recebeu %v, expected uma chamada de cada requisição", name, ceps)
+		}
+	}
+}
+
+func TestForwardCEPRequestHedgeBudget(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(30 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"city":"São Paulo","temp_C":20,"temp_F":68,"temp_K":293.15}`))
+	}))
+	defer server.Close()
+
+	// Sem orçamento para hedges, cada requisição gera uma única chamada
+	hedger := hedge.New(hedge.Policy{Delay: time.Millisecond, MaxRatio: 0})
+	client := NewServiceBClient(newTestBalancer(t, server.URL, server.URL), nil, hedger, retry.Policy{}, nil)
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.ForwardCEPRequest(context.Background(), models.CEPRequest{CEP: "01310100"}); err != nil {
+			t.Fatalf("ForwardCEPRequest() err = %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("chamadas = %d, expected 3", got)
+	}
+}
+
+func TestForwardCEPRequestCoalesces(t *testing.T) {
+	var calls int32
+	arrived := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		arrived <- struct{}{}
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"city":"São Paulo","temp_C":20,"temp_F":68,"temp_K":293.15}`))
+	}))
+	defer server.Close()
+
+	client := NewServiceBClient(newTestBalancer(t, server.URL), nil, nil, retry.Policy{}, nil)
+
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			temperature, err := client.ForwardCEPRequest(context.Background(), models.CEPRequest{CEP: "01310100"})
+			if err == nil && temperature.City != "São Paulo" {
+				err = errors.New("cidade inesperada " + temperature.City)
+			}
+			errs <- err
+		}()
+	}
+
+	// Aguarda a chamada do líder e dá tempo para os demais se juntarem a ela
+	<-arrived
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < 5; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("ForwardCEPRequest() err = %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("chamadas ao Serviço B = %d, expected 1", got)
+	}
+}
+
+func TestForwardCEPRequestDeadline(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(deadline.Header)
+		io.ReadAll(r.Body)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewServiceBClient(newTestBalancer(t, server.URL), nil, nil, retry.Policy{}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := client.ForwardCEPRequest(ctx, models.CEPRequest{CEP: "01310100"})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ForwardCEPRequest() err = %v, expected context.DeadlineExceeded", err)
+	}
+	if remaining, ok := deadline.Parse(<-received); !ok || remaining <= 0 || remaining > 100*time.Millisecond {
+		t.Errorf("prazo enviado ao Serviço B = %v, expected até 100ms", remaining)
+	}
+}
+
+func TestSetEndpoints(t *testing.T) {
+	newServer := func(city string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"city":"` + city + `","temp_C":20,"temp_F":68,"temp_K":293.15}`))
+		}))
+	}
+	old := newServer("Antiga")
+	defer old.Close()
+	replacement := newServer("Nova")
+	defer replacement.Close()
+
+	client := NewServiceBClient(newTestBalancer(t, old.URL), nil, nil, retry.Policy{}, nil)
+
+	if err := client.SetEndpoints([]string{"servico-b:8081"}); err == nil {
+		t.Error("SetEndpoints() err = nil, expected erro com URL inválida")
+	}
+	if err := client.SetEndpoints([]string{replacement.URL}); err != nil {
+		t.Fatalf("SetEndpoints() err = %v", err)
+	}
+
+	temperature, err := client.ForwardCEPRequest(context.Background(), models.CEPRequest{CEP: "01310100"})
+	if err != nil {
+		t.Fatalf("ForwardCEPRequest() err = %v", err)
+	}
+	if temperature.City != "Nova" {
+		t.Errorf("City = %q, expected resposta da nova instância", temperature.City)
+	}
+}
+
+// newTestBalancer cria um balanceador sem ejeção e sem health checks
+func newTestBalancer(t *testing.T, urls ...string) *balancer.Balancer {
+	t.Helper()
+
+	endpoints, err := balancer.New("servico-b", urls, balancer.Settings{})
+	if err != nil {
+		t.Fatalf("balancer.New() err = %v", err)
+	}
+	return endpoints
+}
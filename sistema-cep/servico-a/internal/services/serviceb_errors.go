@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Categorias de erro retornadas pelo ServiceBClient. Use errors.Is para
+// identificar a categoria de um erro
+var (
+	// ErrInvalidZipcode indica que o Serviço B rejeitou o formato do CEP
+	ErrInvalidZipcode = errors.New("servico-b: invalid zipcode")
+	// ErrZipcodeNotFound indica que o CEP não foi encontrado
+	ErrZipcodeNotFound = errors.New("servico-b: zipcode not found")
+	// ErrUnavailable indica falha de conexão, erro 5xx ou circuito aberto
+	ErrUnavailable = errors.New("servico-b: unavailable")
+	// ErrProtocol indica uma resposta fora do contrato do Serviço B, como um
+	// body que não é JSON ou um status inesperado
+	ErrProtocol = errors.New("servico-b: protocol error")
+)
+
+// ServiceBError descreve uma falha na chamada ao Serviço B
+type ServiceBError struct {
+	Kind       error
+	StatusCode int
+	Message    string
+	Err        error
+}
+
+func (e *ServiceBError) Error() string {
+	msg := e.Kind.Error()
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("%s (status %d)", msg, e.StatusCode)
+	}
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+// Unwrap permite que errors.Is reconheça tanto a categoria quanto a causa
+func (e *ServiceBError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"servico-a/internal/balancer"
+	"servico-a/internal/breaker"
+	"servico-a/internal/hedge"
+	"servico-a/internal/logging"
+	"servico-a/internal/models"
+	"servico-a/internal/mtls"
+	"servico-a/internal/requestid"
+	"servico-a/internal/retry"
+	"servico-a/internal/temperaturepb"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// connDrainTimeout é o tempo que a conexão de uma instância removida
+// continua aberta para as chamadas em andamento
+const connDrainTimeout = 30 * time.Second
+
+// NewServiceBGRPCClient cria um cliente do Serviço B que usa o
+// TemperatureService gRPC em vez do POST /temperature. As instâncias
+// continuam sendo as URLs do balanceador, usadas nos health checks HTTP; a
+// chamada gRPC vai para o mesmo host na porta grpcPort. Com tlsReloader as
+// conexões usam mTLS; nil usa conexões sem TLS
+func NewServiceBGRPCClient(endpoints *balancer.Balancer, circuitBreaker *breaker.Breaker, hedger *hedge.Hedger, retryPolicy retry.Policy, grpcPort string, tlsReloader *mtls.Reloader) *ServiceBClient {
+	creds := insecure.NewCredentials()
+	if tlsReloader != nil {
+		creds = tlsReloader.TransportCredentials()
+	}
+	return newServiceBClient(endpoints, circuitBreaker, hedger, &grpcTransport{
+		port:  grpcPort,
+		conns: make(map[string]*grpc.ClientConn),
+		dialOptions: []grpc.DialOption{
+			grpc.WithTransportCredentials(creds),
+			grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+			grpc.WithUnaryInterceptor(retry.UnaryClientInterceptor("servico-b", retryPolicy)),
+		},
+	})
+}
+
+// grpcTransport mantém uma conexão gRPC por instância do Serviço B. O prazo
+// da requisição é propagado pelo próprio gRPC
+type grpcTransport struct {
+	port        string
+	dialOptions []grpc.DialOption
+
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+// getTemperature chama o GetTemperatureByCEP na instância
+func (t *grpcTransport) getTemperature(ctx context.Context, endpoint string, cepReq models.CEPRequest) (*models.TemperatureResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
+	conn, err := t.conn(endpoint)
+	if err != nil {
+		return nil, &ServiceBError{Kind: ErrUnavailable, Err: err}
+	}
+	span.SetAttributes(attribute.String("rpc.target", conn.Target()))
+
+	if id := requestid.FromContext(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(requestid.Header), id)
+	}
+
+	var p peer.Peer
+	resp, err := temperaturepb.NewTemperatureServiceClient(conn).GetTemperatureByCEP(ctx, &temperaturepb.GetTemperatureByCEPRequest{Cep: cepReq.CEP}, grpc.Peer(&p))
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		span.SetAttributes(mtls.PeerAttributes("server", &info.State)...)
+	}
+	if err != nil {
+		logging.Debugf(ctx, "Resposta gRPC do Serviço B (%s) - Status: %s", conn.Target(), status.Code(err))
+		return nil, serviceBErrorFromStatus(err)
+	}
+
+	logging.Debugf(ctx, "Resposta gRPC do Serviço B (%s) - Status: OK, Cidade: %s", conn.Target(), resp.GetCity())
+
+	temperature := &models.TemperatureResponse{
+		City:  resp.GetCity(),
+		TempC: resp.GetTempC(),
+		TempF: resp.GetTempF(),
+		TempK: resp.GetTempK(),
+	}
+	if err := validateTemperature(temperature); err != nil {
+		return nil, &ServiceBError{Kind: ErrProtocol, Err: err}
+	}
+	return temperature, nil
+}
+
+// conn retorna a conexão da instância, criando-a na primeira chamada. A
+// conexão é estabelecida em segundo plano pelo gRPC
+func (t *grpcTransport) conn(endpoint string) (*grpc.ClientConn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if conn, ok := t.conns[endpoint]; ok {
+		return conn, nil
+	}
+
+	target, err := grpcTarget(endpoint, t.port)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.Dial(target, t.dialOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar conexão gRPC com %s: %w", target, err)
+	}
+	t.conns[endpoint] = conn
+	return conn, nil
+}
+
+// retain encerra as conexões das instâncias removidas. O encerramento
+// aguarda connDrainTimeout para que as chamadas em andamento terminem
+func (t *grpcTransport) retain(endpoints []string) {
+	keep := make(map[string]bool, len(endpoints))
+	for _, endpoint := range endpoints {
+		keep[endpoint] = true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for endpoint, conn := range t.conns {
+		if keep[endpoint] {
+			continue
+		}
+		delete(t.conns, endpoint)
+		time.AfterFunc(connDrainTimeout, func() { conn.Close() })
+	}
+}
+
+// close encerra as conexões com todas as instâncias
+func (t *grpcTransport) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var errs []error
+	for endpoint, conn := range t.conns {
+		errs = append(errs, conn.Close())
+		delete(t.conns, endpoint)
+	}
+	return errors.Join(errs...)
+}
+
+// grpcTarget troca a porta da URL da instância pela porta gRPC
+func grpcTarget(endpoint, port string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return "", fmt.Errorf("URL do Serviço B inválida: %q", endpoint)
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// serviceBErrorFromStatus converte o status gRPC no *ServiceBError
+// equivalente ao status HTTP do POST /temperature
+func serviceBErrorFromStatus(err error) error {
+	st := status.Convert(err)
+
+	switch st.Code() {
+	case codes.InvalidArgument:
+		return &ServiceBError{Kind: ErrInvalidZipcode, Message: st.Message()}
+	case codes.NotFound:
+		return &ServiceBError{Kind: ErrZipcodeNotFound, Message: st.Message()}
+	case codes.DeadlineExceeded:
+		return &ServiceBError{Kind: ErrUnavailable, Err: context.DeadlineExceeded}
+	case codes.Canceled:
+		return &ServiceBError{Kind: ErrUnavailable, Err: context.Canceled}
+	case codes.Unavailable, codes.ResourceExhausted, codes.Internal, codes.Unknown, codes.Aborted:
+		return &ServiceBError{Kind: ErrUnavailable, Message: st.Code().String(), Err: err}
+	default:
+		return &ServiceBError{Kind: ErrProtocol, Message: "unexpected status code " + st.Code().String()}
+	}
+}
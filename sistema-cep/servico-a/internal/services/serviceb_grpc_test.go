@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"servico-a/internal/models"
+	"servico-a/internal/requestid"
+	"servico-a/internal/retry"
+	"servico-a/internal/temperaturepb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeTemperatureServer simula o TemperatureService do Serviço B
+type fakeTemperatureServer struct {
+	temperaturepb.UnimplementedTemperatureServiceServer
+	handle func(ctx context.Context, req *temperaturepb.GetTemperatureByCEPRequest) (*temperaturepb.GetTemperatureByCEPResponse, error)
+}
+
+func (f *fakeTemperatureServer) GetTemperatureByCEP(ctx context.Context, req *temperaturepb.GetTemperatureByCEPRequest) (*temperaturepb.GetTemperatureByCEPResponse, error) {
+	return f.handle(ctx, req)
+}
+
+func TestForwardCEPRequestGRPC(t *testing.T) {
+	tests := []struct {
+		name         string
+		response     *temperaturepb.GetTemperatureByCEPResponse
+		code         codes.Code
+		expectedErr  error
+		expectedCity string
+	}{
+		{
+			name:         "Resposta de sucesso válida",
+			response:     &temperaturepb.GetTemperatureByCEPResponse{City: "São Paulo", TempC: 20, TempF: 68, TempK: 293.15},
+			expectedCity: "São Paulo",
+		},
+		{
+			name:        "Sucesso sem cidade",
+			response:    &temperaturepb.GetTemperatureByCEPResponse{TempC: 20, TempF: 68, TempK: 293.15},
+			expectedErr: ErrProtocol,
+		},
+		{
+			name:        "CEP inválido",
+			code:        codes.InvalidArgument,
+			expectedErr: ErrInvalidZipcode,
+		},
+		{
+			name:        "CEP não encontrado",
+			code:        codes.NotFound,
+			expectedErr: ErrZipcodeNotFound,
+		},
+		{
+			name:        "Erro interno do Serviço B",
+			code:        codes.Internal,
+			expectedErr: ErrUnavailable,
+		},
+		{
+			name:        "Serviço B sobrecarregado",
+			code:        codes.Unavailable,
+			expectedErr: ErrUnavailable,
+		},
+		{
+			name:        "Prazo esgotado no Serviço B",
+			code:        codes.DeadlineExceeded,
+			expectedErr: context.DeadlineExceeded,
+		},
+		{
+			name:        "Status inesperado",
+			code:        codes.Unimplemented,
+			expectedErr: ErrProtocol,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint, port := newTestGRPCServer(t, func(ctx context.Context, req *temperaturepb.GetTemperatureByCEPRequest) (*temperaturepb.GetTemperatureByCEPResponse, error) {
+				if tt.code != codes.OK {
+					return nil, status.Error(tt.code, tt.code.String())
+				}
+				return tt.response, nil
+			})
+
+			client := NewServiceBGRPCClient(newTestBalancer(t, endpoint), nil, nil, retry.Policy{}, port, nil)
+			defer client.Close()
+			temperature, err := client.ForwardCEPRequest(context.Background(), models.CEPRequest{CEP: "01310100"})
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("ForwardCEPRequest() err = %v, expected %v", err, tt.expectedErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ForwardCEPRequest() err = %v", err)
+			}
+			if temperature.City != tt.expectedCity {
+				t.Errorf("City = %q, expected %q", temperature.City, tt.expectedCity)
+			}
+		})
+	}
+}
+
+func TestForwardCEPRequestGRPCMetadata(t *testing.T) {
+	type received struct {
+		cep       string
+		requestID string
+		deadline  time.Duration
+	}
+	calls := make(chan received, 1)
+	endpoint, port := newTestGRPCServer(t, func(ctx context.Context, req *temperaturepb.GetTemperatureByCEPRequest) (*temperaturepb.GetTemperatureByCEPResponse, error) {
+		r := received{cep: req.GetCep()}
+		if values := metadata.ValueFromIncomingContext(ctx, strings.ToLower(requestid.Header)); len(values) > 0 {
+			r.requestID = values[0]
+		}
+		if d, ok := ctx.Deadline(); ok {
+			r.deadline = time.Until(d)
+		}
+		calls <- r
+		return &temperaturepb.GetTemperatureByCEPResponse{City: "São Paulo", TempC: 20, TempF: 68, TempK: 293.15}, nil
+	})
+
+	client := NewServiceBGRPCClient(newTestBalancer(t, endpoint), nil, nil, retry.Policy{}, port, nil)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(requestid.NewContext(context.Background(), "abc123"), time.Second)
+	defer cancel()
+	if _, err := client.ForwardCEPRequest(ctx, models.CEPRequest{CEP: "01310100"}); err != nil {
+		t.Fatalf("ForwardCEPRequest() err = %v", err)
+	}
+
+	got := <-calls
+	if got.cep != "01310100" {
+		t.Errorf("CEP recebido = %q, expected 01310100", got.cep)
+	}
+	if got.requestID != "abc123" {
+		t.Errorf("request ID recebido = %q, expected abc123", got.requestID)
+	}
+	if got.deadline <= 0 || got.deadline > time.Second {
+		t.Errorf("prazo recebido = %v, expected até 1s", got.deadline)
+	}
+}
+
+// newTestGRPCServer serve o TemperatureService em uma porta local e retorna
+// a URL da instância para o balanceador e a porta gRPC
+func newTestGRPCServer(t *testing.T, handle func(ctx context.Context, req *temperaturepb.GetTemperatureByCEPRequest) (*temperaturepb.GetTemperatureByCEPResponse, error)) (string, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() err = %v", err)
+	}
+	srv := grpc.NewServer()
+	temperaturepb.RegisterTemperatureServiceServer(srv, &fakeTemperatureServer{handle: handle})
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	return "http://127.0.0.1:8081", port
+}
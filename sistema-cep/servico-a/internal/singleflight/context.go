@@ -0,0 +1,90 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// flightContext é o contexto da execução compartilhada. Ele herda os
+// valores do líder, mas não o seu cancelamento nem o seu prazo: o prazo é
+// o mais longo entre os chamadores e cresce quando um chamador com prazo
+// maior se junta à execução. Sem prazo em algum chamador, a execução não
+// tem prazo e termina apenas quando todos os chamadores desistem
+type flightContext struct {
+	context.Context
+
+	mu       sync.Mutex
+	deadline time.Time
+	timer    *time.Timer
+	done     chan struct{}
+	err      error
+}
+
+// newFlightContext cria o contexto da execução a partir do contexto do líder
+func newFlightContext(leader context.Context) *flightContext {
+	c := &flightContext{
+		Context: context.WithoutCancel(leader),
+		done:    make(chan struct{}),
+	}
+	if deadline, ok := leader.Deadline(); ok {
+		c.deadline = deadline
+		c.timer = time.AfterFunc(time.Until(deadline), func() {
+			c.cancel(context.DeadlineExceeded)
+		})
+	}
+	return c
+}
+
+// extend estende o prazo da execução até o prazo do chamador. Um chamador
+// sem prazo remove o prazo da execução
+func (c *flightContext) extend(caller context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil || c.timer == nil {
+		return
+	}
+	deadline, ok := caller.Deadline()
+	if !ok {
+		c.timer.Stop()
+		c.timer = nil
+		c.deadline = time.Time{}
+		return
+	}
+	if deadline.After(c.deadline) && c.timer.Stop() {
+		c.deadline = deadline
+		c.timer.Reset(time.Until(deadline))
+	}
+}
+
+// cancel encerra a execução com o erro informado
+func (c *flightContext) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return
+	}
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.err = err
+	close(c.done)
+}
+
+func (c *flightContext) Deadline() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.deadline, !c.deadline.IsZero()
+}
+
+func (c *flightContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *flightContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
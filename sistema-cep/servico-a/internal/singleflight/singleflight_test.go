@@ -0,0 +1,244 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestDoCoalesces(t *testing.T) {
+	g := New[int]("test")
+
+	var calls int32
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, _, err := g.Do(context.Background(), "01310100", fn)
+			if err != nil {
+				t.Errorf("Do() err = %v", err)
+			}
+			results <- val
+		}()
+	}
+
+	waitForCallers(t, g, "01310100", 10)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for val := range results {
+		if val != 42 {
+			t.Errorf("Do() = %d, expected 42", val)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("execuções = %d, expected 1", calls)
+	}
+}
+
+func TestDoLeaderCanceled(t *testing.T) {
+	g := New[int]("test")
+
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		select {
+		case <-release:
+			return 42, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, _, err := g.Do(leaderCtx, "01310100", fn)
+		leaderErr <- err
+	}()
+	waitForCallers(t, g, "01310100", 1)
+
+	waiterResult := make(chan int, 1)
+	go func() {
+		val, shared, err := g.Do(context.Background(), "01310100", fn)
+		if err != nil || !shared {
+			t.Errorf("Do() shared = %v, err = %v", shared, err)
+		}
+		waiterResult <- val
+	}()
+	waitForCallers(t, g, "01310100", 2)
+
+	// O líder desiste, mas a execução continua para o outro chamador
+	cancelLeader()
+	if err := <-leaderErr; err != context.Canceled {
+		t.Errorf("Do() do líder err = %v, expected context.Canceled", err)
+	}
+
+	close(release)
+	if val := <-waiterResult; val != 42 {
+		t.Errorf("Do() = %d, expected 42", val)
+	}
+}
+
+func TestDoLeaderDeadline(t *testing.T) {
+	g := New[int]("test")
+
+	release := make(chan struct{})
+	deadlines := make(chan time.Time, 1)
+	fn := func(ctx context.Context) (int, error) {
+		select {
+		case <-release:
+			deadline, _ := ctx.Deadline()
+			deadlines <- deadline
+			return 42, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	leaderCtx, cancelLeader := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelLeader()
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, _, err := g.Do(leaderCtx, "01310100", fn)
+		leaderErr <- err
+	}()
+	waitForCallers(t, g, "01310100", 1)
+
+	waiterCtx, cancelWaiter := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelWaiter()
+	waiterResult := make(chan int, 1)
+	go func() {
+		val, shared, err := g.Do(waiterCtx, "01310100", fn)
+		if err != nil || !shared {
+			t.Errorf("Do() shared = %v, err = %v", shared, err)
+		}
+		waiterResult <- val
+	}()
+	waitForCallers(t, g, "01310100", 2)
+
+	// O prazo do líder termina, mas a execução segue até o prazo do outro chamador
+	if err := <-leaderErr; err != context.DeadlineExceeded {
+		t.Errorf("Do() do líder err = %v, expected context.DeadlineExceeded", err)
+	}
+
+	close(release)
+	if val := <-waiterResult; val != 42 {
+		t.Errorf("Do() = %d, expected 42", val)
+	}
+	expected, _ := waiterCtx.Deadline()
+	if deadline := <-deadlines; !deadline.Equal(expected) {
+		t.Errorf("prazo da execução = %v, expected %v", deadline, expected)
+	}
+}
+
+func TestDoAllCallersCanceled(t *testing.T) {
+	g := New[int]("test")
+
+	canceled := make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		close(canceled)
+		return 0, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go g.Do(ctx, "01310100", fn)
+	waitForCallers(t, g, "01310100", 1)
+	cancel()
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("execução não foi cancelada após todos os chamadores desistirem")
+	}
+
+	// Uma nova chamada inicia outra execução
+	val, shared, err := g.Do(context.Background(), "01310100", func(ctx context.Context) (int, error) { return 7, nil })
+	if val != 7 || shared || err != nil {
+		t.Errorf("Do() = %d, %v, %v; expected nova execução", val, shared, err)
+	}
+}
+
+func TestDoLinksWaiterToLeader(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := tp.Tracer("test")
+
+	g := New[int]("test")
+	g.tracer = tracer
+
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		<-release
+		return 42, nil
+	}
+
+	leaderCtx, leaderSpan := tracer.Start(context.Background(), "leader")
+	done := make(chan struct{})
+	go func() {
+		g.Do(leaderCtx, "01310100", fn)
+		close(done)
+	}()
+	waitForCallers(t, g, "01310100", 1)
+
+	go func() {
+		g.Do(context.Background(), "01310100", fn)
+	}()
+	waitForCallers(t, g, "01310100", 2)
+	close(release)
+	<-done
+	leaderSpan.End()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, span := range recorder.Ended() {
+			if span.Name() != "test.coalesced" {
+				continue
+			}
+			links := span.Links()
+			if len(links) != 1 || links[0].SpanContext.SpanID() != leaderSpan.SpanContext().SpanID() {
+				t.Fatalf("links do span coalesced = %+v, expected o span do líder", links)
+			}
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("span test.coalesced não foi registrado")
+}
+
+// waitForCallers aguarda até que a chave tenha a quantidade de chamadores
+func waitForCallers[T any](t *testing.T, g *Group[T], key string, callers int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		c, ok := g.calls[key]
+		n := 0
+		if ok {
+			n = c.callers
+		}
+		g.mu.Unlock()
+
+		if n == callers {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("chave %s não atingiu %d chamadores", key, callers)
+}
@@ -2,21 +2,20 @@ package telemetry
 
 import (
 	"context"
-	"os"
+	"net/http"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/otel"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/exporters/zipkin"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
-func InitTracer(serviceName string) (func(), error) {
-	zipkinEndpoint := os.Getenv("ZIPKIN_ENDPOINT")
-	if zipkinEndpoint == "" {
-		zipkinEndpoint = "http://localhost:9411/api/v2/spans"
-	}
-
+func InitTracer(serviceName, zipkinEndpoint string) (func(), error) {
 	exporter, err := zipkin.New(zipkinEndpoint)
 	if err != nil {
 		return nil, err
@@ -44,4 +43,40 @@ func InitTracer(serviceName string) (func(), error) {
 			panic(err)
 		}
 	}, nil
-}
\ No newline at end of file
+}
+
+// InitMeter configura o MeterProvider global com exportação no formato
+// Prometheus e retorna o handler que expõe as métricas
+func InitMeter(serviceName string) (http.Handler, func(), error) {
+	registry := prometheus.NewRegistry()
+
+	exporter, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(
+			semconv.ServiceName(serviceName),
+			semconv.ServiceVersion("1.0.0"),
+		),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(exporter),
+		sdkmetric.WithResource(res),
+	)
+
+	otel.SetMeterProvider(mp)
+
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+
+	return handler, func() {
+		if err := mp.Shutdown(context.Background()); err != nil {
+			panic(err)
+		}
+	}, nil
+}
@@ -0,0 +1,4 @@
+// Package temperaturepb contém o contrato gRPC do Serviço B, gerado a partir
+// de servico-b/internal/temperaturepb/temperature.proto com `make proto` na
+// raiz de sistema-cep
+package temperaturepb
@@ -0,0 +1,117 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.1
+// source: temperature.proto
+
+package temperaturepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	TemperatureService_GetTemperatureByCEP_FullMethodName = "/temperature.v1.TemperatureService/GetTemperatureByCEP"
+)
+
+// TemperatureServiceClient is the client API for TemperatureService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TemperatureServiceClient interface {
+	// GetTemperatureByCEP retorna a temperatura da cidade do CEP. Erros usam os
+	// códigos INVALID_ARGUMENT (CEP com formato inválido), NOT_FOUND (CEP não
+	// encontrado), DEADLINE_EXCEEDED (prazo esgotado), UNAVAILABLE (servidor
+	// sobrecarregado) e INTERNAL (demais falhas)
+	GetTemperatureByCEP(ctx context.Context, in *GetTemperatureByCEPRequest, opts ...grpc.CallOption) (*GetTemperatureByCEPResponse, error)
+}
+
+type temperatureServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTemperatureServiceClient(cc grpc.ClientConnInterface) TemperatureServiceClient {
+	return &temperatureServiceClient{cc}
+}
+
+func (c *temperatureServiceClient) GetTemperatureByCEP(ctx context.Context, in *GetTemperatureByCEPRequest, opts ...grpc.CallOption) (*GetTemperatureByCEPResponse, error) {
+	out := new(GetTemperatureByCEPResponse)
+	err := c.cc.Invoke(ctx, TemperatureService_GetTemperatureByCEP_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TemperatureServiceServer is the server API for TemperatureService service.
+// All implementations must embed UnimplementedTemperatureServiceServer
+// for forward compatibility
+type TemperatureServiceServer interface {
+	// GetTemperatureByCEP retorna a temperatura da cidade do CEP. Erros usam os
+	// códigos INVALID_ARGUMENT (CEP com formato inválido), NOT_FOUND (CEP não
+	// encontrado), DEADLINE_EXCEEDED (prazo esgotado), UNAVAILABLE (servidor
+	// sobrecarregado) e INTERNAL (demais falhas)
+	GetTemperatureByCEP(context.Context, *GetTemperatureByCEPRequest) (*GetTemperatureByCEPResponse, error)
+	mustEmbedUnimplementedTemperatureServiceServer()
+}
+
+// UnimplementedTemperatureServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedTemperatureServiceServer struct {
+}
+
+func (UnimplementedTemperatureServiceServer) GetTemperatureByCEP(context.Context, *GetTemperatureByCEPRequest) (*GetTemperatureByCEPResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTemperatureByCEP not implemented")
+}
+func (UnimplementedTemperatureServiceServer) mustEmbedUnimplementedTemperatureServiceServer() {}
+
+// UnsafeTemperatureServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TemperatureServiceServer will
+// result in compilation errors.
+type UnsafeTemperatureServiceServer interface {
+	mustEmbedUnimplementedTemperatureServiceServer()
+}
+
+func RegisterTemperatureServiceServer(s grpc.ServiceRegistrar, srv TemperatureServiceServer) {
+	s.RegisterService(&TemperatureService_ServiceDesc, srv)
+}
+
+func _TemperatureService_GetTemperatureByCEP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTemperatureByCEPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServiceServer).GetTemperatureByCEP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TemperatureService_GetTemperatureByCEP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServiceServer).GetTemperatureByCEP(ctx, req.(*GetTemperatureByCEPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TemperatureService_ServiceDesc is the grpc.ServiceDesc for TemperatureService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TemperatureService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "temperature.v1.TemperatureService",
+	HandlerType: (*TemperatureServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetTemperatureByCEP",
+			Handler:    _TemperatureService_GetTemperatureByCEP_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "temperature.proto",
+}
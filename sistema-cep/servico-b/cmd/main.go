@@ -1,44 +1,200 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"flag"
 	"log"
+	"net"
+	"os"
 
+	"servico-b/internal/admin"
 	"servico-b/internal/config"
 	"servico-b/internal/handlers"
+	"servico-b/internal/health"
+	"servico-b/internal/logging"
+	"servico-b/internal/mtls"
+	"servico-b/internal/redact"
+	"servico-b/internal/retry"
+	"servico-b/internal/selftest"
 	"servico-b/internal/server"
 	"servico-b/internal/services"
 	"servico-b/internal/telemetry"
 )
 
 func main() {
-	// Carrega configuração
-	cfg := config.LoadConfig()
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "arquivo YAML de configuração (as variáveis de ambiente têm precedência)")
+	printConfig := flag.Bool("print-config", false, "exibe a configuração efetiva, com credenciais ocultadas, e encerra")
+	check := flag.Bool("check", false, "verifica a conectividade com a ViaCEP e a WeatherAPI e a chave da WeatherAPI, e encerra com status 1 se falhar")
+	flag.Parse()
+
+	// Segredos registrados em redact nunca chegam à saída do log
+	log.SetOutput(redact.Writer(os.Stderr))
+
+	// Carrega e valida configuração
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Configuração inválida:\n%v", err)
+	}
+	redact.Add(cfg.WeatherAPIKey)
+	redact.Add(cfg.Admin.Token)
+
+	if *printConfig {
+		if err := cfg.WriteRedacted(os.Stdout); err != nil {
+			log.Fatal("Erro ao exibir configuração: ", err)
+		}
+		return
+	}
+
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Fatal("Erro ao configurar o log: ", err)
+	}
+	logging.SetLevel(level)
+
+	// Os clientes das APIs externas são criados antes da telemetria para
+	// que --check não exporte spans; a instrumentação passa a usar os
+	// providers globais assim que são registrados
+	retryPolicy := retry.Policy{
+		MaxAttempts:     cfg.Retry.MaxAttempts,
+		InitialBackoff:  cfg.Retry.InitialBackoff,
+		MaxBackoff:      cfg.Retry.MaxBackoff,
+		BudgetRatio:     cfg.Retry.BudgetRatio,
+		BudgetMaxTokens: cfg.Retry.BudgetMaxTokens,
+	}
+	viaCEPService := services.NewViaCEPService(cfg.ViaCEPURL, retryPolicy)
+	weatherService := services.NewWeatherService(cfg.WeatherAPIURL, cfg.WeatherAPIKey, retryPolicy)
+
+	if *check {
+		report := runSelfTest(cfg, viaCEPService, weatherService)
+		report.Write(os.Stdout)
+		if !report.OK() {
+			os.Exit(1)
+		}
+		return
+	}
 
 	// Inicializa telemetria
-	shutdown, err := telemetry.InitTracer("servico-b")
+	shutdown, err := telemetry.InitTracer("servico-b", cfg.ZipkinEndpoint)
 	if err != nil {
 		log.Fatal("Erro ao inicializar telemetria: ", err)
 	}
 	defer shutdown()
 
-	// Valida configuração crítica
-	if cfg.WeatherAPIKey == "" {
-		log.Fatal("WEATHER_API_KEY é obrigatória. Obtenha uma chave em https://www.weatherapi.com/")
+	metricsHandler, shutdownMeter, err := telemetry.InitMeter("servico-b")
+	if err != nil {
+		log.Fatal("Erro ao inicializar métricas: ", err)
 	}
+	defer shutdownMeter()
 
 	// Inicializa serviços
-	viaCEPService := services.NewViaCEPService()
-	weatherService := services.NewWeatherService(cfg.WeatherAPIKey)
 	temperatureService := services.NewTemperatureService(viaCEPService, weatherService)
 
+	// Verifica a conectividade com a ViaCEP e a WeatherAPI e a chave antes
+	// de aceitar requisições
+	if cfg.StartupCheck.Mode != "off" {
+		if err := runSelfTest(cfg, viaCEPService, weatherService).Err(); err != nil {
+			if cfg.StartupCheck.Mode == "strict" {
+				log.Fatalf("Verificação de inicialização falhou, o serviço não será iniciado:\n%v", err)
+			}
+			logging.Warnf(context.Background(), "Verificação de inicialização falhou, o serviço inicia degradado:\n%v", err)
+		} else {
+			log.Printf("Verificação de inicialização concluída: ViaCEP e WeatherAPI disponíveis")
+		}
+	}
+
 	// Inicializa handlers
 	temperatureHandler := handlers.NewTemperatureHandler(temperatureService)
+	temperatureGRPC := handlers.NewTemperatureGRPCHandler(temperatureService)
+
+	// Com mTLS, o certificado e as CAs dos clientes são relidos quando os
+	// arquivos mudam
+	var tlsReloader *mtls.Reloader
+	var tlsConfig *tls.Config
+	if cfg.TLS.Enabled {
+		tlsReloader, err = mtls.NewReloader(mtls.Settings{
+			CertFile: cfg.TLS.CertFile,
+			KeyFile:  cfg.TLS.KeyFile,
+			CAFile:   cfg.TLS.ClientCAFile,
+		})
+		if err != nil {
+			log.Fatal("Erro ao carregar os certificados TLS: ", err)
+		}
+		tlsConfig = tlsReloader.ServerConfig()
+	}
+
+	// O serviço só está pronto com a ViaCEP respondendo e a chave da
+	// WeatherAPI aceita. A chave é verificada com menos frequência porque
+	// cada verificação consome a cota
+	checker := health.New("servico-b", health.Settings{
+		CacheTTL: cfg.Readiness.CacheTTL,
+		Timeout:  cfg.Readiness.Timeout,
+	},
+		health.Check{Name: "viacep", Run: viaCEPService.Ping},
+		health.Check{Name: "weatherapi", TTL: cfg.Readiness.WeatherAPIKeyTTL, Run: weatherService.CheckAPIKey},
+	)
+
+	// Com o admin habilitado, /metrics sai da porta pública
+	publicMetrics := metricsHandler
+	if cfg.Admin.Enabled {
+		publicMetrics = nil
+	}
 
 	// Inicializa servidor
-	srv := server.NewServer(cfg.Port, temperatureHandler)
+	srv := server.NewServer(cfg, temperatureHandler, temperatureGRPC, publicMetrics, checker, tlsConfig)
 
-	log.Printf("Serviço B iniciado na porta %s com tracing habilitado", cfg.Port)
-	log.Printf("Usando WeatherAPI com chave: %s...", cfg.WeatherAPIKey[:min(len(cfg.WeatherAPIKey), 8)])
+	// Recarrega a configuração no SIGHUP ou quando o arquivo muda. A única
+	// etapa que pode falhar vem antes das demais para que uma recarga
+	// rejeitada não altere nenhum componente
+	var adminServer *admin.Server
+	watcher := config.NewWatcher(*configPath, cfg, func(next *config.Config) error {
+		level, err := logging.ParseLevel(next.LogLevel)
+		if err != nil {
+			return err
+		}
+		logging.SetLevel(level)
+		weatherService.SetAPIKey(next.WeatherAPIKey)
+		redact.Add(next.Admin.Token)
+		if adminServer != nil {
+			adminServer.SetToken(next.Admin.Token)
+		}
+		viaCEPService.SetBaseURL(next.ViaCEPURL)
+		weatherService.SetBaseURL(next.WeatherAPIURL)
+		srv.Reload(next)
+		return nil
+	})
+	// O servidor administrativo reúne as métricas, o pprof e os endpoints
+	// registrados por cada subsistema
+	if cfg.Admin.Enabled {
+		registry := admin.NewRegistry()
+		registry.Handle("GET /metrics", "Métricas no formato Prometheus", metricsHandler)
+		admin.RegisterLogLevel(registry)
+		if cfg.Admin.Pprof {
+			admin.RegisterPprof(registry)
+		}
+		watcher.RegisterAdmin(registry)
+		srv.RegisterAdmin(registry)
+
+		adminAddr := net.JoinHostPort(cfg.Admin.BindAddress, cfg.Admin.Port)
+		adminServer = admin.NewServer(adminAddr, cfg.Admin.Token, registry)
+		go func() {
+			if err := adminServer.Start(); err != nil {
+				log.Fatal("Erro ao iniciar o servidor administrativo: ", err)
+			}
+		}()
+		log.Printf("Admin disponível em %s", adminAddr)
+	}
+
+	// O watcher só começa depois que o servidor administrativo existe, já
+	// que as recargas atualizam o seu token
+	watchCtx, stopWatcher := context.WithCancel(context.Background())
+	defer stopWatcher()
+	go watcher.Run(watchCtx)
+	if tlsReloader != nil {
+		go tlsReloader.Run(watchCtx, cfg.TLS.ReloadInterval)
+	}
+
+	log.Printf("Serviço B iniciado na porta %s (gRPC na porta %s) com tracing habilitado", cfg.Port, cfg.GRPCPort)
 
 	// Inicia o servidor
 	if err := srv.Start(); err != nil {
@@ -46,9 +202,11 @@ func main() {
 	}
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+// runSelfTest consulta o CEP de STARTUP_CHECK_CEP na ViaCEP e a cidade
+// encontrada na WeatherAPI, limitado a STARTUP_CHECK_TIMEOUT
+func runSelfTest(cfg *config.Config, viaCEPService *services.ViaCEPService, weatherService *services.WeatherService) *selftest.Report {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.StartupCheck.Timeout)
+	defer cancel()
+
+	return selftest.Run(ctx, viaCEPService, weatherService, cfg.StartupCheck.CEP)
 }
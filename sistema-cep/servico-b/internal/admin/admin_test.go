@@ -0,0 +1,191 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"servico-b/internal/logging"
+)
+
+func TestServerAuthentication(t *testing.T) {
+	registry := NewRegistry()
+	registry.HandleFunc("POST /cache/flush", "Esvazia o cache", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, map[string]int{"flushed": 3})
+	})
+	srv := NewServer("127.0.0.1:0", "token-de-teste", registry)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			name:           "Sem token",
+			method:         http.MethodGet,
+			path:           "/",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Token inválido",
+			method:         http.MethodPost,
+			path:           "/cache/flush",
+			authorization:  "Bearer outro-token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Esquema diferente de Bearer",
+			method:         http.MethodGet,
+			path:           "/",
+			authorization:  "Basic token-de-teste",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Token válido",
+			method:         http.MethodPost,
+			path:           "/cache/flush",
+			authorization:  "Bearer token-de-teste",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Método não registrado",
+			method:         http.MethodGet,
+			path:           "/cache/flush",
+			authorization:  "Bearer token-de-teste",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			srv.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("resposta 401 sem o header WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestServerSetToken(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", "token-antigo", NewRegistry())
+	srv.SetToken("token-novo")
+
+	for token, expectedStatus := range map[string]int{
+		"token-antigo": http.StatusUnauthorized,
+		"token-novo":   http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+
+		srv.Handler().ServeHTTP(rec, req)
+
+		if rec.Code != expectedStatus {
+			t.Errorf("%s: status = %d, expected %d", token, rec.Code, expectedStatus)
+		}
+	}
+}
+
+func TestRegistryIndex(t *testing.T) {
+	registry := NewRegistry()
+	registry.HandleFunc("GET /cache", "Estado do cache", func(w http.ResponseWriter, r *http.Request) {})
+	RegisterLogLevel(registry)
+
+	rec := httptest.NewRecorder()
+	registry.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, expected 200", rec.Code)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, expected no-store", got)
+	}
+
+	var body struct {
+		Endpoints []Endpoint `json:"endpoints"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Decode() err = %v", err)
+	}
+	var patterns []string
+	for _, endpoint := range body.Endpoints {
+		patterns = append(patterns, endpoint.Pattern)
+	}
+	expected := "GET /cache,GET /log-level,PUT /log-level"
+	if got := strings.Join(patterns, ","); got != expected {
+		t.Errorf("endpoints = %s, expected %s", got, expected)
+	}
+}
+
+func TestRegisterPprof(t *testing.T) {
+	registry := NewRegistry()
+	RegisterPprof(registry)
+
+	rec := httptest.NewRecorder()
+	registry.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/cmdline", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, expected 200", rec.Code)
+	}
+}
+
+func TestRegisterLogLevel(t *testing.T) {
+	defer logging.SetLevel(logging.CurrentLevel())
+
+	registry := NewRegistry()
+	RegisterLogLevel(registry)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedLevel  logging.Level
+	}{
+		{
+			name:           "Nível válido",
+			body:           `{"level":"debug"}`,
+			expectedStatus: http.StatusOK,
+			expectedLevel:  logging.LevelDebug,
+		},
+		{
+			name:           "Nível inválido",
+			body:           `{"level":"verbose"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedLevel:  logging.LevelDebug,
+		},
+		{
+			name:           "Corpo inválido",
+			body:           `level=warn`,
+			expectedStatus: http.StatusBadRequest,
+			expectedLevel:  logging.LevelDebug,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			registry.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/log-level", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if got := logging.CurrentLevel(); got != tt.expectedLevel {
+				t.Errorf("CurrentLevel() = %v, expected %v", got, tt.expectedLevel)
+			}
+		})
+	}
+}
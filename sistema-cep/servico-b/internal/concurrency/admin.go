@@ -0,0 +1,19 @@
+package concurrency
+
+import (
+	"net/http"
+
+	"servico-b/internal/admin"
+)
+
+// RegisterAdmin registra a consulta do limite adaptativo atual
+func (l *Limiter) RegisterAdmin(r *admin.Registry) {
+	r.HandleFunc("GET /concurrency", "Limite de concorrência atual e requisições em andamento", func(w http.ResponseWriter, req *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, map[string]int{
+			"limit":     l.Limit(),
+			"in_flight": l.InFlight(),
+			"min_limit": l.settings.MinLimit,
+			"max_limit": l.settings.MaxLimit,
+		})
+	})
+}
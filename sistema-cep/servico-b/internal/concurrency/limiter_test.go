@@ -0,0 +1,95 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterRejectsAboveLimit(t *testing.T) {
+	l := NewLimiter(Settings{InitialLimit: 2, MinLimit: 1, MaxLimit: 10, LatencyThreshold: time.Second})
+
+	release1, ok1 := l.Acquire()
+	_, ok2 := l.Acquire()
+	_, ok3 := l.Acquire()
+	if !ok1 || !ok2 || ok3 {
+		t.Fatalf("Acquire() = %v, %v, %v; expected true, true, false", ok1, ok2, ok3)
+	}
+
+	release1(time.Millisecond, false)
+	if _, ok := l.Acquire(); !ok {
+		t.Error("Acquire() rejeitado após liberar uma vaga")
+	}
+}
+
+func TestLimiterAdjustsLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		latency  time.Duration
+		failed   bool
+		requests int
+		check    func(limit int) bool
+		expected string
+	}{
+		{
+			name:     "Respostas rápidas aumentam o limite",
+			latency:  10 * time.Millisecond,
+			requests: 50,
+			check:    func(limit int) bool { return limit > 10 },
+			expected: "maior que 10",
+		},
+		{
+			name:     "Respostas lentas reduzem o limite",
+			latency:  2 * time.Second,
+			requests: 5,
+			check:    func(limit int) bool { return limit < 10 },
+			expected: "menor que 10",
+		},
+		{
+			name:     "Falhas reduzem o limite até o mínimo",
+			latency:  10 * time.Millisecond,
+			failed:   true,
+			requests: 100,
+			check:    func(limit int) bool { return limit == 2 },
+			expected: "igual ao mínimo 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(Settings{InitialLimit: 10, MinLimit: 2, MaxLimit: 20, LatencyThreshold: time.Second, BackoffRatio: 0.9})
+
+			for i := 0; i < tt.requests; i++ {
+				// Mantém o limite ocupado para que o aumento seja permitido
+				var releases []func(time.Duration, bool)
+				for j := 0; j < l.Limit(); j++ {
+					release, ok := l.Acquire()
+					if !ok {
+						break
+					}
+					releases = append(releases, release)
+				}
+				for _, release := range releases {
+					release(tt.latency, tt.failed)
+				}
+			}
+
+			if limit := l.Limit(); !tt.check(limit) {
+				t.Errorf("Limit() = %d, expected %s", limit, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLimiterDoesNotGrowWhenIdle(t *testing.T) {
+	l := NewLimiter(Settings{InitialLimit: 10, MinLimit: 1, MaxLimit: 100, LatencyThreshold: time.Second})
+
+	// Uma requisição por vez não justifica aumentar o limite
+	for i := 0; i < 100; i++ {
+		release, _ := l.Acquire()
+		release(time.Millisecond, false)
+	}
+
+	if limit := l.Limit(); limit != 10 {
+		t.Errorf("Limit() = %d, expected 10", limit)
+	}
+}
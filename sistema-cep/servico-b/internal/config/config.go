@@ -1,29 +1,356 @@
 package config
 
-import "os"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
 
-// Config representa a configuração da aplicação
+	"servico-b/internal/redact"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config representa a configuração da aplicação. Os valores padrão são
+// sobrescritos pelo arquivo YAML, se informado, e depois pelas variáveis de
+// ambiente
 type Config struct {
-	Port           string
-	WeatherAPIKey  string
-	ViaCEPURL      string
-	WeatherAPIURL  string
+	Port           string        `yaml:"port"`
+	GRPCPort       string        `yaml:"grpc_port"`
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	WeatherAPIKey  string        `yaml:"weather_api_key"`
+	// WeatherAPIKeyFile é o arquivo com a chave da WeatherAPI, alternativa
+	// a WeatherAPIKey. O arquivo é relido quando muda, permitindo a rotação
+	// da chave sem reiniciar o serviço
+	WeatherAPIKeyFile string `yaml:"weather_api_key_file"`
+	ViaCEPURL         string `yaml:"viacep_url"`
+	WeatherAPIURL     string `yaml:"weather_api_url"`
+	ZipkinEndpoint    string `yaml:"zipkin_endpoint"`
+	LogLevel          string `yaml:"log_level"`
+	// ConfigWatchInterval é o intervalo de verificação de mudanças no
+	// arquivo de configuração. Zero recarrega apenas no SIGHUP
+	ConfigWatchInterval time.Duration          `yaml:"config_watch_interval"`
+	TLS                 TLSConfig              `yaml:"tls"`
+	Readiness           ReadinessConfig        `yaml:"readiness"`
+	StartupCheck        StartupCheckConfig     `yaml:"startup_check"`
+	Admin               AdminConfig            `yaml:"admin"`
+	Concurrency         ConcurrencyLimitConfig `yaml:"concurrency"`
+	CORS                CORSConfig             `yaml:"cors"`
+	Retry               RetryConfig            `yaml:"retry"`
+}
+
+// TLSConfig representa o mTLS nas portas HTTP e gRPC: o certificado do
+// Serviço B e as CAs aceitas para o certificado exigido dos clientes. Os
+// arquivos são relidos quando mudam, verificados a cada ReloadInterval; zero
+// desabilita a recarga. Com TLS, as sondas também são servidas sem TLS em
+// ProbePort, para quem não apresenta certificado, como o kubelet
+type TLSConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	CertFile       string        `yaml:"cert_file"`
+	KeyFile        string        `yaml:"key_file"`
+	ClientCAFile   string        `yaml:"client_ca_file"`
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+	ProbePort      string        `yaml:"probe_port"`
+}
+
+// ReadinessConfig representa as verificações das dependências feitas pelo
+// /readyz. Os resultados são reaproveitados por CacheTTL e cada verificação
+// é limitada a Timeout. A validade da chave da WeatherAPI é verificada a
+// cada WeatherAPIKeyTTL, pois cada verificação consome a cota da chave
+type ReadinessConfig struct {
+	CacheTTL         time.Duration `yaml:"cache_ttl"`
+	Timeout          time.Duration `yaml:"timeout"`
+	WeatherAPIKeyTTL time.Duration `yaml:"weather_api_key_ttl"`
+}
+
+// StartupCheckConfig representa a verificação feita na inicialização:
+// consulta CEP na ViaCEP e a temperatura da cidade na WeatherAPI. Com Mode
+// "strict" o serviço não inicia se a verificação falhar; com "degraded" as
+// falhas são registradas e o serviço inicia mesmo assim; "off" desabilita
+// a verificação
+type StartupCheckConfig struct {
+	Mode    string        `yaml:"mode"`
+	CEP     string        `yaml:"cep"`
+	Timeout time.Duration `yaml:"timeout"`
 }
 
-// LoadConfig carrega as configurações a partir das variáveis de ambiente
-func LoadConfig() *Config {
+// AdminConfig representa o servidor administrativo, em uma porta separada
+// das públicas, com /metrics, pprof, a configuração em uso e os endpoints
+// dos subsistemas. Todas as requisições exigem o token no header
+// Authorization: Bearer; com TokenFile o token é relido quando o arquivo muda
+type AdminConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	BindAddress string `yaml:"bind_address"`
+	Port        string `yaml:"port"`
+	Token       string `yaml:"token"`
+	TokenFile   string `yaml:"token_file"`
+	Pprof       bool   `yaml:"pprof"`
+}
+
+// ConcurrencyLimitConfig representa o limite adaptativo de requisições
+// simultâneas. O limite cresce enquanto as respostas são rápidas e é reduzido
+// quando a latência passa de LatencyThreshold ou o prazo se esgota
+type ConcurrencyLimitConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	InitialLimit     int           `yaml:"initial_limit"`
+	MinLimit         int           `yaml:"min_limit"`
+	MaxLimit         int           `yaml:"max_limit"`
+	LatencyThreshold time.Duration `yaml:"latency_threshold"`
+	BackoffRatio     float64       `yaml:"backoff_ratio"`
+}
+
+// CORSConfig representa a política CORS aplicada às rotas do serviço. O
+// Serviço B é interno, portanto por padrão nenhuma origem é permitida
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+// RetryConfig representa a política de retentativas das chamadas à ViaCEP
+// e à WeatherAPI. Cada API externa tem o seu próprio orçamento
+type RetryConfig struct {
+	MaxAttempts     int           `yaml:"max_attempts"`
+	InitialBackoff  time.Duration `yaml:"initial_backoff"`
+	MaxBackoff      time.Duration `yaml:"max_backoff"`
+	BudgetRatio     float64       `yaml:"budget_ratio"`
+	BudgetMaxTokens float64       `yaml:"budget_max_tokens"`
+}
+
+// Default retorna a configuração com os valores padrão
+func Default() *Config {
 	return &Config{
-		Port:           getEnv("PORT", "8081"),
-		WeatherAPIKey:  getEnv("WEATHER_API_KEY", ""),
-		ViaCEPURL:      getEnv("VIACEP_URL", "https://viacep.com.br/ws"),
-		WeatherAPIURL:  getEnv("WEATHER_API_URL", "http://api.weatherapi.com/v1"),
+		Port:                "8081",
+		GRPCPort:            "9081",
+		RequestTimeout:      10 * time.Second,
+		ViaCEPURL:           "https://viacep.com.br/ws",
+		WeatherAPIURL:       "http://api.weatherapi.com/v1",
+		ZipkinEndpoint:      "http://localhost:9411/api/v2/spans",
+		LogLevel:            "info",
+		ConfigWatchInterval: 5 * time.Second,
+		TLS: TLSConfig{
+			Enabled:        false,
+			ReloadInterval: 30 * time.Second,
+			ProbePort:      "8082",
+		},
+		Readiness: ReadinessConfig{
+			CacheTTL:         10 * time.Second,
+			Timeout:          5 * time.Second,
+			WeatherAPIKeyTTL: 5 * time.Minute,
+		},
+		StartupCheck: StartupCheckConfig{
+			Mode:    "degraded",
+			CEP:     "01001000",
+			Timeout: 15 * time.Second,
+		},
+		Admin: AdminConfig{
+			Enabled:     false,
+			BindAddress: "127.0.0.1",
+			Port:        "9091",
+			Pprof:       true,
+		},
+		Concurrency: ConcurrencyLimitConfig{
+			Enabled:          true,
+			InitialLimit:     20,
+			MinLimit:         5,
+			MaxLimit:         200,
+			LatencyThreshold: 2 * time.Second,
+			BackoffRatio:     0.9,
+		},
+		CORS: CORSConfig{
+			AllowedMethods:   []string{"POST"},
+			AllowedHeaders:   []string{"Content-Type", "Accept", "Accept-Language", "X-Request-ID"},
+			ExposedHeaders:   []string{"X-Trace-Id", "X-Request-ID"},
+			AllowCredentials: false,
+			MaxAge:           10 * time.Minute,
+		},
+		Retry: RetryConfig{
+			MaxAttempts:     3,
+			InitialBackoff:  100 * time.Millisecond,
+			MaxBackoff:      2 * time.Second,
+			BudgetRatio:     0.2,
+			BudgetMaxTokens: 10,
+		},
+	}
+}
+
+// Load carrega a configuração a partir dos valores padrão, do arquivo YAML
+// em path (opcional) e das variáveis de ambiente, nessa ordem de
+// precedência crescente. Todos os erros de leitura e de validação são
+// retornados juntos
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	var errs []error
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	env := &envLoader{}
+	cfg.applyEnv(env)
+	errs = append(errs, env.errs...)
+	if err := cfg.loadSecrets(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return cfg, nil
+}
+
+// loadFile sobrescreve a configuração com os campos presentes no arquivo.
+// Campos desconhecidos são rejeitados para que erros de digitação não passem
+// despercebidos
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("erro ao ler o arquivo de configuração: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("arquivo de configuração %s inválido: %w", path, err)
+	}
+	return nil
+}
+
+// loadSecrets lê os segredos informados por arquivo
+func (c *Config) loadSecrets() error {
+	return errors.Join(
+		readSecret("WEATHER_API_KEY", c.WeatherAPIKeyFile, &c.WeatherAPIKey),
+		readSecret("ADMIN_TOKEN", c.Admin.TokenFile, &c.Admin.Token),
+	)
+}
+
+// readSecret lê o segredo field do arquivo informado em field_FILE. O
+// conteúdo é usado sem os espaços e quebras de linha das extremidades
+func readSecret(field, file string, value *string) error {
+	if file == "" {
+		return nil
+	}
+	if *value != "" {
+		return fmt.Errorf("%s_FILE: não pode ser usado junto com %s", field, field)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("%s_FILE: erro ao ler o arquivo: %w", field, err)
+	}
+	*value = strings.TrimSpace(string(data))
+	if *value == "" {
+		return fmt.Errorf("%s_FILE: o arquivo %s está vazio", field, file)
+	}
+	return nil
+}
+
+// secretFiles retorna os arquivos de segredos configurados
+func (c *Config) secretFiles() []string {
+	var files []string
+	if c.WeatherAPIKeyFile != "" {
+		files = append(files, c.WeatherAPIKeyFile)
+	}
+	if c.Admin.TokenFile != "" {
+		files = append(files, c.Admin.TokenFile)
+	}
+	return files
+}
+
+// applyEnv sobrescreve a configuração com as variáveis de ambiente definidas
+func (c *Config) applyEnv(env *envLoader) {
+	env.string("PORT", &c.Port)
+	env.string("GRPC_PORT", &c.GRPCPort)
+	env.bool("TLS_ENABLED", &c.TLS.Enabled)
+	env.string("TLS_CERT_FILE", &c.TLS.CertFile)
+	env.string("TLS_KEY_FILE", &c.TLS.KeyFile)
+	env.string("TLS_CLIENT_CA_FILE", &c.TLS.ClientCAFile)
+	env.duration("TLS_RELOAD_INTERVAL", &c.TLS.ReloadInterval)
+	env.string("TLS_PROBE_PORT", &c.TLS.ProbePort)
+	env.duration("REQUEST_TIMEOUT", &c.RequestTimeout)
+	env.string("WEATHER_API_KEY", &c.WeatherAPIKey)
+	env.string("WEATHER_API_KEY_FILE", &c.WeatherAPIKeyFile)
+	env.string("VIACEP_URL", &c.ViaCEPURL)
+	env.string("WEATHER_API_URL", &c.WeatherAPIURL)
+	env.string("ZIPKIN_ENDPOINT", &c.ZipkinEndpoint)
+	env.string("LOG_LEVEL", &c.LogLevel)
+	env.duration("CONFIG_WATCH_INTERVAL", &c.ConfigWatchInterval)
+
+	env.duration("READINESS_CACHE_TTL", &c.Readiness.CacheTTL)
+	env.duration("READINESS_TIMEOUT", &c.Readiness.Timeout)
+	env.duration("READINESS_WEATHER_API_KEY_TTL", &c.Readiness.WeatherAPIKeyTTL)
+
+	env.string("STARTUP_CHECK_MODE", &c.StartupCheck.Mode)
+	env.string("STARTUP_CHECK_CEP", &c.StartupCheck.CEP)
+	env.duration("STARTUP_CHECK_TIMEOUT", &c.StartupCheck.Timeout)
+
+	env.bool("ADMIN_ENABLED", &c.Admin.Enabled)
+	env.string("ADMIN_BIND_ADDRESS", &c.Admin.BindAddress)
+	env.string("ADMIN_PORT", &c.Admin.Port)
+	env.string("ADMIN_TOKEN", &c.Admin.Token)
+	env.string("ADMIN_TOKEN_FILE", &c.Admin.TokenFile)
+	env.bool("ADMIN_PPROF_ENABLED", &c.Admin.Pprof)
+
+	env.bool("CONCURRENCY_LIMIT_ENABLED", &c.Concurrency.Enabled)
+	env.int("CONCURRENCY_LIMIT_INITIAL", &c.Concurrency.InitialLimit)
+	env.int("CONCURRENCY_LIMIT_MIN", &c.Concurrency.MinLimit)
+	env.int("CONCURRENCY_LIMIT_MAX", &c.Concurrency.MaxLimit)
+	env.duration("CONCURRENCY_LIMIT_LATENCY_THRESHOLD", &c.Concurrency.LatencyThreshold)
+	env.float("CONCURRENCY_LIMIT_BACKOFF_RATIO", &c.Concurrency.BackoffRatio)
+
+	env.list("CORS_ALLOWED_ORIGINS", &c.CORS.AllowedOrigins)
+	env.list("CORS_ALLOWED_METHODS", &c.CORS.AllowedMethods)
+	env.list("CORS_ALLOWED_HEADERS", &c.CORS.AllowedHeaders)
+	env.list("CORS_EXPOSED_HEADERS", &c.CORS.ExposedHeaders)
+	env.bool("CORS_ALLOW_CREDENTIALS", &c.CORS.AllowCredentials)
+	env.duration("CORS_MAX_AGE", &c.CORS.MaxAge)
+
+	env.int("RETRY_MAX_ATTEMPTS", &c.Retry.MaxAttempts)
+	env.duration("RETRY_INITIAL_BACKOFF", &c.Retry.InitialBackoff)
+	env.duration("RETRY_MAX_BACKOFF", &c.Retry.MaxBackoff)
+	env.float("RETRY_BUDGET_RATIO", &c.Retry.BudgetRatio)
+	env.float("RETRY_BUDGET_MAX_TOKENS", &c.Retry.BudgetMaxTokens)
+}
+
+// WriteRedacted escreve a configuração em YAML, com a chave da WeatherAPI, o
+// token do admin e credenciais em URLs ocultados
+func (c *Config) WriteRedacted(w io.Writer) error {
+	redactedConfig := *c
+	if c.WeatherAPIKey != "" {
+		redactedConfig.WeatherAPIKey = redact.Placeholder
+	}
+	if c.Admin.Token != "" {
+		redactedConfig.Admin.Token = redact.Placeholder
+	}
+	redactedConfig.ViaCEPURL = redactURL(c.ViaCEPURL)
+	redactedConfig.WeatherAPIURL = redactURL(c.WeatherAPIURL)
+	redactedConfig.ZipkinEndpoint = redactURL(c.ZipkinEndpoint)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&redactedConfig); err != nil {
+		return err
 	}
+	return enc.Close()
 }
 
-// getEnv retorna o valor da variável de ambiente ou um valor padrão
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+// redactURL oculta a senha de URLs com credenciais
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil {
 		return value
 	}
-	return defaultValue
+	return u.Redacted()
 }
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"servico-b/internal/validators"
+)
+
+// Validate verifica todos os campos e retorna os problemas encontrados
+// juntos. Os campos são identificados pelo nome da variável de ambiente
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.port("PORT", c.Port)
+	v.port("GRPC_PORT", c.GRPCPort)
+	v.check(c.GRPCPort != c.Port, "GRPC_PORT", "deve ser diferente de PORT")
+	v.positive("REQUEST_TIMEOUT", c.RequestTimeout)
+	v.tls(c.TLS, c.Port, c.GRPCPort, c.Admin.Port)
+	v.check(c.WeatherAPIKey != "" || c.WeatherAPIKeyFile != "", "WEATHER_API_KEY", "é obrigatória (ou WEATHER_API_KEY_FILE), obtenha uma chave em https://www.weatherapi.com/")
+	v.url("VIACEP_URL", c.ViaCEPURL)
+	v.url("WEATHER_API_URL", c.WeatherAPIURL)
+	v.url("ZIPKIN_ENDPOINT", c.ZipkinEndpoint)
+	v.oneOf("LOG_LEVEL", c.LogLevel, "debug", "info", "warn", "error")
+	v.nonNegative("CONFIG_WATCH_INTERVAL", c.ConfigWatchInterval)
+
+	v.positive("READINESS_CACHE_TTL", c.Readiness.CacheTTL)
+	v.positive("READINESS_TIMEOUT", c.Readiness.Timeout)
+	v.positive("READINESS_WEATHER_API_KEY_TTL", c.Readiness.WeatherAPIKeyTTL)
+
+	v.oneOf("STARTUP_CHECK_MODE", c.StartupCheck.Mode, "off", "degraded", "strict")
+	v.check(validators.ValidateCEP(c.StartupCheck.CEP), "STARTUP_CHECK_CEP", "CEP inválido %q, use 8 dígitos", c.StartupCheck.CEP)
+	v.positive("STARTUP_CHECK_TIMEOUT", c.StartupCheck.Timeout)
+	v.admin(c.Admin, c.Port, c.GRPCPort)
+
+	v.concurrency(c.Concurrency)
+	v.nonNegative("CORS_MAX_AGE", c.CORS.MaxAge)
+	v.retry(c.Retry)
+
+	return errors.Join(v.errs...)
+}
+
+// validator acumula os problemas encontrados na configuração
+type validator struct {
+	errs []error
+}
+
+// check registra o problema quando a condição não é atendida
+func (v *validator) check(ok bool, field, format string, args ...any) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+}
+
+// port verifica se o valor é uma porta TCP
+func (v *validator) port(field, value string) {
+	port, err := strconv.Atoi(value)
+	v.check(err == nil && port > 0 && port <= 65535, field, "porta inválida %q", value)
+}
+
+// url verifica se o valor é uma URL HTTP absoluta
+func (v *validator) url(field, value string) {
+	u, err := url.Parse(value)
+	v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", field, "URL HTTP inválida %q", redactURL(value))
+}
+
+// oneOf verifica se o valor é uma das opções
+func (v *validator) oneOf(field, value string, options ...string) {
+	for _, option := range options {
+		if value == option {
+			return
+		}
+	}
+	v.check(false, field, "valor %q inválido, use um de %v", value, options)
+}
+
+// positive verifica se a duração é maior que zero
+func (v *validator) positive(field string, d time.Duration) {
+	v.check(d > 0, field, "deve ser maior que zero")
+}
+
+// nonNegative verifica se a duração não é negativa
+func (v *validator) nonNegative(field string, d time.Duration) {
+	v.check(d >= 0, field, "não pode ser negativo")
+}
+
+// concurrency verifica os limites de concorrência
+func (v *validator) concurrency(c ConcurrencyLimitConfig) {
+	if !c.Enabled {
+		return
+	}
+	v.check(c.MinLimit >= 1, "CONCURRENCY_LIMIT_MIN", "deve ser ao menos 1")
+	v.check(c.MaxLimit >= c.MinLimit, "CONCURRENCY_LIMIT_MAX", "deve ser maior ou igual a CONCURRENCY_LIMIT_MIN")
+	v.check(c.InitialLimit >= c.MinLimit && c.InitialLimit <= c.MaxLimit, "CONCURRENCY_LIMIT_INITIAL", "deve estar entre CONCURRENCY_LIMIT_MIN e CONCURRENCY_LIMIT_MAX")
+	v.positive("CONCURRENCY_LIMIT_LATENCY_THRESHOLD", c.LatencyThreshold)
+	v.check(c.BackoffRatio > 0 && c.BackoffRatio < 1, "CONCURRENCY_LIMIT_BACKOFF_RATIO", "deve estar entre 0 e 1, sem incluí-los")
+}
+
+// tls verifica os arquivos do mTLS e a porta das sondas sem TLS
+func (v *validator) tls(c TLSConfig, otherPorts ...string) {
+	if !c.Enabled {
+		return
+	}
+	v.check(c.CertFile != "", "TLS_CERT_FILE", "é obrigatório com TLS_ENABLED")
+	v.check(c.KeyFile != "", "TLS_KEY_FILE", "é obrigatório com TLS_ENABLED")
+	v.check(c.ClientCAFile != "", "TLS_CLIENT_CA_FILE", "é obrigatório com TLS_ENABLED")
+	v.nonNegative("TLS_RELOAD_INTERVAL", c.ReloadInterval)
+	v.port("TLS_PROBE_PORT", c.ProbePort)
+	v.check(!slices.Contains(otherPorts, c.ProbePort), "TLS_PROBE_PORT", "deve ser diferente de PORT, GRPC_PORT e ADMIN_PORT")
+}
+
+// admin verifica o servidor administrativo
+func (v *validator) admin(c AdminConfig, publicPorts ...string) {
+	if !c.Enabled {
+		return
+	}
+	v.port("ADMIN_PORT", c.Port)
+	v.check(!slices.Contains(publicPorts, c.Port), "ADMIN_PORT", "deve ser diferente de PORT e GRPC_PORT")
+	v.check(c.BindAddress == "" || c.BindAddress == "localhost" || net.ParseIP(c.BindAddress) != nil, "ADMIN_BIND_ADDRESS", "endereço IP inválido %q", c.BindAddress)
+	v.check(c.Token != "" || c.TokenFile != "", "ADMIN_TOKEN", "é obrigatório com ADMIN_ENABLED (ou ADMIN_TOKEN_FILE)")
+}
+
+// retry verifica a política de retentativas
+func (v *validator) retry(c RetryConfig) {
+	v.check(c.MaxAttempts >= 1, "RETRY_MAX_ATTEMPTS", "deve ser ao menos 1")
+	v.nonNegative("RETRY_INITIAL_BACKOFF", c.InitialBackoff)
+	v.nonNegative("RETRY_MAX_BACKOFF", c.MaxBackoff)
+	v.check(c.BudgetRatio >= 0, "RETRY_BUDGET_RATIO", "não pode ser negativo")
+	v.check(c.BudgetMaxTokens >= 0, "RETRY_BUDGET_MAX_TOKENS", "não pode ser negativo")
+}
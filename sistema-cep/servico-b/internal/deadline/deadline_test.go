@@ -0,0 +1,58 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTransport(t *testing.T) {
+	var received string
+	calls := 0
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		received = req.Header.Get(Header)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+	transport := NewTransport(base)
+
+	// Sem prazo, o header não é enviado
+	req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() err = %v", err)
+	}
+	if received != "" {
+		t.Errorf("header = %q sem prazo", received)
+	}
+
+	// Com prazo, o tempo restante é enviado em milissegundos
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "http://example.invalid", nil)
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() err = %v", err)
+	}
+	remaining, ok := Parse(received)
+	if !ok || remaining <= time.Second || remaining > 2*time.Second {
+		t.Errorf("header = %q, expected cerca de 2000", received)
+	}
+
+	// Com o prazo esgotado, a chamada não é feita
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	req, _ = http.NewRequestWithContext(expired, http.MethodGet, "http://example.invalid", nil)
+	if _, err := transport.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RoundTrip() err = %v, expected context.DeadlineExceeded", err)
+	}
+	if calls != 2 {
+		t.Errorf("chamadas = %d, expected 2", calls)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"servico-b/internal/problem"
+)
+
+// maxRequestBodyBytes limita o tamanho do body aceito pelos handlers
+const maxRequestBodyBytes = 4096
+
+// requestError representa uma falha na leitura do body que deve ser
+// devolvida ao cliente com o status e a mensagem indicados
+type requestError struct {
+	status  int
+	code    problem.Code
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// decodeJSONBody lê o body da requisição em dst de forma estrita: exige
+// Content-Type JSON, limita o tamanho, rejeita campos desconhecidos e
+// qualquer conteúdo após o objeto JSON
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	// Requisições sem Content-Type também são rejeitadas
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return &requestError{
+			status:  http.StatusUnsupportedMediaType,
+			code:    problem.CodeUnsupportedMediaType,
+			message: "Content-Type header must be application/json",
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		var syntaxError *json.SyntaxError
+		var typeError *json.UnmarshalTypeError
+		var maxBytesError *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &syntaxError):
+			return &requestError{
+				status:  http.StatusBadRequest,
+				code:    problem.CodeInvalidRequestBody,
+				message: fmt.Sprintf("request body contains badly-formed JSON (at position %d)", syntaxError.Offset),
+			}
+
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return &requestError{
+				status:  http.StatusBadRequest,
+				code:    problem.CodeInvalidRequestBody,
+				message: "request body contains badly-formed JSON",
+			}
+
+		case errors.As(err, &typeError):
+			if typeError.Field == "" {
+				return &requestError{
+					status:  http.StatusBadRequest,
+					code:    problem.CodeInvalidRequestBody,
+					message: "request body must be a JSON object",
+				}
+			}
+			return &requestError{
+				status:  http.StatusUnprocessableEntity,
+				code:    problem.CodeInvalidRequestBody,
+				message: fmt.Sprintf("request body contains an invalid value for the %q field (expected %s)", typeError.Field, typeError.Type),
+			}
+
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+			return &requestError{
+				status:  http.StatusBadRequest,
+				code:    problem.CodeInvalidRequestBody,
+				message: fmt.Sprintf("request body contains unknown field %s", fieldName),
+			}
+
+		case errors.Is(err, io.EOF):
+			return &requestError{
+				status:  http.StatusBadRequest,
+				code:    problem.CodeInvalidRequestBody,
+				message: "request body must not be empty",
+			}
+
+		case errors.As(err, &maxBytesError):
+			return &requestError{
+				status:  http.StatusRequestEntityTooLarge,
+				code:    problem.CodePayloadTooLarge,
+				message: fmt.Sprintf("request body must not be larger than %d bytes", maxBytesError.Limit),
+			}
+
+		default:
+			return err
+		}
+	}
+
+	// Garante que o body contém um único objeto JSON
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return &requestError{
+				status:  http.StatusRequestEntityTooLarge,
+				code:    problem.CodePayloadTooLarge,
+				message: fmt.Sprintf("request body must not be larger than %d bytes", maxBytesError.Limit),
+			}
+		}
+		return &requestError{
+			status:  http.StatusBadRequest,
+			code:    problem.CodeInvalidRequestBody,
+			message: "request body must only contain a single JSON object",
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"servico-b/internal/logging"
+	"servico-b/internal/services"
+	"servico-b/internal/temperaturepb"
+	"servico-b/internal/validators"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TemperatureGRPCHandler implementa o TemperatureService gRPC com as mesmas
+// regras do POST /temperature
+type TemperatureGRPCHandler struct {
+	temperaturepb.UnimplementedTemperatureServiceServer
+	temperatureService *services.TemperatureService
+}
+
+// NewTemperatureGRPCHandler cria uma nova instância do handler gRPC de temperatura
+func NewTemperatureGRPCHandler(temperatureService *services.TemperatureService) *TemperatureGRPCHandler {
+	return &TemperatureGRPCHandler{
+		temperatureService: temperatureService,
+	}
+}
+
+// GetTemperatureByCEP busca a temperatura do CEP. Os erros usam os códigos
+// gRPC equivalentes aos status HTTP do POST /temperature: INVALID_ARGUMENT
+// (422), NOT_FOUND (404), DEADLINE_EXCEEDED (504) e INTERNAL (500)
+func (h *TemperatureGRPCHandler) GetTemperatureByCEP(ctx context.Context, req *temperaturepb.GetTemperatureByCEPRequest) (*temperaturepb.GetTemperatureByCEPResponse, error) {
+	tracer := otel.Tracer("servico-b")
+	ctx, span := tracer.Start(ctx, "HandleTemperatureGRPC")
+	defer span.End()
+
+	cep := req.GetCep()
+	span.SetAttributes(attribute.String("cep.received", cep))
+
+	// Valida o CEP
+	if !validators.ValidateCEP(cep) {
+		logging.Printf(ctx, "CEP inválido recebido via gRPC: %s", cep)
+		span.SetAttributes(attribute.Bool("cep.valid", false))
+		span.SetStatus(otelcodes.Error, "invalid zipcode")
+		return nil, status.Error(codes.InvalidArgument, "invalid zipcode")
+	}
+
+	span.SetAttributes(attribute.Bool("cep.valid", true))
+	logging.Debugf(ctx, "Processando CEP via gRPC: %s", cep)
+
+	// Busca temperatura pelo CEP
+	temperatureInfo, err := h.temperatureService.GetTemperatureByCEP(ctx, cep)
+	if err != nil {
+		logging.Errorf(ctx, "Erro ao buscar temperatura para CEP %s: %v", cep, err)
+
+		switch {
+		case isZipcodeNotFound(err):
+			span.SetStatus(otelcodes.Error, "zipcode not found")
+			return nil, status.Error(codes.NotFound, "can not find zipcode")
+
+		case errors.Is(err, context.DeadlineExceeded):
+			span.SetStatus(otelcodes.Error, "deadline exceeded")
+			return nil, status.Error(codes.DeadlineExceeded, "request deadline exceeded")
+
+		default:
+			span.SetStatus(otelcodes.Error, "internal server error")
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+
+	span.SetAttributes(
+		attribute.String("city.name", temperatureInfo.City),
+		attribute.Float64("temperature.celsius", temperatureInfo.TempC),
+	)
+
+	logging.Printf(ctx, "Resposta gRPC enviada para CEP %s: %s - %.1f°C",
+		cep, temperatureInfo.City, temperatureInfo.TempC)
+
+	return &temperaturepb.GetTemperatureByCEPResponse{
+		City:  temperatureInfo.City,
+		TempC: temperatureInfo.TempC,
+		TempF: temperatureInfo.TempF,
+		TempK: temperatureInfo.TempK,
+	}, nil
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"servico-b/internal/retry"
+	"servico-b/internal/services"
+	"servico-b/internal/temperaturepb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGetTemperatureByCEPGRPC(t *testing.T) {
+	viaCEPService := services.NewViaCEPService("https://viacep.com.br/ws", retry.Policy{})
+	weatherService := services.NewWeatherService("http://api.weatherapi.com/v1", "test-key", retry.Policy{})
+	client := newTestGRPCClient(t, NewTemperatureGRPCHandler(services.NewTemperatureService(viaCEPService, weatherService)))
+
+	tests := []struct {
+		name            string
+		cep             string
+		expectedCode    codes.Code
+		expectedMessage string
+	}{
+		{
+			name:            "CEP vazio",
+			cep:             "",
+			expectedCode:    codes.InvalidArgument,
+			expectedMessage: "invalid zipcode",
+		},
+		{
+			name:            "CEP com formato inválido",
+			cep:             "123",
+			expectedCode:    codes.InvalidArgument,
+			expectedMessage: "invalid zipcode",
+		},
+		{
+			name:            "CEP com letras",
+			cep:             "0131010a",
+			expectedCode:    codes.InvalidArgument,
+			expectedMessage: "invalid zipcode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetTemperatureByCEP(context.Background(), &temperaturepb.GetTemperatureByCEPRequest{Cep: tt.cep})
+
+			st := status.Convert(err)
+			if st.Code() != tt.expectedCode {
+				t.Fatalf("GetTemperatureByCEP() code = %s, expected %s (err: %v)", st.Code(), tt.expectedCode, err)
+			}
+			if st.Message() != tt.expectedMessage {
+				t.Errorf("GetTemperatureByCEP() message = %q, expected %q", st.Message(), tt.expectedMessage)
+			}
+		})
+	}
+}
+
+// newTestGRPCClient serve o handler em memória e retorna um cliente conectado
+func newTestGRPCClient(t *testing.T, handler *TemperatureGRPCHandler) temperaturepb.TemperatureServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	temperaturepb.RegisterTemperatureServiceServer(srv, handler)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() err = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return temperaturepb.NewTemperatureServiceClient(conn)
+}
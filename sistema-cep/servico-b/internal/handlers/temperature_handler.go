@@ -1,13 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"io"
-	"log"
+	"errors"
 	"net/http"
 	"strings"
 
+	"servico-b/internal/logging"
 	"servico-b/internal/models"
+	"servico-b/internal/problem"
 	"servico-b/internal/services"
 	"servico-b/internal/validators"
 	"go.opentelemetry.io/otel"
@@ -35,16 +37,6 @@ func (h *TemperatureHandler) HandleTemperature(w http.ResponseWriter, r *http.Re
 
 	// Configura headers
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	// Handle preflight requests
-	if r.Method == "OPTIONS" {
-		span.SetAttributes(attribute.String("http.method", r.Method))
-		w.WriteHeader(http.StatusOK)
-		return
-	}
 
 	// Apenas aceita método POST
 	if r.Method != http.MethodPost {
@@ -53,28 +45,26 @@ func (h *TemperatureHandler) HandleTemperature(w http.ResponseWriter, r *http.Re
 			attribute.String("error", "method not allowed"),
 		)
 		span.SetStatus(codes.Error, "method not allowed")
-		h.writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "method not allowed")
 		return
 	}
 
 	span.SetAttributes(attribute.String("http.method", r.Method))
 
-	// Lê o body da requisição
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Erro ao ler body da requisição: %v", err)
-		span.SetStatus(codes.Error, "failed to read request body")
-		h.writeErrorResponse(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-	defer r.Body.Close()
-
-	// Parse do JSON
+	// Lê e decodifica o body da requisição
 	var cepReq models.CEPRequest
-	if err := json.Unmarshal(body, &cepReq); err != nil {
-		log.Printf("Erro ao fazer parse do JSON: %v", err)
-		span.SetStatus(codes.Error, "invalid json format")
-		h.writeErrorResponse(w, http.StatusBadRequest, "invalid json format")
+	if err := decodeJSONBody(w, r, &cepReq); err != nil {
+		var reqErr *requestError
+		if errors.As(err, &reqErr) {
+			logging.Warnf(ctx, "Body da requisição rejeitado: %v", reqErr)
+			span.SetStatus(codes.Error, "invalid request body")
+			problem.Write(w, r, reqErr.status, reqErr.code, reqErr.message)
+			return
+		}
+
+		logging.Warnf(ctx, "Erro ao ler body da requisição: %v", err)
+		span.SetStatus(codes.Error, "failed to read request body")
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternalError, "internal server error")
 		return
 	}
 
@@ -82,31 +72,38 @@ func (h *TemperatureHandler) HandleTemperature(w http.ResponseWriter, r *http.Re
 
 	// Valida o CEP
 	if !validators.ValidateCEP(cepReq.CEP) {
-		log.Printf("CEP inválido recebido: %s", cepReq.CEP)
+		logging.Printf(ctx, "CEP inválido recebido: %s", cepReq.CEP)
 		span.SetAttributes(attribute.Bool("cep.valid", false))
 		span.SetStatus(codes.Error, "invalid zipcode")
-		h.writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid zipcode")
+		problem.Write(w, r, http.StatusUnprocessableEntity, problem.CodeInvalidZipcode, "invalid zipcode")
 		return
 	}
 
 	span.SetAttributes(attribute.Bool("cep.valid", true))
-	log.Printf("Processando CEP: %s", cepReq.CEP)
+	logging.Debugf(ctx, "Processando CEP: %s", cepReq.CEP)
 
 	// Busca temperatura pelo CEP
 	temperatureInfo, err := h.temperatureService.GetTemperatureByCEP(ctx, cepReq.CEP)
 	if err != nil {
-		log.Printf("Erro ao buscar temperatura para CEP %s: %v", cepReq.CEP, err)
+		logging.Errorf(ctx, "Erro ao buscar temperatura para CEP %s: %v", cepReq.CEP, err)
 
 		// Verifica se é erro de CEP não encontrado
-		if strings.Contains(err.Error(), "CEP não encontrado") {
+		if isZipcodeNotFound(err) {
 			span.SetStatus(codes.Error, "zipcode not found")
-			h.writeErrorResponse(w, http.StatusNotFound, "can not find zipcode")
+			problem.Write(w, r, http.StatusNotFound, problem.CodeZipcodeNotFound, "can not find zipcode")
+			return
+		}
+
+		// O prazo da requisição terminou antes das consultas externas
+		if errors.Is(err, context.DeadlineExceeded) {
+			span.SetStatus(codes.Error, "deadline exceeded")
+			problem.Write(w, r, http.StatusGatewayTimeout, problem.CodeDeadlineExceeded, "request deadline exceeded")
 			return
 		}
 
 		// Outros erros são considerados erro interno
 		span.SetStatus(codes.Error, "internal server error")
-		h.writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternalError, "internal server error")
 		return
 	}
 
@@ -125,15 +122,14 @@ func (h *TemperatureHandler) HandleTemperature(w http.ResponseWriter, r *http.Re
 		attribute.Float64("temperature.kelvin", response.TempK),
 	)
 
-	log.Printf("Resposta enviada para CEP %s: %s - %.1f°C",
+	logging.Printf(ctx, "Resposta enviada para CEP %s: %s - %.1f°C",
 		cepReq.CEP, response.City, response.TempC)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
 
-// writeErrorResponse escreve uma resposta de erro padronizada
-func (h *TemperatureHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(models.ErrorResponse{Message: message})
+// isZipcodeNotFound indica se a busca falhou porque a ViaCEP não conhece o CEP
+func isZipcodeNotFound(err error) bool {
+	return strings.Contains(err.Error(), "CEP não encontrado")
 }
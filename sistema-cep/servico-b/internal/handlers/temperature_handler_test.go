@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"servico-b/internal/models"
+	"servico-b/internal/retry"
+	"servico-b/internal/services"
+)
+
+func TestHandleTemperature(t *testing.T) {
+	viaCEPService := services.NewViaCEPService("https://viacep.com.br/ws", retry.Policy{})
+	weatherService := services.NewWeatherService("http://api.weatherapi.com/v1", "test-key", retry.Policy{})
+	handler := NewTemperatureHandler(services.NewTemperatureService(viaCEPService, weatherService))
+
+	tests := []struct {
+		name            string
+		method          string
+		contentType     string
+		body            string
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{
+			name:            "Método não permitido",
+			method:          http.MethodGet,
+			expectedStatus:  http.StatusMethodNotAllowed,
+			expectedMessage: "method not allowed",
+		},
+		{
+			name:            "Content-Type diferente de JSON",
+			method:          http.MethodPost,
+			contentType:     "text/plain",
+			body:            `{"cep": "01310100"}`,
+			expectedStatus:  http.StatusUnsupportedMediaType,
+			expectedMessage: "Content-Type header must be application/json",
+		},
+		{
+			name:            "Sem Content-Type",
+			method:          http.MethodPost,
+			body:            `{"cep": "01310100"}`,
+			expectedStatus:  http.StatusUnsupportedMediaType,
+			expectedMessage: "Content-Type header must be application/json",
+		},
+		{
+			name:            "Body vazio",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            ``,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "request body must not be empty",
+		},
+		{
+			name:            "JSON malformado",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": "01310100",}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "request body contains badly-formed JSON (at position 20)",
+		},
+		{
+			name:            "JSON incompleto",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": "01310100"`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "request body contains badly-formed JSON",
+		},
+		{
+			name:            "Body que não é um objeto",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `["01310100"]`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "request body must be a JSON object",
+		},
+		{
+			name:            "CEP numérico",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": 12345678}`,
+			expectedStatus:  http.StatusUnprocessableEntity,
+			expectedMessage: `request body contains an invalid value for the "cep" field (expected string)`,
+		},
+		{
+			name:            "Campo desconhecido",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": "01310100", "city": "São Paulo"}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: `request body contains unknown field "city"`,
+		},
+		{
+			name:            "Conteúdo após o objeto JSON",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": "01310100"}{"cep": "01310100"}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "request body must only contain a single JSON object",
+		},
+		{
+			name:            "Body maior que o limite",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": "` + strings.Repeat("1", maxRequestBodyBytes) + `"}`,
+			expectedStatus:  http.StatusRequestEntityTooLarge,
+			expectedMessage: "request body must not be larger than 4096 bytes",
+		},
+		{
+			name:            "CEP com formato inválido",
+			method:          http.MethodPost,
+			contentType:     "application/json",
+			body:            `{"cep": "123"}`,
+			expectedStatus:  http.StatusUnprocessableEntity,
+			expectedMessage: "invalid zipcode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/temperature", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.HandleTemperature(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("HandleTemperature() status = %d, expected %d (body: %s)", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+
+			if tt.expectedMessage == "" {
+				return
+			}
+
+			var errResp models.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil {
+				t.Fatalf("resposta de erro não é JSON válido: %v", err)
+			}
+			if errResp.Message != tt.expectedMessage {
+				t.Errorf("HandleTemperature() message = %q, expected %q", errResp.Message, tt.expectedMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"servico-b/internal/logging"
+	"servico-b/internal/problem"
+	"servico-b/internal/redact"
+)
+
+const (
+	// StatusPass indica que o serviço ou a dependência está disponível
+	StatusPass = "pass"
+	// StatusFail indica que o serviço ou a dependência está indisponível
+	StatusFail = "fail"
+)
+
+// Paths das sondas de liveness, readiness e startup
+const (
+	LivezPath    = "/livez"
+	ReadyzPath   = "/readyz"
+	StartupzPath = "/startupz"
+)
+
+// Check é a verificação de uma dependência. Run retorna nil quando a
+// dependência está disponível. O resultado é reaproveitado por TTL; zero usa
+// o CacheTTL do Checker
+type Check struct {
+	Name string
+	TTL  time.Duration
+	Run  func(ctx context.Context) error
+}
+
+// Settings define por quanto tempo os resultados das verificações são
+// reaproveitados e o tempo máximo de cada verificação
+type Settings struct {
+	CacheTTL time.Duration
+	Timeout  time.Duration
+}
+
+// Result é o resultado de uma verificação
+type Result struct {
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Report é o corpo das respostas das sondas
+type Report struct {
+	Status  string            `json:"status"`
+	Service string            `json:"service"`
+	Checks  map[string]Result `json:"checks,omitempty"`
+}
+
+// Checker responde às sondas de liveness, readiness e startup. A readiness
+// executa as verificações das dependências em paralelo, reaproveitando os
+// resultados recentes para que sondas frequentes não sobrecarreguem as
+// dependências
+type Checker struct {
+	service  string
+	settings Settings
+	checks   []*cachedCheck
+	now      func() time.Time
+	started  atomic.Bool
+}
+
+// cachedCheck guarda o último resultado de uma verificação. O mutex faz
+// sondas simultâneas aguardarem a mesma execução
+type cachedCheck struct {
+	Check
+
+	mu     sync.Mutex
+	result Result
+	valid  bool
+}
+
+// New cria o Checker do serviço com as verificações das dependências
+func New(service string, settings Settings, checks ...Check) *Checker {
+	c := &Checker{
+		service:  service,
+		settings: settings,
+		now:      time.Now,
+	}
+	for _, check := range checks {
+		if check.TTL <= 0 {
+			check.TTL = settings.CacheTTL
+		}
+		c.checks = append(c.checks, &cachedCheck{Check: check})
+	}
+	return c
+}
+
+// MarkStarted indica que a inicialização terminou. Até lá a sonda de
+// startup responde 503
+func (c *Checker) MarkStarted() {
+	c.started.Store(true)
+}
+
+// Started indica se a inicialização terminou
+func (c *Checker) Started() bool {
+	return c.started.Load()
+}
+
+// Ready executa as verificações e indica se todas passaram
+func (c *Checker) Ready(ctx context.Context) (bool, map[string]Result) {
+	results := make(map[string]Result, len(c.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check *cachedCheck) {
+			defer wg.Done()
+			result := c.run(ctx, check)
+
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	ready := true
+	for _, result := range results {
+		if result.Status != StatusPass {
+			ready = false
+		}
+	}
+	return ready, results
+}
+
+// run retorna o resultado em cache da verificação ou a executa. A execução
+// não é interrompida quando a sonda desiste, para que o resultado fique em
+// cache para as próximas
+func (c *Checker) run(ctx context.Context, check *cachedCheck) Result {
+	check.mu.Lock()
+	defer check.mu.Unlock()
+
+	now := c.now()
+	if check.valid && now.Sub(check.result.CheckedAt) < check.TTL {
+		return check.result
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	if c.settings.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.settings.Timeout)
+		defer cancel()
+	}
+
+	err := check.Run(ctx)
+	result := Result{
+		Status:     StatusPass,
+		DurationMS: c.now().Sub(now).Milliseconds(),
+		CheckedAt:  now,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		// O erro é exposto na resposta e não pode conter a chave da WeatherAPI
+		result.Error = redact.String(err.Error())
+	}
+
+	// Registra apenas as mudanças de estado
+	switch {
+	case err != nil && (!check.valid || check.result.Status == StatusPass):
+		logging.Warnf(ctx, "Verificação %s do %s falhou: %v", check.Name, c.service, err)
+	case err == nil && check.valid && check.result.Status != StatusPass:
+		logging.Printf(ctx, "Verificação %s do %s voltou a passar", check.Name, c.service)
+	}
+
+	check.result = result
+	check.valid = true
+	return result
+}
+
+// Register registra as sondas no mux
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.HandleFunc(LivezPath, c.livez)
+	mux.HandleFunc(ReadyzPath, c.readyz)
+	mux.HandleFunc(StartupzPath, c.startupz)
+}
+
+// IsProbe indica se a requisição é de uma das sondas. Usado para não gerar
+// spans nem métricas para o tráfego das sondas
+func IsProbe(r *http.Request) bool {
+	switch r.URL.Path {
+	case LivezPath, ReadyzPath, StartupzPath:
+		return true
+	default:
+		return false
+	}
+}
+
+// livez responde 200 enquanto o processo atende requisições
+func (c *Checker) livez(w http.ResponseWriter, r *http.Request) {
+	if !allowed(w, r) {
+		return
+	}
+	c.write(w, true, nil)
+}
+
+// readyz responde 200 quando todas as dependências estão disponíveis e 503
+// caso contrário, com o resultado de cada verificação
+func (c *Checker) readyz(w http.ResponseWriter, r *http.Request) {
+	if !allowed(w, r) {
+		return
+	}
+	if !c.Started() {
+		c.write(w, false, nil)
+		return
+	}
+	ready, results := c.Ready(r.Context())
+	c.write(w, ready, results)
+}
+
+// startupz responde 200 depois que a inicialização terminou
+func (c *Checker) startupz(w http.ResponseWriter, r *http.Request) {
+	if !allowed(w, r) {
+		return
+	}
+	c.write(w, c.Started(), nil)
+}
+
+// write escreve o relatório com o status HTTP correspondente
+func (c *Checker) write(w http.ResponseWriter, ok bool, results map[string]Result) {
+	report := Report{Status: StatusPass, Service: c.service, Checks: results}
+	status := http.StatusOK
+	if !ok {
+		report.Status = StatusFail
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// allowed aceita apenas GET e HEAD
+func allowed(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	w.Header().Set("Allow", "GET, HEAD")
+	problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "method not allowed")
+	return false
+}
@@ -0,0 +1,214 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"servico-b/internal/redact"
+)
+
+func TestProbes(t *testing.T) {
+	failing := Check{Name: "viacep", Run: func(ctx context.Context) error {
+		return errors.New("ViaCEP retornou status 502")
+	}}
+	passing := Check{Name: "viacep", Run: func(ctx context.Context) error { return nil }}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		check          Check
+		started        bool
+		expectedStatus int
+		expectedReport Report
+	}{
+		{
+			name:           "Liveness",
+			method:         http.MethodGet,
+			path:           LivezPath,
+			check:          failing,
+			expectedStatus: http.StatusOK,
+			expectedReport: Report{Status: StatusPass, Service: "servico-b"},
+		},
+		{
+			name:           "Startup antes da inicialização",
+			method:         http.MethodGet,
+			path:           StartupzPath,
+			check:          passing,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedReport: Report{Status: StatusFail, Service: "servico-b"},
+		},
+		{
+			name:           "Startup após a inicialização",
+			method:         http.MethodGet,
+			path:           StartupzPath,
+			check:          passing,
+			started:        true,
+			expectedStatus: http.StatusOK,
+			expectedReport: Report{Status: StatusPass, Service: "servico-b"},
+		},
+		{
+			name:           "Readiness com dependências disponíveis",
+			method:         http.MethodGet,
+			path:           ReadyzPath,
+			check:          passing,
+			started:        true,
+			expectedStatus: http.StatusOK,
+			expectedReport: Report{Status: StatusPass, Service: "servico-b", Checks: map[string]Result{
+				"viacep": {Status: StatusPass},
+			}},
+		},
+		{
+			name:           "Readiness com dependência indisponível",
+			method:         http.MethodGet,
+			path:           ReadyzPath,
+			check:          failing,
+			started:        true,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedReport: Report{Status: StatusFail, Service: "servico-b", Checks: map[string]Result{
+				"viacep": {Status: StatusFail, Error: "ViaCEP retornou status 502"},
+			}},
+		},
+		{
+			name:           "Readiness antes da inicialização",
+			method:         http.MethodGet,
+			path:           ReadyzPath,
+			check:          passing,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedReport: Report{Status: StatusFail, Service: "servico-b"},
+		},
+		{
+			name:           "Método não permitido",
+			method:         http.MethodPost,
+			path:           ReadyzPath,
+			check:          passing,
+			started:        true,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := New("servico-b", Settings{CacheTTL: time.Minute, Timeout: time.Second}, tt.check)
+			if tt.started {
+				checker.MarkStarted()
+			}
+			mux := http.NewServeMux()
+			checker.Register(mux)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if !IsProbe(req) {
+				t.Errorf("IsProbe(%s) = false", tt.path)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus == http.StatusMethodNotAllowed {
+				return
+			}
+
+			var report Report
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("resposta não é JSON: %v\n%s", err, rec.Body.String())
+			}
+			if report.Status != tt.expectedReport.Status || report.Service != tt.expectedReport.Service {
+				t.Errorf("relatório = %+v, expected %+v", report, tt.expectedReport)
+			}
+			if len(report.Checks) != len(tt.expectedReport.Checks) {
+				t.Fatalf("verificações = %+v, expected %+v", report.Checks, tt.expectedReport.Checks)
+			}
+			for name, expected := range tt.expectedReport.Checks {
+				got := report.Checks[name]
+				if got.Status != expected.Status || got.Error != expected.Error || got.CheckedAt.IsZero() {
+					t.Errorf("verificação %s = %+v, expected %+v", name, got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestReadyRedactsErrors(t *testing.T) {
+	const apiKey = "chave-weatherapi-de-teste"
+	redact.Add(apiKey)
+
+	checker := New("servico-b", Settings{CacheTTL: time.Minute, Timeout: time.Second},
+		Check{Name: "weatherapi", Run: func(ctx context.Context) error {
+			return errors.New("API key " + apiKey + " has been disabled.")
+		}},
+	)
+	_, results := checker.Ready(context.Background())
+	if got := results["weatherapi"].Error; strings.Contains(got, apiKey) {
+		t.Errorf("erro da verificação contém a chave: %q", got)
+	}
+}
+
+func TestIsProbe(t *testing.T) {
+	for _, path := range []string{"/", "/metrics", "/livez/extra"} {
+		if IsProbe(httptest.NewRequest(http.MethodGet, path, nil)) {
+			t.Errorf("IsProbe(%s) = true", path)
+		}
+	}
+}
+
+func TestReadyCache(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	checker := New("servico-b", Settings{CacheTTL: 10 * time.Second, Timeout: time.Second},
+		Check{Name: "viacep", Run: func(ctx context.Context) error {
+			runs.Add(1)
+			<-release
+			return nil
+		}},
+		Check{Name: "lenta", TTL: time.Hour, Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+	now := time.Now()
+	checker.now = func() time.Time { return now }
+
+	// Sondas simultâneas aguardam a mesma execução
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checker.Ready(context.Background())
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if got := runs.Load(); got != 1 {
+		t.Errorf("execuções = %d, expected 1 para sondas simultâneas", got)
+	}
+
+	// O resultado é reaproveitado dentro do TTL
+	now = now.Add(5 * time.Second)
+	ready, results := checker.Ready(context.Background())
+	if got := runs.Load(); got != 1 {
+		t.Errorf("execuções = %d, expected 1 dentro do TTL", got)
+	}
+
+	// A verificação que passa do timeout falha, com TTL próprio
+	if ready || results["lenta"].Status != StatusFail || results["lenta"].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("ready = %v, lenta = %+v, expected falha por timeout", ready, results["lenta"])
+	}
+
+	now = now.Add(10 * time.Second)
+	checker.Ready(context.Background())
+	if got := runs.Load(); got != 2 {
+		t.Errorf("execuções = %d, expected 2 após o TTL", got)
+	}
+}
@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestSetLevel(t *testing.T) {
+	var out bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&out)
+	defer SetLevel(LevelInfo)
+
+	level, err := ParseLevel("WARN")
+	if err != nil {
+		t.Fatalf("ParseLevel() err = %v", err)
+	}
+	SetLevel(level)
+
+	ctx := context.Background()
+	Debugf(ctx, "mensagem debug")
+	Printf(ctx, "mensagem info")
+	Warnf(ctx, "mensagem warn")
+	Errorf(ctx, "mensagem error")
+
+	for _, msg := range []string{"mensagem debug", "mensagem info"} {
+		if strings.Contains(out.String(), msg) {
+			t.Errorf("%q registrada com nível warn", msg)
+		}
+	}
+	for _, msg := range []string{"[WARN] mensagem warn", "[ERROR] mensagem error"} {
+		if !strings.Contains(out.String(), msg) {
+			t.Errorf("%q não registrada com nível warn:\n%s", msg, out.String())
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(verbose) err = nil, expected erro")
+	}
+}
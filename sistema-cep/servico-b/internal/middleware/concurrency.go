@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"servico-b/internal/admin"
+	"servico-b/internal/concurrency"
+	"servico-b/internal/config"
+	"servico-b/internal/problem"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ConcurrencyLimiter limita as requisições simultâneas com um limite
+// adaptativo, rejeitando o excesso com 503 antes de qualquer processamento
+type ConcurrencyLimiter struct {
+	enabled bool
+	limiter *concurrency.Limiter
+	shed    metric.Int64Counter
+}
+
+// NewConcurrencyLimiter cria o middleware de limite de concorrência a partir
+// da configuração
+func NewConcurrencyLimiter(cfg config.ConcurrencyLimitConfig) *ConcurrencyLimiter {
+	c := &ConcurrencyLimiter{
+		enabled: cfg.Enabled,
+		limiter: concurrency.NewLimiter(concurrency.Settings{
+			InitialLimit:     cfg.InitialLimit,
+			MinLimit:         cfg.MinLimit,
+			MaxLimit:         cfg.MaxLimit,
+			LatencyThreshold: cfg.LatencyThreshold,
+			BackoffRatio:     cfg.BackoffRatio,
+		}),
+	}
+	if c.enabled {
+		c.registerMetrics()
+	}
+	return c
+}
+
+// RegisterAdmin registra os endpoints administrativos do limite, se habilitado
+func (c *ConcurrencyLimiter) RegisterAdmin(r *admin.Registry) {
+	if c.enabled {
+		c.limiter.RegisterAdmin(r)
+	}
+}
+
+// Handler envolve o próximo handler aplicando o limite de concorrência
+func (c *ConcurrencyLimiter) Handler(next http.Handler) http.Handler {
+	if !c.enabled {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release, ok := c.limiter.Acquire()
+		if !ok {
+			c.recordShed(r.Context())
+			w.Header().Set("Retry-After", "1")
+			problem.Write(w, r, http.StatusServiceUnavailable, problem.CodeOverloaded, "server overloaded")
+			return
+		}
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			// Erros 5xx, incluindo o 504 do prazo esgotado, e panics indicam
+			// sobrecarga tanto quanto a latência alta. O prazo é aplicado
+			// dentro deste middleware, então só aparece no status. A vaga é
+			// liberada mesmo após um panic
+			failed := !completed || rec.status >= http.StatusInternalServerError
+			release(time.Since(start), failed)
+		}()
+
+		next.ServeHTTP(rec, r)
+		completed = true
+	})
+}
+
+// UnaryInterceptor aplica o mesmo limite às chamadas gRPC. Chamadas acima do
+// limite recebem UNAVAILABLE, que o cliente pode repetir como um 503
+func (c *ConcurrencyLimiter) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !c.enabled {
+			return handler(ctx, req)
+		}
+
+		release, ok := c.limiter.Acquire()
+		if !ok {
+			c.recordShed(ctx)
+			return nil, status.Error(grpccodes.Unavailable, "server overloaded")
+		}
+
+		start := time.Now()
+		completed := false
+		var err error
+		defer func() {
+			failed := !completed || isServerFailure(status.Code(err)) ||
+				errors.Is(ctx.Err(), context.DeadlineExceeded)
+			release(time.Since(start), failed)
+		}()
+
+		resp, err := handler(ctx, req)
+		completed = true
+		return resp, err
+	}
+}
+
+// isServerFailure indica se o código gRPC equivale a um erro 5xx
+func isServerFailure(code grpccodes.Code) bool {
+	switch code {
+	case grpccodes.Unknown, grpccodes.DeadlineExceeded, grpccodes.Unimplemented,
+		grpccodes.Internal, grpccodes.Unavailable, grpccodes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// recordShed registra a requisição rejeitada no span e na métrica
+func (c *ConcurrencyLimiter) recordShed(ctx context.Context) {
+	trace.SpanFromContext(ctx).AddEvent("concurrency_limit.shed", trace.WithAttributes(
+		attribute.Int("concurrency_limit.limit", c.limiter.Limit()),
+	))
+	if c.shed != nil {
+		c.shed.Add(ctx, 1)
+	}
+}
+
+// registerMetrics publica o limite atual, as requisições em andamento e as
+// requisições rejeitadas
+func (c *ConcurrencyLimiter) registerMetrics() {
+	meter := otel.Meter("servico-b")
+
+	shed, err := meter.Int64Counter(
+		"concurrency_limit.shed",
+		metric.WithDescription("Requisições rejeitadas pelo limite de concorrência"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	c.shed = shed
+
+	limit, err := meter.Int64ObservableGauge(
+		"concurrency_limit.limit",
+		metric.WithDescription("Limite atual de requisições simultâneas"),
+	)
+	if err != nil {
+		otel.Handle(err)
+		return
+	}
+	inFlight, err := meter.Int64ObservableGauge(
+		"concurrency_limit.in_flight",
+		metric.WithDescription("Requisições em andamento"),
+	)
+	if err != nil {
+		otel.Handle(err)
+		return
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(limit, int64(c.limiter.Limit()))
+		o.ObserveInt64(inFlight, int64(c.limiter.InFlight()))
+		return nil
+	}, limit, inFlight)
+	if err != nil {
+		otel.Handle(err)
+	}
+}
+
+// statusRecorder registra o status da resposta
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap expõe o ResponseWriter original ao http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"servico-b/internal/deadline"
+	"servico-b/internal/logging"
+	"servico-b/internal/problem"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Deadline limita o tempo total da requisição ao menor valor entre o timeout
+// configurado e o tempo restante informado pelo chamador no header
+// X-Request-Timeout. Requisições que chegam com o prazo esgotado recebem 504
+// sem serem processadas. Timeout zero só aplica o prazo do chamador
+func Deadline(timeout time.Duration, next http.Handler) http.Handler {
+	return DeadlineFunc(func() time.Duration { return timeout }, next)
+}
+
+// DeadlineFunc é o Deadline com o timeout consultado a cada requisição,
+// permitindo alterá-lo sem remontar os handlers
+func DeadlineFunc(timeout func() time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		budget := timeout()
+		span := trace.SpanFromContext(r.Context())
+
+		if value := r.Header.Get(deadline.Header); value != "" {
+			remaining, ok := deadline.Parse(value)
+			switch {
+			case !ok:
+				logging.Warnf(r.Context(), "Header %s inválido ignorado: %q", deadline.Header, value)
+			case remaining <= 0:
+				span.AddEvent("deadline.exceeded_on_arrival")
+				problem.Write(w, r, http.StatusGatewayTimeout, problem.CodeDeadlineExceeded, "request deadline exceeded")
+				return
+			case budget <= 0 || remaining < budget:
+				budget = remaining
+			}
+		}
+
+		if budget <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		span.SetAttributes(attribute.Int64("request.timeout_ms", budget.Milliseconds()))
+
+		ctx, cancel := context.WithTimeout(r.Context(), budget)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// UnaryDeadline é o equivalente gRPC do Deadline. O prazo do chamador já
+// chega no contexto pelo próprio gRPC; o interceptor só o limita ao timeout
+// configurado e rejeita chamadas que chegam com o prazo esgotado
+func UnaryDeadline(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return UnaryDeadlineFunc(func() time.Duration { return timeout })
+}
+
+// UnaryDeadlineFunc é o UnaryDeadline com o timeout consultado a cada chamada
+func UnaryDeadlineFunc(timeout func() time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		span := trace.SpanFromContext(ctx)
+
+		budget := timeout()
+		if deadline, ok := ctx.Deadline(); ok {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				span.AddEvent("deadline.exceeded_on_arrival")
+				return nil, status.Error(codes.DeadlineExceeded, "request deadline exceeded")
+			}
+			if budget <= 0 || remaining < budget {
+				budget = remaining
+			}
+		}
+
+		if budget <= 0 {
+			return handler(ctx, req)
+		}
+
+		span.SetAttributes(attribute.Int64("request.timeout_ms", budget.Milliseconds()))
+
+		ctx, cancel := context.WithTimeout(ctx, budget)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"servico-b/internal/deadline"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDeadline(t *testing.T) {
+	tests := []struct {
+		name           string
+		timeout        time.Duration
+		header         string
+		expectedStatus int
+		expectedBudget time.Duration
+	}{
+		{
+			name:           "Sem header usa o timeout configurado",
+			timeout:        10 * time.Second,
+			expectedStatus: http.StatusOK,
+			expectedBudget: 10 * time.Second,
+		},
+		{
+			name:           "Prazo do chamador menor que o timeout",
+			timeout:        10 * time.Second,
+			header:         "2000",
+			expectedStatus: http.StatusOK,
+			expectedBudget: 2 * time.Second,
+		},
+		{
+			name:           "Prazo do chamador maior que o timeout",
+			timeout:        10 * time.Second,
+			header:         "60000",
+			expectedStatus: http.StatusOK,
+			expectedBudget: 10 * time.Second,
+		},
+		{
+			name:           "Prazo do chamador sem timeout configurado",
+			header:         "3000",
+			expectedStatus: http.StatusOK,
+			expectedBudget: 3 * time.Second,
+		},
+		{
+			name:           "Header inválido é ignorado",
+			timeout:        10 * time.Second,
+			header:         "abc",
+			expectedStatus: http.StatusOK,
+			expectedBudget: 10 * time.Second,
+		},
+		{
+			name:           "Prazo esgotado na chegada",
+			timeout:        10 * time.Second,
+			header:         "0",
+			expectedStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:           "Prazo negativo na chegada",
+			timeout:        10 * time.Second,
+			header:         "-5",
+			expectedStatus: http.StatusGatewayTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var budget time.Duration
+			called := false
+			handler := Deadline(tt.timeout, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				if d, ok := r.Context().Deadline(); ok {
+					budget = time.Until(d)
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(deadline.Header, tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				if called {
+					t.Error("handler chamado com o prazo esgotado")
+				}
+				return
+			}
+			if budget > tt.expectedBudget || budget < tt.expectedBudget-time.Second {
+				t.Errorf("prazo = %v, expected %v", budget, tt.expectedBudget)
+			}
+		})
+	}
+}
+
+func TestUnaryDeadline(t *testing.T) {
+	tests := []struct {
+		name           string
+		timeout        time.Duration
+		callerTimeout  time.Duration
+		expectedCode   codes.Code
+		expectedBudget time.Duration
+	}{
+		{
+			name:           "Sem prazo do chamador usa o timeout configurado",
+			timeout:        10 * time.Second,
+			expectedCode:   codes.OK,
+			expectedBudget: 10 * time.Second,
+		},
+		{
+			name:           "Prazo do chamador menor que o timeout",
+			timeout:        10 * time.Second,
+			callerTimeout:  2 * time.Second,
+			expectedCode:   codes.OK,
+			expectedBudget: 2 * time.Second,
+		},
+		{
+			name:           "Prazo do chamador maior que o timeout",
+			timeout:        10 * time.Second,
+			callerTimeout:  time.Minute,
+			expectedCode:   codes.OK,
+			expectedBudget: 10 * time.Second,
+		},
+		{
+			name:          "Prazo esgotado na chegada",
+			timeout:       10 * time.Second,
+			callerTimeout: -time.Second,
+			expectedCode:  codes.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.callerTimeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.callerTimeout)
+				defer cancel()
+			}
+
+			var budget time.Duration
+			called := false
+			handler := func(ctx context.Context, req any) (any, error) {
+				called = true
+				if d, ok := ctx.Deadline(); ok {
+					budget = time.Until(d)
+				}
+				return nil, nil
+			}
+
+			_, err := UnaryDeadline(tt.timeout)(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+
+			if code := status.Code(err); code != tt.expectedCode {
+				t.Fatalf("code = %s, expected %s", code, tt.expectedCode)
+			}
+			if tt.expectedCode != codes.OK {
+				if called {
+					t.Error("handler chamado com o prazo esgotado")
+				}
+				return
+			}
+			if budget > tt.expectedBudget || budget < tt.expectedBudget-time.Second {
+				t.Errorf("prazo = %v, expected %v", budget, tt.expectedBudget)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"servico-b/internal/mtls"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// PeerIdentity registra no span da requisição a identidade do certificado
+// apresentado pelo cliente. Requisições sem TLS passam sem alteração
+func PeerIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace.SpanFromContext(r.Context()).SetAttributes(mtls.PeerAttributes("client", r.TLS)...)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// UnaryPeerIdentity é o equivalente gRPC do PeerIdentity
+func UnaryPeerIdentity(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			trace.SpanFromContext(ctx).SetAttributes(mtls.PeerAttributes("client", &tlsInfo.State)...)
+		}
+	}
+	return handler(ctx, req)
+}
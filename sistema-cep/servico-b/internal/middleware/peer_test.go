@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestPeerIdentity(t *testing.T) {
+	cert := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "servico-a"},
+		Issuer:   pkix.Name{CommonName: "sistema-cep CA"},
+		URIs:     []*url.URL{{Scheme: "spiffe", Host: "sistema-cep", Path: "/servico-a"}},
+		NotAfter: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	state := &tls.ConnectionState{Version: tls.VersionTLS13, PeerCertificates: []*x509.Certificate{cert}}
+
+	tests := []struct {
+		name     string
+		call     func(ctx context.Context)
+		expected string
+	}{
+		{
+			name: "HTTP com certificado do cliente",
+			call: func(ctx context.Context) {
+				req := httptest.NewRequest(http.MethodPost, "/temperature", nil).WithContext(ctx)
+				req.TLS = state
+				PeerIdentity(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), req)
+			},
+			expected: "spiffe://sistema-cep/servico-a",
+		},
+		{
+			name: "HTTP sem TLS",
+			call: func(ctx context.Context) {
+				req := httptest.NewRequest(http.MethodPost, "/temperature", nil).WithContext(ctx)
+				PeerIdentity(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), req)
+			},
+		},
+		{
+			name: "gRPC com certificado do cliente",
+			call: func(ctx context.Context) {
+				ctx = peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: *state}})
+				UnaryPeerIdentity(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) { return nil, nil })
+			},
+			expected: "spiffe://sistema-cep/servico-a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(context.Background(), "request")
+			tt.call(ctx)
+			span.End()
+
+			attrs := make(map[string]string)
+			for _, kv := range recorder.Ended()[0].Attributes() {
+				attrs[string(kv.Key)] = kv.Value.AsString()
+			}
+			if got := attrs["tls.client.identity"]; got != tt.expected {
+				t.Errorf("tls.client.identity = %q, expected %q", got, tt.expected)
+			}
+			if tt.expected != "" && attrs["tls.client.issuer"] != "CN=sistema-cep CA" {
+				t.Errorf("tls.client.issuer = %q", attrs["tls.client.issuer"])
+			}
+		})
+	}
+}
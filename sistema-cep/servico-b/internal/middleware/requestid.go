@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"servico-b/internal/requestid"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestID reaproveita o X-Request-ID recebido ou gera um novo, disponibiliza
+// o ID no contexto, no span da requisição e no header de resposta
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.IsValid(id) {
+			id = requestid.New()
+		}
+
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("request.id", id))
+		w.Header().Set(requestid.Header, id)
+
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
+
+// UnaryRequestID é o equivalente gRPC do RequestID: lê o ID dos metadados
+// x-request-id ou gera um novo, e o devolve nos headers da resposta
+func UnaryRequestID(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	key := strings.ToLower(requestid.Header)
+
+	var id string
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		id = values[0]
+	}
+	if !requestid.IsValid(id) {
+		id = requestid.New()
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("request.id", id))
+	grpc.SetHeader(ctx, metadata.Pairs(key, id))
+
+	return handler(requestid.NewContext(ctx, id), req)
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDHeader é o header de resposta que expõe o trace ID da requisição
+const TraceIDHeader = "X-Trace-Id"
+
+// TraceID adiciona o trace ID da requisição ao header de resposta, para que
+// clientes possam localizar o trace no Zipkin
+func TraceID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+			w.Header().Set(TraceIDHeader, sc.TraceID().String())
+		}
+		next.ServeHTTP(w, r)
+	})
+}
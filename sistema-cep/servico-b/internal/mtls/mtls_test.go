@@ -0,0 +1,269 @@
+package mtls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA é uma CA descartável criada para o teste
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() err = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() err = %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue emite um certificado de folha e grava o certificado, a chave e a CA
+// em dir, retornando as configurações com os arquivos
+func (ca *testCA) issue(t *testing.T, dir, name string, usage x509.ExtKeyUsage) Settings {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() err = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		URIs:         []*url.URL{{Scheme: "spiffe", Host: "sistema-cep", Path: "/" + name}},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() err = %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() err = %v", err)
+	}
+
+	settings := Settings{
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+		CAFile:   filepath.Join(dir, "ca.crt"),
+	}
+	writeFile(t, settings.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	writeFile(t, settings.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	writeFile(t, settings.CAFile, ca.pem)
+	return settings
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile() err = %v", err)
+	}
+}
+
+// newTestServer inicia um servidor HTTPS que responde com a identidade do
+// certificado do cliente
+func newTestServer(t *testing.T, reloader *Reloader) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(Identity(r.TLS.PeerCertificates[0])))
+	}))
+	// StartTLS colocaria o certificado do httptest na frente do GetCertificate
+	server.Listener = tls.NewListener(server.Listener, reloader.ServerConfig())
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.Start()
+	server.URL = strings.Replace(server.URL, "http://", "https://", 1)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newClient cria um cliente HTTPS com o certificado e as CAs dos arquivos
+func newClient(t *testing.T, settings Settings) *http.Client {
+	t.Helper()
+
+	cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+	if err != nil {
+		t.Fatalf("LoadX509KeyPair() err = %v", err)
+	}
+	caPEM, err := os.ReadFile(settings.CAFile)
+	if err != nil {
+		t.Fatalf("ReadFile() err = %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caPEM)
+
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: pool},
+		DisableKeepAlives: true,
+	}}
+}
+
+func newReloader(t *testing.T, settings Settings) *Reloader {
+	t.Helper()
+	reloader, err := NewReloader(settings)
+	if err != nil {
+		t.Fatalf("NewReloader() err = %v", err)
+	}
+	return reloader
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t, "sistema-cep CA")
+	other := newTestCA(t, "outra CA")
+
+	server := newTestServer(t, newReloader(t, ca.issue(t, t.TempDir(), "servico-b", x509.ExtKeyUsageServerAuth)))
+
+	tests := []struct {
+		name       string
+		settings   Settings
+		expectedOK bool
+	}{
+		{
+			name:       "cliente com certificado da CA",
+			settings:   ca.issue(t, t.TempDir(), "servico-a", x509.ExtKeyUsageClientAuth),
+			expectedOK: true,
+		},
+		{
+			name:     "cliente com certificado de outra CA",
+			settings: other.issue(t, t.TempDir(), "intruso", x509.ExtKeyUsageClientAuth),
+		},
+		{
+			name:     "certificado sem uso de cliente",
+			settings: ca.issue(t, t.TempDir(), "servico-a", x509.ExtKeyUsageServerAuth),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := newClient(t, tt.settings).Get(server.URL)
+			if !tt.expectedOK {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("conexão aceita, expected rejeição")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get() err = %v", err)
+			}
+			defer resp.Body.Close()
+
+			body := make([]byte, 64)
+			n, _ := resp.Body.Read(body)
+			if got := string(body[:n]); got != "spiffe://sistema-cep/servico-a" {
+				t.Errorf("identidade vista pelo servidor = %q", got)
+			}
+
+			attrs := make(map[string]string)
+			for _, kv := range PeerAttributes("server", resp.TLS) {
+				attrs[string(kv.Key)] = kv.Value.AsString()
+			}
+			if attrs["tls.server.identity"] != "spiffe://sistema-cep/servico-b" {
+				t.Errorf("tls.server.identity = %q", attrs["tls.server.identity"])
+			}
+			if !strings.Contains(attrs["tls.server.issuer"], "sistema-cep CA") {
+				t.Errorf("tls.server.issuer = %q", attrs["tls.server.issuer"])
+			}
+		})
+	}
+
+	t.Run("cliente sem certificado", func(t *testing.T) {
+		client := newClient(t, ca.issue(t, t.TempDir(), "servico-a", x509.ExtKeyUsageClientAuth))
+		client.Transport.(*http.Transport).TLSClientConfig.Certificates = nil
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+			t.Fatal("conexão aceita, expected rejeição")
+		}
+	})
+}
+
+func TestReloaderRotation(t *testing.T) {
+	oldCA := newTestCA(t, "CA antiga")
+	newCA := newTestCA(t, "CA nova")
+
+	serverDir := t.TempDir()
+	serverReloader := newReloader(t, oldCA.issue(t, serverDir, "servico-b", x509.ExtKeyUsageServerAuth))
+	server := newTestServer(t, serverReloader)
+
+	oldCAClient := newClient(t, oldCA.issue(t, t.TempDir(), "servico-a", x509.ExtKeyUsageClientAuth))
+	newCAClient := newClient(t, newCA.issue(t, t.TempDir(), "servico-a", x509.ExtKeyUsageClientAuth))
+	get := func(client *http.Client) error {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+	if err := get(oldCAClient); err != nil {
+		t.Fatalf("Get() antes da rotação err = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go serverReloader.Run(ctx, 10*time.Millisecond)
+
+	// O servidor passa para a CA nova sem reiniciar: o certificado antigo
+	// deixa de ser aceito e o novo passa a ser
+	newCA.issue(t, serverDir, "servico-b", x509.ExtKeyUsageServerAuth)
+	deadline := time.Now().Add(2 * time.Second)
+	for get(oldCAClient) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("servidor continuou aceitando o certificado da CA antiga")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := get(newCAClient); err != nil {
+		t.Errorf("Get() após a rotação err = %v", err)
+	}
+
+	// Arquivos inválidos são rejeitados e os certificados atuais mantidos
+	writeFile(t, filepath.Join(serverDir, "tls.key"), []byte("inválido"))
+	if err := serverReloader.Reload(); err == nil {
+		t.Error("Reload() com chave inválida não retornou erro")
+	}
+	if err := get(newCAClient); err != nil {
+		t.Errorf("Get() com os certificados mantidos err = %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package problem
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Language identifica um idioma suportado pelo catálogo de mensagens
+type Language string
+
+const (
+	LanguageEnglish    Language = "en"
+	LanguagePortuguese Language = "pt-BR"
+)
+
+// DefaultLanguage é o idioma usado quando o cliente não indica preferência
+// suportada; as mensagens em inglês são as exigidas pela especificação
+const DefaultLanguage = LanguageEnglish
+
+// catalogue contém as mensagens traduzidas por idioma e código de erro.
+// O inglês não aparece aqui porque o detalhe recebido pelos handlers já é
+// a mensagem original em inglês
+var catalogue = map[Language]map[Code]string{
+	LanguagePortuguese: {
+		CodeInvalidRequestBody:   "corpo da requisição inválido",
+		CodeUnsupportedMediaType: "o header Content-Type deve ser application/json",
+		CodePayloadTooLarge:      "corpo da requisição excede o tamanho máximo permitido",
+		CodeMethodNotAllowed:     "método não permitido",
+		CodeUnauthorized:         "credenciais ausentes ou inválidas",
+		CodeInvalidZipcode:       "CEP inválido",
+		CodeZipcodeNotFound:      "CEP não encontrado",
+		CodeUpstreamUnavailable:  "serviço temporariamente indisponível",
+		CodeDeadlineExceeded:     "tempo limite da requisição excedido",
+		CodeOverloaded:           "servidor sobrecarregado, tente novamente mais tarde",
+		CodeInternalError:        "erro interno do servidor",
+	},
+}
+
+// NegotiateLanguage escolhe o idioma da resposta a partir do header
+// Accept-Language, respeitando os pesos q informados pelo cliente
+func NegotiateLanguage(r *http.Request) Language {
+	type candidate struct {
+		language Language
+		quality  float64
+	}
+
+	var candidates []candidate
+	for _, header := range r.Header.Values("Accept-Language") {
+		for _, part := range strings.Split(header, ",") {
+			tag, quality := parseLanguageRange(part)
+			if quality <= 0 {
+				continue
+			}
+			if language, ok := matchLanguage(tag); ok {
+				candidates = append(candidates, candidate{language: language, quality: quality})
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].language
+}
+
+// Localize retorna a mensagem do código no idioma indicado, ou o detalhe
+// original em inglês quando não há tradução
+func Localize(language Language, code Code, detail string) string {
+	if message, ok := catalogue[language][code]; ok {
+		return message
+	}
+	return detail
+}
+
+// parseLanguageRange separa a tag de idioma e o peso q de um item do header
+func parseLanguageRange(part string) (string, float64) {
+	fields := strings.Split(strings.TrimSpace(part), ";")
+	tag := strings.TrimSpace(fields[0])
+	quality := 1.0
+
+	for _, param := range fields[1:] {
+		param = strings.TrimSpace(param)
+		if value, ok := strings.CutPrefix(param, "q="); ok {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return tag, 0
+			}
+			quality = q
+		}
+	}
+
+	return tag, quality
+}
+
+// matchLanguage associa uma tag de idioma a um idioma suportado
+func matchLanguage(tag string) (Language, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+
+	switch primary {
+	case "pt":
+		return LanguagePortuguese, true
+	case "en":
+		return LanguageEnglish, true
+	default:
+		return "", false
+	}
+}
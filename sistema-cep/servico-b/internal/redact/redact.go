@@ -0,0 +1,165 @@
+package redact
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Placeholder substitui os segredos nos textos redigidos
+const Placeholder = "[REDACTED]"
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+)
+
+// Add registra segredos que nunca devem aparecer em logs, spans ou
+// mensagens de erro. Segredos anteriores continuam registrados, pois podem
+// estar em requisições iniciadas antes de uma rotação
+func Add(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, value := range values {
+		if value == "" || contains(secrets, value) {
+			continue
+		}
+		secrets = append(secrets, value)
+	}
+}
+
+// String substitui os segredos registrados no texto
+func String(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Placeholder)
+	}
+	return s
+}
+
+// Error retorna err com a mensagem redigida, se ela contiver algum segredo.
+// errors.Is e errors.As continuam funcionando com o erro original
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if redacted := String(msg); redacted != msg {
+		return &redactedError{msg: redacted, err: err}
+	}
+	return err
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// Writer redige os segredos antes de escrever em w. Deve envolver a saída
+// do pacote log, que escreve cada mensagem em uma única chamada
+func Writer(w io.Writer) io.Writer {
+	return &writer{w: w}
+}
+
+type writer struct {
+	w io.Writer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// NewSpanExporter redige os segredos no nome, nos atributos, nos eventos,
+// nos links e no status dos spans antes de repassá-los ao exporter
+func NewSpanExporter(next sdktrace.SpanExporter) sdktrace.SpanExporter {
+	return &spanExporter{next: next}
+}
+
+type spanExporter struct {
+	next sdktrace.SpanExporter
+}
+
+func (e *spanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	redacted := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, span := range spans {
+		redacted[i] = redactSpan(span)
+	}
+	return e.next.ExportSpans(ctx, redacted)
+}
+
+func (e *spanExporter) Shutdown(ctx context.Context) error {
+	return e.next.Shutdown(ctx)
+}
+
+// redactSpan retorna uma cópia do span com os segredos substituídos
+func redactSpan(span sdktrace.ReadOnlySpan) sdktrace.ReadOnlySpan {
+	stub := tracetest.SpanStubFromReadOnlySpan(span)
+	stub.Name = String(stub.Name)
+	stub.Attributes = attributes(stub.Attributes)
+
+	// Eventos e links são copiados para não alterar o span original, que
+	// pode ser lido por outros processadores
+	events := make([]sdktrace.Event, len(stub.Events))
+	for i, event := range stub.Events {
+		event.Name = String(event.Name)
+		event.Attributes = attributes(event.Attributes)
+		events[i] = event
+	}
+	stub.Events = events
+
+	links := make([]sdktrace.Link, len(stub.Links))
+	for i, link := range stub.Links {
+		link.Attributes = attributes(link.Attributes)
+		links[i] = link
+	}
+	stub.Links = links
+
+	stub.Status.Description = String(stub.Status.Description)
+	return stub.Snapshot()
+}
+
+// attributes retorna os atributos com os valores textuais redigidos
+func attributes(kvs []attribute.KeyValue) []attribute.KeyValue {
+	if len(kvs) == 0 {
+		return kvs
+	}
+
+	redacted := make([]attribute.KeyValue, len(kvs))
+	for i, kv := range kvs {
+		switch kv.Value.Type() {
+		case attribute.STRING:
+			kv = kv.Key.String(String(kv.Value.AsString()))
+		case attribute.STRINGSLICE:
+			values := kv.Value.AsStringSlice()
+			for j := range values {
+				values[j] = String(values[j])
+			}
+			kv = kv.Key.StringSlice(values)
+		}
+		redacted[i] = kv
+	}
+	return redacted
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package redact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestString(t *testing.T) {
+	Add("chave-antiga", "", "chave-nova")
+
+	got := String("key=chave-antiga&next=chave-nova")
+	if got != "key=[REDACTED]&next=[REDACTED]" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestError(t *testing.T) {
+	Add("segredo-erro")
+
+	base := errors.New("falha de rede")
+	err := Error(fmt.Errorf("chamada com segredo-erro: %w", base))
+	if strings.Contains(err.Error(), "segredo-erro") {
+		t.Errorf("Error() = %q, contém o segredo", err)
+	}
+	if !errors.Is(err, base) {
+		t.Error("errors.Is() = false, expected o erro original na cadeia")
+	}
+
+	plain := errors.New("sem segredos")
+	if Error(plain) != plain {
+		t.Error("Error() alterou um erro sem segredos")
+	}
+}
+
+func TestWriter(t *testing.T) {
+	Add("segredo-log")
+
+	var out strings.Builder
+	msg := "usando segredo-log\n"
+	n, err := Writer(&out).Write([]byte(msg))
+	if err != nil || n != len(msg) {
+		t.Fatalf("Write() = %d, %v; expected %d, nil", n, err, len(msg))
+	}
+	if out.String() != "usando [REDACTED]\n" {
+		t.Errorf("saída = %q", out.String())
+	}
+}
+
+func TestSpanExporter(t *testing.T) {
+	Add("segredo-span")
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(NewSpanExporter(exporter)))
+
+	_, span := tp.Tracer("test").Start(context.Background(), "GET /segredo-span")
+	span.SetAttributes(
+		attribute.String("url.full", "http://api/?key=segredo-span"),
+		attribute.StringSlice("lista", []string{"segredo-span"}),
+		attribute.Int("status", 200),
+	)
+	span.AddEvent("evento segredo-span", trace.WithAttributes(attribute.String("detalhe", "segredo-span")))
+	span.RecordError(errors.New("erro com segredo-span"))
+	span.SetStatus(codes.Error, "status com segredo-span")
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("spans exportados = %d, expected 1", len(spans))
+	}
+	if dump := fmt.Sprintf("%+v", spans[0]); strings.Contains(dump, "segredo-span") {
+		t.Errorf("span exportado contém o segredo:\n%s", dump)
+	}
+	if got := spans[0].Attributes[2].Value.AsInt64(); got != 200 {
+		t.Errorf("atributo não textual = %d, expected 200 preservado", got)
+	}
+}
@@ -0,0 +1,44 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+)
+
+// Header é o header HTTP que transporta o ID da requisição entre os serviços
+const Header = "X-Request-ID"
+
+// maxLength limita o tamanho de IDs recebidos de clientes
+const maxLength = 64
+
+// validID restringe os IDs aceitos a caracteres seguros para logs e headers
+var validID = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+type contextKey struct{}
+
+// New gera um ID curto e aleatório, fácil de ser informado ao suporte
+func New() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "000000000000"
+	}
+	return hex.EncodeToString(b)
+}
+
+// IsValid indica se um ID recebido pode ser reaproveitado
+func IsValid(id string) bool {
+	return len(id) > 0 && len(id) <= maxLength && validID.MatchString(id)
+}
+
+// NewContext retorna uma cópia do contexto contendo o ID da requisição
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext retorna o ID da requisição armazenado no contexto
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
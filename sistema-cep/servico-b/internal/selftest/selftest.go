@@ -0,0 +1,129 @@
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"servico-b/internal/models"
+	"servico-b/internal/services"
+)
+
+// Step é o resultado de uma etapa da verificação
+type Step struct {
+	Name     string
+	Detail   string
+	Err      error
+	Duration time.Duration
+}
+
+// Message retorna o detalhe da etapa ou, em caso de falha, o erro com a
+// orientação para corrigi-lo
+func (s Step) Message() string {
+	if s.Err == nil {
+		return s.Detail
+	}
+	if hint := hint(s.Err); hint != "" {
+		return fmt.Sprintf("%v; %s", s.Err, hint)
+	}
+	return s.Err.Error()
+}
+
+// Report é o resultado da verificação
+type Report struct {
+	Steps []Step
+}
+
+// OK indica se todas as etapas passaram
+func (r *Report) OK() bool {
+	return r.Err() == nil
+}
+
+// Err retorna as falhas das etapas juntas, ou nil se todas passaram
+func (r *Report) Err() error {
+	var errs []error
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", step.Name, step.Message()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Write escreve uma linha por etapa, alinhada para leitura no terminal
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, step := range r.Steps {
+		status := "ok"
+		if step.Err != nil {
+			status = "FALHA"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t(%s)\n", status, step.Name, step.Message(), step.Duration.Round(time.Millisecond))
+	}
+	return tw.Flush()
+}
+
+// Run consulta o CEP na ViaCEP e a temperatura da cidade encontrada na
+// WeatherAPI, verificando a conectividade com as duas APIs e a chave da
+// WeatherAPI. A WeatherAPI é verificada mesmo se a ViaCEP falhar
+func Run(ctx context.Context, viaCEP *services.ViaCEPService, weather *services.WeatherService, cep string) *Report {
+	report := &Report{}
+
+	var location *models.LocationInfo
+	report.Steps = append(report.Steps, run("viacep", func() (string, error) {
+		var err error
+		location, err = viaCEP.GetLocationByCEP(ctx, cep)
+		if err != nil {
+			return "", &viaCEPError{cep: cep, err: err}
+		}
+		return fmt.Sprintf("CEP %s: %s/%s", cep, location.City, location.State), nil
+	}))
+
+	report.Steps = append(report.Steps, run("weatherapi", func() (string, error) {
+		if location == nil {
+			return "chave aceita", weather.CheckAPIKey(ctx)
+		}
+		return fmt.Sprintf("chave aceita, temperatura de %s consultada", location.City), weather.CheckCity(ctx, location.City)
+	}))
+
+	return report
+}
+
+// run executa a etapa medindo a duração
+func run(name string, fn func() (string, error)) Step {
+	start := time.Now()
+	detail, err := fn()
+	return Step{Name: name, Detail: detail, Err: err, Duration: time.Since(start)}
+}
+
+// viaCEPError identifica as falhas da consulta à ViaCEP
+type viaCEPError struct {
+	cep string
+	err error
+}
+
+func (e *viaCEPError) Error() string {
+	return fmt.Sprintf("consulta do CEP %s falhou: %v", e.cep, e.err)
+}
+
+func (e *viaCEPError) Unwrap() error { return e.err }
+
+// hint orienta a correção de cada tipo de falha
+func hint(err error) string {
+	var viaCEPErr *viaCEPError
+	switch {
+	case errors.Is(err, services.ErrWeatherAPIKeyRejected):
+		return "verifique WEATHER_API_KEY ou WEATHER_API_KEY_FILE"
+	case errors.Is(err, services.ErrWeatherAPIQuotaExceeded):
+		return "a cota é renovada no início de cada mês; troque a chave ou o plano em https://www.weatherapi.com/"
+	case errors.Is(err, services.ErrWeatherAPIUnavailable):
+		return "verifique WEATHER_API_URL e a conectividade com a WeatherAPI"
+	case errors.As(err, &viaCEPErr):
+		return "verifique VIACEP_URL, STARTUP_CHECK_CEP e a conectividade com a ViaCEP"
+	default:
+		return ""
+	}
+}
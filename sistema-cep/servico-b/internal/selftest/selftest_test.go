@@ -0,0 +1,114 @@
+package selftest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"servico-b/internal/retry"
+	"servico-b/internal/services"
+)
+
+func TestRun(t *testing.T) {
+	const apiKey = "chave-weatherapi-de-teste"
+
+	tests := []struct {
+		name          string
+		viaCEPStatus  int
+		weatherStatus int
+		weatherBody   string
+		expectedCity  string
+		expectedErr   error
+		expectedHint  string
+	}{
+		{
+			name:          "APIs disponíveis e chave aceita",
+			viaCEPStatus:  http.StatusOK,
+			weatherStatus: http.StatusOK,
+			weatherBody:   `{"location":{"name":"Sao Paulo"},"current":{"temp_c":20,"temp_f":68}}`,
+			expectedCity:  "São Paulo",
+		},
+		{
+			name:          "Chave inválida",
+			viaCEPStatus:  http.StatusOK,
+			weatherStatus: http.StatusUnauthorized,
+			weatherBody:   `{"error":{"code":2006,"message":"API key is invalid."}}`,
+			expectedCity:  "São Paulo",
+			expectedErr:   services.ErrWeatherAPIKeyRejected,
+			expectedHint:  "verifique WEATHER_API_KEY",
+		},
+		{
+			name:          "Cota excedida",
+			viaCEPStatus:  http.StatusOK,
+			weatherStatus: http.StatusForbidden,
+			weatherBody:   `{"error":{"code":2007,"message":"API key has exceeded calls per month quota."}}`,
+			expectedCity:  "São Paulo",
+			expectedErr:   services.ErrWeatherAPIQuotaExceeded,
+			expectedHint:  "a cota é renovada",
+		},
+		{
+			// A chave é verificada com a cidade padrão
+			name:          "ViaCEP indisponível",
+			viaCEPStatus:  http.StatusBadRequest,
+			weatherStatus: http.StatusOK,
+			weatherBody:   `{"location":{"name":"Sao Paulo"},"current":{"temp_c":20,"temp_f":68}}`,
+			expectedCity:  "São Paulo",
+			expectedHint:  "verifique VIACEP_URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viaCEP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/01001000/json/" {
+					t.Errorf("ViaCEP path = %q, expected o CEP configurado", r.URL.Path)
+				}
+				w.WriteHeader(tt.viaCEPStatus)
+				w.Write([]byte(`{"cep":"01001-000","localidade":"São Paulo","uf":"SP"}`))
+			}))
+			defer viaCEP.Close()
+
+			var city string
+			weather := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				city = r.URL.Query().Get("q")
+				w.WriteHeader(tt.weatherStatus)
+				w.Write([]byte(tt.weatherBody))
+			}))
+			defer weather.Close()
+
+			report := Run(context.Background(),
+				services.NewViaCEPService(viaCEP.URL, retry.Policy{}),
+				services.NewWeatherService(weather.URL, apiKey, retry.Policy{}),
+				"01001000",
+			)
+
+			if city != tt.expectedCity {
+				t.Errorf("cidade consultada na WeatherAPI = %q, expected %q", city, tt.expectedCity)
+			}
+			if len(report.Steps) != 2 {
+				t.Fatalf("etapas = %+v, expected viacep e weatherapi", report.Steps)
+			}
+			if tt.expectedErr != nil && !errors.Is(report.Steps[1].Err, tt.expectedErr) {
+				t.Errorf("erro da WeatherAPI = %v, expected %v", report.Steps[1].Err, tt.expectedErr)
+			}
+			if ok := tt.expectedHint == ""; report.OK() != ok {
+				t.Fatalf("OK() = %v, expected %v: %v", report.OK(), ok, report.Err())
+			}
+
+			var out bytes.Buffer
+			if err := report.Write(&out); err != nil {
+				t.Fatalf("Write() err = %v", err)
+			}
+			if !strings.Contains(out.String(), tt.expectedHint) {
+				t.Errorf("relatório não contém %q:\n%s", tt.expectedHint, out.String())
+			}
+			if strings.Contains(out.String(), apiKey) {
+				t.Errorf("relatório contém a chave:\n%s", out.String())
+			}
+		})
+	}
+}
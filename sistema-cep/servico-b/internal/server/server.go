@@ -1,53 +1,168 @@
 package server
 
 import (
+	"crypto/tls"
+	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 
+	"servico-b/internal/admin"
+	"servico-b/internal/config"
 	"servico-b/internal/handlers"
+	"servico-b/internal/health"
+	"servico-b/internal/middleware"
+	"servico-b/internal/temperaturepb"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
-// Server representa o servidor HTTP
+// Server representa os servidores HTTP e gRPC
 type Server struct {
 	port               string
+	grpcPort           string
+	probePort          string
 	temperatureHandler *handlers.TemperatureHandler
+	temperatureGRPC    *handlers.TemperatureGRPCHandler
+	metricsHandler     http.Handler
+	health             *health.Checker
+	cors               *middleware.CORS
+	concurrency        *middleware.ConcurrencyLimiter
+	tlsConfig          *tls.Config
+	requestTimeout     atomic.Int64
 }
 
-// NewServer cria uma nova instância do servidor
-func NewServer(port string, temperatureHandler *handlers.TemperatureHandler) *Server {
-	return &Server{
-		port:               port,
+// NewServer cria uma nova instância do servidor. O checker responde às
+// sondas de liveness, readiness e startup. Com tlsConfig as portas HTTP e
+// gRPC usam TLS e as sondas também ficam na porta sem TLS de
+// cfg.TLS.ProbePort; nil as mantém sem TLS. metricsHandler nil deixa /metrics
+// fora da porta pública, quando ele é servido pelo admin
+func NewServer(cfg *config.Config, temperatureHandler *handlers.TemperatureHandler, temperatureGRPC *handlers.TemperatureGRPCHandler, metricsHandler http.Handler, checker *health.Checker, tlsConfig *tls.Config) *Server {
+	s := &Server{
+		port:               cfg.Port,
+		grpcPort:           cfg.GRPCPort,
+		probePort:          cfg.TLS.ProbePort,
 		temperatureHandler: temperatureHandler,
+		temperatureGRPC:    temperatureGRPC,
+		metricsHandler:     metricsHandler,
+		health:             checker,
+		cors:               middleware.NewCORS(cfg.CORS),
+		concurrency:        middleware.NewConcurrencyLimiter(cfg.Concurrency),
+		tlsConfig:          tlsConfig,
 	}
+	s.requestTimeout.Store(int64(cfg.RequestTimeout))
+	return s
 }
 
-// Start inicia o servidor HTTP
+// Reload aplica às próximas requisições o timeout e o CORS da nova
+// configuração, já validada
+func (s *Server) Reload(cfg *config.Config) {
+	s.requestTimeout.Store(int64(cfg.RequestTimeout))
+	s.cors.Update(cfg.CORS)
+}
+
+// timeout retorna o timeout atual das requisições
+func (s *Server) timeout() time.Duration {
+	return time.Duration(s.requestTimeout.Load())
+}
+
+// Start inicia os servidores HTTP e gRPC, retornando o primeiro erro. A
+// sonda de startup passa a responder 200 depois que todas as portas estão
+// abertas
 func (s *Server) Start() error {
 	// Configura as rotas
 	mux := s.setupRoutes()
 
-	// Inicia o servidor com instrumentação OpenTelemetry
-	handler := otelhttp.NewHandler(mux, "servico-b")
-	return http.ListenAndServe(":"+s.port, handler)
+	// Aplica os middlewares e inicia o servidor com instrumentação
+	// OpenTelemetry. As sondas não geram spans nem métricas
+	handler := otelhttp.NewHandler(middleware.RequestID(middleware.TraceID(middleware.PeerIdentity(s.cors.Handler(mux)))), "servico-b",
+		otelhttp.WithFilter(func(r *http.Request) bool { return !health.IsProbe(r) }))
+
+	httpListener, err := net.Listen("tcp", ":"+s.port)
+	if err != nil {
+		return err
+	}
+	grpcListener, err := net.Listen("tcp", ":"+s.grpcPort)
+	if err != nil {
+		httpListener.Close()
+		return err
+	}
+
+	// O mTLS exige certificado de todos os clientes da porta HTTP; as sondas
+	// do kubelet e do Docker Compose usam uma porta sem TLS
+	var probeListener net.Listener
+	if s.tlsConfig != nil {
+		probeListener, err = net.Listen("tcp", ":"+s.probePort)
+		if err != nil {
+			httpListener.Close()
+			grpcListener.Close()
+			return err
+		}
+	}
+	s.health.MarkStarted()
+
+	errs := make(chan error, 3)
+	go func() { errs <- s.serveHTTP(httpListener, handler) }()
+	go func() { errs <- s.serveGRPC(grpcListener) }()
+	if probeListener != nil {
+		go func() { errs <- http.Serve(probeListener, s.probeRoutes()) }()
+	}
+	return <-errs
+}
+
+// serveHTTP atende as requisições HTTP, com TLS se configurado
+func (s *Server) serveHTTP(listener net.Listener, handler http.Handler) error {
+	srv := &http.Server{Handler: handler, TLSConfig: s.tlsConfig}
+	if s.tlsConfig != nil {
+		// O certificado vem do GetCertificate da configuração TLS
+		return srv.ServeTLS(listener, "", "")
+	}
+	return srv.Serve(listener)
+}
+
+// serveGRPC atende as chamadas gRPC com instrumentação OpenTelemetry. As
+// chamadas passam pelos mesmos limites de concorrência e de prazo do HTTP
+func (s *Server) serveGRPC(listener net.Listener) error {
+	opts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			middleware.UnaryRequestID,
+			middleware.UnaryPeerIdentity,
+			s.concurrency.UnaryInterceptor(),
+			middleware.UnaryDeadlineFunc(s.timeout),
+		),
+	}
+	if s.tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.tlsConfig)))
+	}
+
+	srv := grpc.NewServer(opts...)
+	temperaturepb.RegisterTemperatureServiceServer(srv, s.temperatureGRPC)
+	return srv.Serve(listener)
+}
+
+// RegisterAdmin registra os endpoints administrativos dos componentes do
+// servidor
+func (s *Server) RegisterAdmin(r *admin.Registry) {
+	s.concurrency.RegisterAdmin(r)
 }
 
 // setupRoutes configura as rotas da aplicação
 func (s *Server) setupRoutes() *http.ServeMux {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/temperature", s.temperatureHandler.HandleTemperature)
-	mux.HandleFunc("/health", s.healthCheck)
+	mux.Handle("/temperature", s.concurrency.Handler(middleware.DeadlineFunc(s.timeout, http.HandlerFunc(s.temperatureHandler.HandleTemperature))))
+	if s.metricsHandler != nil {
+		mux.Handle("/metrics", s.metricsHandler)
+	}
+	s.health.Register(mux)
 	return mux
 }
 
-// healthCheck endpoint para verificação de saúde da aplicação
-func (s *Server) healthCheck(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status": "healthy", "service": "servico-b"}`))
+// probeRoutes configura as rotas da porta das sondas, sem TLS
+func (s *Server) probeRoutes() *http.ServeMux {
+	mux := http.NewServeMux()
+	s.health.Register(mux)
+	return mux
 }
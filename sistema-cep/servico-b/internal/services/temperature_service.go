@@ -5,15 +5,18 @@ import (
 	"fmt"
 
 	"servico-b/internal/models"
+	"servico-b/internal/singleflight"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TemperatureService orquestra a busca de localização e temperatura
 type TemperatureService struct {
 	viaCEPService  *ViaCEPService
 	weatherService *WeatherService
+	inflight       *singleflight.Group[*models.TemperatureInfo]
 }
 
 // NewTemperatureService cria uma nova instância do serviço de temperatura
@@ -21,10 +24,12 @@ func NewTemperatureService(viaCEPService *ViaCEPService, weatherService *Weather
 	return &TemperatureService{
 		viaCEPService:  viaCEPService,
 		weatherService: weatherService,
+		inflight:       singleflight.New[*models.TemperatureInfo]("GetTemperatureByCEP"),
 	}
 }
 
-// GetTemperatureByCEP busca a temperatura a partir de um CEP
+// GetTemperatureByCEP busca a temperatura a partir de um CEP. Buscas
+// simultâneas do mesmo CEP compartilham as chamadas à ViaCEP e à WeatherAPI
 func (t *TemperatureService) GetTemperatureByCEP(ctx context.Context, cep string) (*models.TemperatureInfo, error) {
 	tracer := otel.Tracer("servico-b")
 	ctx, span := tracer.Start(ctx, "GetTemperatureByCEP")
@@ -32,26 +37,39 @@ func (t *TemperatureService) GetTemperatureByCEP(ctx context.Context, cep string
 
 	span.SetAttributes(attribute.String("cep", cep))
 
+	temperature, _, err := t.inflight.Do(ctx, cep, func(ctx context.Context) (*models.TemperatureInfo, error) {
+		return t.lookupTemperature(ctx, cep)
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.String("result.city", temperature.City),
+		attribute.Float64("result.temp_c", temperature.TempC),
+	)
+
+	// Cada chamador recebe sua própria cópia do resultado compartilhado
+	result := *temperature
+	return &result, nil
+}
+
+// lookupTemperature consulta a ViaCEP e a WeatherAPI
+func (t *TemperatureService) lookupTemperature(ctx context.Context, cep string) (*models.TemperatureInfo, error) {
 	// 1. Busca informações de localização pelo CEP
 	location, err := t.viaCEPService.GetLocationByCEP(ctx, cep)
 	if err != nil {
-		span.SetStatus(codes.Error, "failed to get location")
 		return nil, fmt.Errorf("erro ao buscar localização: %w", err)
 	}
 
-	span.SetAttributes(attribute.String("location.city", location.City))
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("location.city", location.City))
 
 	// 2. Busca informações de temperatura pela localização
 	temperature, err := t.weatherService.GetTemperatureByLocation(ctx, location)
 	if err != nil {
-		span.SetStatus(codes.Error, "failed to get temperature")
 		return nil, fmt.Errorf("erro ao buscar temperatura: %w", err)
 	}
 
-	span.SetAttributes(
-		attribute.String("result.city", temperature.City),
-		attribute.Float64("result.temp_c", temperature.TempC),
-	)
-
 	return temperature, nil
 }
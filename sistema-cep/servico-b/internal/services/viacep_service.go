@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
-	"time"
+	"strings"
+	"sync/atomic"
 
+	"servico-b/internal/logging"
 	"servico-b/internal/models"
+	"servico-b/internal/retry"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
@@ -19,19 +21,32 @@ import (
 
 // ViaCEPService é responsável pela comunicação com a API ViaCEP
 type ViaCEPService struct {
-	baseURL string
+	baseURL atomic.Pointer[string]
 	client  *http.Client
+	// probe faz as verificações de disponibilidade, sem retentativas e sem
+	// spans
+	probe *http.Client
 }
 
-// NewViaCEPService cria uma nova instância do serviço ViaCEP
-func NewViaCEPService() *ViaCEPService {
-	return &ViaCEPService{
-		baseURL: "https://viacep.com.br/ws",
+// NewViaCEPService cria uma nova instância do serviço ViaCEP na URL base
+// informada. Falhas transitórias são repetidas conforme a política de
+// retentativas. As consultas não têm timeout fixo: são limitadas pelo prazo
+// do contexto, vindo da requisição, da readiness ou da verificação inicial
+func NewViaCEPService(baseURL string, retryPolicy retry.Policy) *ViaCEPService {
+	v := &ViaCEPService{
 		client: &http.Client{
-			Timeout:   10 * time.Second,
-			Transport: otelhttp.NewTransport(http.DefaultTransport),
+			Transport: retry.NewTransport(otelhttp.NewTransport(http.DefaultTransport), "viacep", retryPolicy),
 		},
 	}
+	v.probe = &http.Client{}
+	v.SetBaseURL(baseURL)
+	return v
+}
+
+// SetBaseURL troca a URL base usada nas próximas consultas
+func (v *ViaCEPService) SetBaseURL(baseURL string) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	v.baseURL.Store(&baseURL)
 }
 
 // GetLocationByCEP busca informações de localização pelo CEP
@@ -40,14 +55,14 @@ func (v *ViaCEPService) GetLocationByCEP(ctx context.Context, cep string) (*mode
 	ctx, span := tracer.Start(ctx, "ViaCEP.GetLocationByCEP")
 	defer span.End()
 
-	url := fmt.Sprintf("%s/%s/json/", v.baseURL, cep)
+	url := fmt.Sprintf("%s/%s/json/", *v.baseURL.Load(), cep)
 
 	span.SetAttributes(
 		attribute.String("viacep.cep", cep),
 		attribute.String("viacep.url", url),
 	)
 
-	log.Printf("Buscando informações do CEP %s na ViaCEP: %s", cep, url)
+	logging.Debugf(ctx, "Buscando informações do CEP %s na ViaCEP: %s", cep, url)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -83,7 +98,7 @@ func (v *ViaCEPService) GetLocationByCEP(ctx context.Context, cep string) (*mode
 
 	// Verifica se o CEP foi encontrado
 	if viaCEPResp.Erro.Bool() {
-		log.Printf("CEP %s não encontrado na ViaCEP", cep)
+		logging.Printf(ctx, "CEP %s não encontrado na ViaCEP", cep)
 		span.SetAttributes(attribute.Bool("viacep.found", false))
 		span.SetStatus(codes.Error, "CEP not found")
 		return nil, fmt.Errorf("CEP não encontrado")
@@ -91,7 +106,7 @@ func (v *ViaCEPService) GetLocationByCEP(ctx context.Context, cep string) (*mode
 
 	// Verifica se os campos essenciais estão presentes
 	if viaCEPResp.Localidade == "" {
-		log.Printf("CEP %s retornou dados incompletos da ViaCEP", cep)
+		logging.Warnf(ctx, "CEP %s retornou dados incompletos da ViaCEP", cep)
 		span.SetStatus(codes.Error, "incomplete location data")
 		return nil, fmt.Errorf("dados de localização incompletos")
 	}
@@ -107,7 +122,32 @@ func (v *ViaCEPService) GetLocationByCEP(ctx context.Context, cep string) (*mode
 		attribute.String("viacep.state", location.State),
 	)
 
-	log.Printf("CEP %s encontrado: %s/%s", cep, location.City, location.State)
+	logging.Debugf(ctx, "CEP %s encontrado: %s/%s", cep, location.City, location.State)
 
 	return location, nil
 }
+
+// probeCEP é o CEP consultado na verificação de disponibilidade (Praça da
+// Sé, São Paulo)
+const probeCEP = "01001000"
+
+// Ping verifica se a ViaCEP responde à consulta de um CEP conhecido
+func (v *ViaCEPService) Ping(ctx context.Context) error {
+	url := fmt.Sprintf("%s/%s/json/", *v.baseURL.Load(), probeCEP)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+
+	resp, err := v.probe.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao fazer requisição para ViaCEP: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ViaCEP retornou status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"servico-b/internal/retry"
+)
+
+func TestViaCEPPing(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "ViaCEP disponível", status: http.StatusOK},
+		{name: "ViaCEP indisponível", status: http.StatusServiceUnavailable, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := NewViaCEPService(server.URL, retry.Policy{}).Ping(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Ping() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if path != "/"+probeCEP+"/json/" {
+				t.Errorf("path = %q, expected a consulta de um CEP conhecido", path)
+			}
+		})
+	}
+
+	// Sem servidor a conexão é recusada
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	if err := NewViaCEPService(server.URL, retry.Policy{}).Ping(context.Background()); err == nil {
+		t.Error("Ping() sem servidor não retornou erro")
+	}
+}
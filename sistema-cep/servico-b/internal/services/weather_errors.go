@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Categorias de erro da verificação da chave da WeatherAPI. Use errors.Is
+// para identificar a categoria de um erro
+var (
+	// ErrWeatherAPIKeyRejected indica chave ausente, inválida ou desabilitada
+	ErrWeatherAPIKeyRejected = errors.New("weatherapi: API key rejected")
+	// ErrWeatherAPIQuotaExceeded indica que a cota mensal da chave acabou
+	ErrWeatherAPIQuotaExceeded = errors.New("weatherapi: quota exceeded")
+	// ErrWeatherAPIUnavailable indica falha de conexão ou resposta inesperada
+	ErrWeatherAPIUnavailable = errors.New("weatherapi: unavailable")
+)
+
+// Códigos de erro documentados da WeatherAPI relacionados à chave
+var weatherAPIKeyErrors = map[int]struct {
+	kind    error
+	message string
+}{
+	1002: {ErrWeatherAPIKeyRejected, "chave da WeatherAPI não informada"},
+	2006: {ErrWeatherAPIKeyRejected, "chave da WeatherAPI inválida"},
+	2007: {ErrWeatherAPIQuotaExceeded, "cota mensal da chave da WeatherAPI excedida"},
+	2008: {ErrWeatherAPIKeyRejected, "chave da WeatherAPI desabilitada"},
+	2009: {ErrWeatherAPIKeyRejected, "o plano da chave da WeatherAPI não permite esta consulta"},
+}
+
+// WeatherAPIError descreve uma falha na verificação da chave da WeatherAPI
+type WeatherAPIError struct {
+	Kind       error
+	StatusCode int
+	Code       int
+	Message    string
+	Err        error
+}
+
+func (e *WeatherAPIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Kind.Error()
+	}
+	switch {
+	case e.Code != 0:
+		msg = fmt.Sprintf("%s (status %d, código %d)", msg, e.StatusCode, e.Code)
+	case e.StatusCode != 0:
+		msg = fmt.Sprintf("%s (status %d)", msg, e.StatusCode)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+// Unwrap permite que errors.Is reconheça tanto a categoria quanto a causa
+func (e *WeatherAPIError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// newWeatherAPIError classifica a resposta de erro da WeatherAPI pelo código
+// do corpo {"error": {"code": ..., "message": ...}}
+func newWeatherAPIError(statusCode int, code int, message string) *WeatherAPIError {
+	if known, ok := weatherAPIKeyErrors[code]; ok {
+		return &WeatherAPIError{Kind: known.kind, StatusCode: statusCode, Code: code, Message: known.message}
+	}
+	if message == "" {
+		message = "resposta inesperada da WeatherAPI"
+	}
+	return &WeatherAPIError{Kind: ErrWeatherAPIUnavailable, StatusCode: statusCode, Code: code, Message: message}
+}
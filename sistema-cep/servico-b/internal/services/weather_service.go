@@ -5,39 +5,79 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
-	"time"
+	"strings"
+	"sync/atomic"
 
+	"servico-b/internal/logging"
 	"servico-b/internal/models"
+	"servico-b/internal/redact"
+	"servico-b/internal/retry"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 )
 
+// probeCity é a cidade consultada na verificação da chave, a mesma do CEP
+// consultado na verificação da ViaCEP
+const probeCity = "São Paulo"
+
 // WeatherService é responsável pela comunicação com a API WeatherAPI
 type WeatherService struct {
-	apiKey  string
-	baseURL string
+	apiKey  atomic.Pointer[string]
+	baseURL atomic.Pointer[string]
 	client  *http.Client
+	// probe faz as verificações da chave, sem retentativas e sem spans
+	probe *http.Client
 }
 
-// NewWeatherService cria uma nova instância do serviço Weather
-func NewWeatherService(apiKey string) *WeatherService {
-	return &WeatherService{
-		apiKey:  apiKey,
-		baseURL: "http://api.weatherapi.com/v1",
-		client: &http.Client{
-			Timeout:   10 * time.Second,
-			Transport: otelhttp.NewTransport(http.DefaultTransport),
-		},
+// NewWeatherService cria uma nova instância do serviço Weather na URL base
+// informada. Falhas transitórias são repetidas conforme a política de
+// retentativas. As consultas não têm timeout fixo: são limitadas pelo prazo
+// do contexto, vindo da requisição, da readiness ou da verificação inicial
+func NewWeatherService(baseURL, apiKey string, retryPolicy retry.Policy) *WeatherService {
+	w := &WeatherService{}
+	w.client = &http.Client{
+		// A chave é incluída abaixo do otelhttp para não ser registrada no
+		// url.full dos spans nem nos erros do http.Client
+		Transport: retry.NewTransport(otelhttp.NewTransport(&apiKeyTransport{next: http.DefaultTransport, key: w.currentAPIKey}), "weatherapi", retryPolicy),
+	}
+	w.probe = &http.Client{
+		Transport: &apiKeyTransport{next: http.DefaultTransport, key: w.currentAPIKey},
 	}
+	w.SetBaseURL(baseURL)
+	w.SetAPIKey(apiKey)
+	return w
+}
+
+// SetAPIKey troca a chave usada nas próximas consultas. A chave é
+// registrada para ser redigida de logs, spans e erros
+func (w *WeatherService) SetAPIKey(apiKey string) {
+	redact.Add(apiKey)
+	w.apiKey.Store(&apiKey)
+}
+
+// currentAPIKey retorna a chave atual
+func (w *WeatherService) currentAPIKey() string {
+	return *w.apiKey.Load()
+}
+
+// SetBaseURL troca a URL base usada nas próximas consultas
+func (w *WeatherService) SetBaseURL(baseURL string) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	w.baseURL.Store(&baseURL)
 }
 
-// GetTemperatureByLocation busca informações de temperatura pela localização
+// GetTemperatureByLocation busca informações de temperatura pela
+// localização. Os erros nunca contêm a chave da WeatherAPI
 func (w *WeatherService) GetTemperatureByLocation(ctx context.Context, location *models.LocationInfo) (*models.TemperatureInfo, error) {
+	tempInfo, err := w.getTemperatureByLocation(ctx, location)
+	return tempInfo, redact.Error(err)
+}
+
+func (w *WeatherService) getTemperatureByLocation(ctx context.Context, location *models.LocationInfo) (*models.TemperatureInfo, error) {
 	tracer := otel.Tracer("servico-b")
 	ctx, span := tracer.Start(ctx, "WeatherAPI.GetTemperatureByLocation")
 	defer span.End()
@@ -52,16 +92,15 @@ func (w *WeatherService) GetTemperatureByLocation(ctx context.Context, location
 	)
 
 	// Constrói a URL com parâmetros
-	apiURL := fmt.Sprintf("%s/current.json", w.baseURL)
+	apiURL := fmt.Sprintf("%s/current.json", *w.baseURL.Load())
 	params := url.Values{}
-	params.Add("key", w.apiKey)
-	params.Add("q", query) // url.Values.Add já faz o encoding automático
+	params.Add("q", query)  // url.Values.Add já faz o encoding automático
 	params.Add("aqi", "no") // Não precisamos de dados de qualidade do ar
 
 	fullURL := fmt.Sprintf("%s?%s", apiURL, params.Encode())
 	span.SetAttributes(attribute.String("weather.url", apiURL))
 
-	log.Printf("Buscando temperatura para %s na WeatherAPI", query)
+	logging.Debugf(ctx, "Buscando temperatura para %s na WeatherAPI", query)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
@@ -85,7 +124,7 @@ func (w *WeatherService) GetTemperatureByLocation(ctx context.Context, location
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("WeatherAPI retornou erro %d: %s", resp.StatusCode, string(body))
+		logging.Errorf(ctx, "WeatherAPI retornou erro %d: %s", resp.StatusCode, string(body))
 
 		// Tenta fazer parse da mensagem de erro
 		var errorResp map[string]interface{}
@@ -123,8 +162,63 @@ func (w *WeatherService) GetTemperatureByLocation(ctx context.Context, location
 		attribute.Float64("weather.temp_k", tempInfo.TempK),
 	)
 
-	log.Printf("Temperatura obtida para %s: %.1f°C, %.1f°F, %.1f K",
+	logging.Debugf(ctx, "Temperatura obtida para %s: %.1f°C, %.1f°F, %.1f K",
 		tempInfo.City, tempInfo.TempC, tempInfo.TempF, tempInfo.TempK)
 
 	return tempInfo, nil
 }
+
+// CheckAPIKey verifica se a chave atual é aceita pela WeatherAPI com a
+// consulta de uma cidade conhecida. Cada verificação consome uma consulta da
+// cota da chave
+func (w *WeatherService) CheckAPIKey(ctx context.Context) error {
+	return w.CheckCity(ctx, probeCity)
+}
+
+// CheckCity consulta a temperatura da cidade, sem retentativas e sem spans,
+// para verificar a chave e a conectividade com a WeatherAPI. Os erros são
+// *WeatherAPIError e nunca contêm a chave
+func (w *WeatherService) CheckCity(ctx context.Context, city string) error {
+	params := url.Values{}
+	params.Add("q", city)
+	params.Add("aqi", "no")
+	fullURL := fmt.Sprintf("%s/current.json?%s", *w.baseURL.Load(), params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return &WeatherAPIError{Kind: ErrWeatherAPIUnavailable, Message: "erro ao criar requisição", Err: err}
+	}
+	resp, err := w.probe.Do(req)
+	if err != nil {
+		return redact.Error(&WeatherAPIError{Kind: ErrWeatherAPIUnavailable, Message: "erro ao fazer requisição para WeatherAPI", Err: err})
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var errorResp struct {
+		Error struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&errorResp)
+	return redact.Error(newWeatherAPIError(resp.StatusCode, errorResp.Error.Code, errorResp.Error.Message))
+}
+
+// apiKeyTransport inclui a chave da WeatherAPI na query string de cada
+// requisição
+type apiKeyTransport struct {
+	next http.RoundTripper
+	key  func() string
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	query := req.URL.Query()
+	query.Set("key", t.key())
+	req.URL.RawQuery = query.Encode()
+	return t.next.RoundTrip(req)
+}
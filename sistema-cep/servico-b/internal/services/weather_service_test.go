@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"servico-b/internal/models"
+	"servico-b/internal/redact"
+	"servico-b/internal/retry"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWeatherServiceNeverLeaksAPIKey(t *testing.T) {
+	const apiKey = "chave-weatherapi-de-teste"
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{
+			name:   "Sucesso",
+			status: http.StatusOK,
+			body:   `{"location":{"name":"São Paulo"},"current":{"temp_c":20,"temp_f":68}}`,
+		},
+		{
+			// Pior caso: a API devolve a chave na mensagem de erro
+			name:    "Erro da WeatherAPI com a chave na resposta",
+			status:  http.StatusForbidden,
+			body:    `{"error":{"code":2008,"message":"API key ` + apiKey + ` has been disabled."}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receivedKey string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				receivedKey = r.URL.Query().Get("key")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			// Captura os spans antes e depois da redação e a saída do log
+			raw := tracetest.NewSpanRecorder()
+			exported := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(
+				sdktrace.WithSpanProcessor(raw),
+				sdktrace.WithSyncer(redact.NewSpanExporter(exported)),
+			)
+			defer otel.SetTracerProvider(otel.GetTracerProvider())
+			otel.SetTracerProvider(tp)
+
+			var logs bytes.Buffer
+			defer log.SetOutput(log.Writer())
+			log.SetOutput(redact.Writer(&logs))
+
+			service := NewWeatherService(server.URL, apiKey, retry.Policy{})
+			_, err := service.GetTemperatureByLocation(context.Background(), &models.LocationInfo{City: "São Paulo", State: "SP"})
+
+			if receivedKey != apiKey {
+				t.Fatalf("chave recebida pela WeatherAPI = %q, expected a chave configurada", receivedKey)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTemperatureByLocation() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && strings.Contains(err.Error(), apiKey) {
+				t.Errorf("erro contém a chave: %v", err)
+			}
+			if strings.Contains(logs.String(), apiKey) {
+				t.Errorf("log contém a chave:\n%s", logs.String())
+			}
+
+			spans := exported.GetSpans()
+			if len(spans) == 0 {
+				t.Fatal("nenhum span exportado")
+			}
+			for _, span := range spans {
+				if dump := fmt.Sprintf("%+v", span); strings.Contains(dump, apiKey) {
+					t.Errorf("span exportado %s contém a chave:\n%s", span.Name, dump)
+				}
+			}
+
+			// A chave é incluída abaixo do otelhttp, então nem o span do
+			// cliente HTTP antes da redação a registra
+			for _, span := range raw.Ended() {
+				for _, attr := range span.Attributes() {
+					if attr.Key == "url.full" && strings.Contains(attr.Value.Emit(), apiKey) {
+						t.Errorf("url.full do span %s contém a chave: %s", span.Name(), attr.Value.Emit())
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCheckAPIKey(t *testing.T) {
+	const apiKey = "chave-weatherapi-de-teste"
+
+	tests := []struct {
+		name            string
+		status          int
+		body            string
+		expectedKind    error
+		expectedMessage string
+	}{
+		{
+			name:   "Chave aceita",
+			status: http.StatusOK,
+			body:   `{"location":{"name":"Sao Paulo"},"current":{"temp_c":20,"temp_f":68}}`,
+		},
+		{
+			name:            "Chave inválida",
+			status:          http.StatusUnauthorized,
+			body:            `{"error":{"code":2006,"message":"API key is invalid."}}`,
+			expectedKind:    ErrWeatherAPIKeyRejected,
+			expectedMessage: "chave da WeatherAPI inválida (status 401, código 2006)",
+		},
+		{
+			name:            "Cota excedida",
+			status:          http.StatusForbidden,
+			body:            `{"error":{"code":2007,"message":"API key has exceeded calls per month quota."}}`,
+			expectedKind:    ErrWeatherAPIQuotaExceeded,
+			expectedMessage: "cota mensal da chave da WeatherAPI excedida (status 403, código 2007)",
+		},
+		{
+			name:            "Chave desabilitada com a chave na resposta",
+			status:          http.StatusForbidden,
+			body:            `{"error":{"code":2008,"message":"API key ` + apiKey + ` has been disabled."}}`,
+			expectedKind:    ErrWeatherAPIKeyRejected,
+			expectedMessage: "chave da WeatherAPI desabilitada (status 403, código 2008)",
+		},
+		{
+			name:            "Erro interno sem corpo",
+			status:          http.StatusBadGateway,
+			expectedKind:    ErrWeatherAPIUnavailable,
+			expectedMessage: "resposta inesperada da WeatherAPI (status 502)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receivedKey string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				receivedKey = r.URL.Query().Get("key")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			err := NewWeatherService(server.URL, apiKey, retry.Policy{}).CheckAPIKey(context.Background())
+
+			if receivedKey != apiKey {
+				t.Fatalf("chave recebida pela WeatherAPI = %q, expected a chave configurada", receivedKey)
+			}
+			if tt.expectedKind == nil {
+				if err != nil {
+					t.Fatalf("CheckAPIKey() err = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.expectedKind) {
+				t.Fatalf("CheckAPIKey() err = %v, expected %v", err, tt.expectedKind)
+			}
+			if err.Error() != tt.expectedMessage {
+				t.Errorf("CheckAPIKey() err = %q, expected %q", err.Error(), tt.expectedMessage)
+			}
+		})
+	}
+}
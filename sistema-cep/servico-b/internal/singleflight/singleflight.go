@@ -0,0 +1,116 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// call é uma execução em andamento compartilhada pelos chamadores da chave
+type call[T any] struct {
+	done    chan struct{}
+	val     T
+	err     error
+	callers int
+	ctx     *flightContext
+	leader  trace.SpanContext
+}
+
+// Group agrupa chamadas concorrentes com a mesma chave em uma única
+// execução. A execução não depende do contexto de nenhum chamador
+// específico: ela dura até o prazo mais longo entre os chamadores e é
+// cancelada quando todos eles desistem
+type Group[T any] struct {
+	name   string
+	tracer trace.Tracer
+
+	mu    sync.Mutex
+	calls map[string]*call[T]
+}
+
+// New cria um grupo. O nome identifica o grupo nos spans
+func New[T any](name string) *Group[T] {
+	return &Group[T]{
+		name:   name,
+		tracer: otel.Tracer("servico-b"),
+		calls:  make(map[string]*call[T]),
+	}
+}
+
+// Do executa fn para a chave, ou aguarda a execução já em andamento. O
+// primeiro chamador (líder) executa fn com os valores do seu contexto, mas
+// sem o seu cancelamento; o prazo da execução é o mais longo entre os
+// chamadores. Os demais recebem o mesmo resultado e um span ligado ao span
+// do líder. Cada chamador desiste quando o próprio contexto termina.
+// shared indica se o resultado foi compartilhado com outros chamadores
+func (g *Group[T]) Do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (val T, shared bool, err error) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		c.callers++
+		c.ctx.extend(ctx)
+		g.mu.Unlock()
+
+		ctx, span := g.tracer.Start(ctx, g.name+".coalesced",
+			trace.WithLinks(trace.Link{SpanContext: c.leader}),
+			trace.WithAttributes(attribute.String("singleflight.key", key)),
+		)
+		defer span.End()
+
+		return g.wait(ctx, key, c, true)
+	}
+
+	c := &call[T]{
+		done:    make(chan struct{}),
+		callers: 1,
+		ctx:     newFlightContext(ctx),
+		leader:  trace.SpanContextFromContext(ctx),
+	}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	go func() {
+		defer c.ctx.cancel(context.Canceled)
+
+		c.val, c.err = fn(c.ctx)
+
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	return g.wait(ctx, key, c, false)
+}
+
+// wait aguarda o resultado ou o cancelamento do chamador. Quando o último
+// chamador desiste, a execução é cancelada e a chave liberada
+func (g *Group[T]) wait(ctx context.Context, key string, c *call[T], coalesced bool) (T, bool, error) {
+	select {
+	case <-c.done:
+		g.mu.Lock()
+		shared := c.callers > 1
+		g.mu.Unlock()
+
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("singleflight.shared", shared))
+		return c.val, shared || coalesced, c.err
+
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.callers--
+		if c.callers == 0 {
+			c.ctx.cancel(context.Canceled)
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+
+		var zero T
+		return zero, coalesced, ctx.Err()
+	}
+}
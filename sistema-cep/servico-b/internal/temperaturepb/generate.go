@@ -0,0 +1,4 @@
+// Package temperaturepb contém o contrato gRPC do Serviço B, gerado a partir
+// de temperature.proto com `make proto` na raiz de sistema-cep, que também
+// gera o código do Serviço A
+package temperaturepb